@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AlertSchedule is a weekly window during which a service alerts, e.g.
+// weekdays 09:00–18:00 for internal tools. Services name one with
+// alert_schedule; services without one alert around the clock.
+type AlertSchedule struct {
+	Days     []string `json:"days"`
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Timezone string   `json:"timezone"`
+
+	loc      *time.Location
+	weekdays []time.Weekday
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (s *AlertSchedule) validate() error {
+	for _, v := range []string{s.Start, s.End} {
+		if _, err := time.Parse("15:04", v); err != nil {
+			return fmt.Errorf("start and end must be HH:MM")
+		}
+	}
+	if s.Start >= s.End {
+		return fmt.Errorf("start must be before end")
+	}
+
+	s.weekdays = nil
+	for _, d := range s.Days {
+		wd, ok := weekdayNames[strings.ToLower(d)]
+		if !ok {
+			return fmt.Errorf("unknown day %q, use mon to sun", d)
+		}
+		s.weekdays = append(s.weekdays, wd)
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	s.loc = loc
+	return nil
+}
+
+// open reports whether now falls within the schedule. Without days, every
+// day of the week is included.
+func (s AlertSchedule) open(now time.Time) bool {
+	if s.loc != nil {
+		now = now.In(s.loc)
+	}
+	if len(s.weekdays) > 0 && !slices.Contains(s.weekdays, now.Weekday()) {
+		return false
+	}
+	clock := now.Format("15:04")
+	return clock >= s.Start && clock < s.End
+}
+
+// alerting reports whether svc may alert at now.
+func (c Config) alerting(svc Service, now time.Time) bool {
+	if svc.AlertSchedule == "" {
+		return true
+	}
+	s, ok := c.AlertSchedules[svc.AlertSchedule]
+	return !ok || s.open(now)
+}
+
+// scheduledAlerts drops the transitions of services outside their alert
+// schedule. A service that went down out of hours is alerted when its
+// schedule opens if it is still down; if it recovered in the meantime,
+// neither the outage nor the recovery is sent. Callers hold b.mu.
+func (b *Bot) scheduledAlerts(transitions []Transition, now time.Time) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		state := b.states[serviceKey(t.Service)]
+		deferred := state != nil && state.AlertDeferred
+		switch {
+		case !b.cfg.alerting(t.Service, now):
+			if state != nil && t.Type != "change" {
+				state.AlertDeferred = t.Type == "down"
+			}
+		case deferred && t.Type == "up":
+			state.AlertDeferred = false
+		case deferred:
+			// The held back alert below carries the current error.
+		default:
+			out = append(out, t)
+		}
+	}
+
+	for _, svc := range b.services() {
+		state := b.states[serviceKey(svc)]
+		if state == nil || !state.AlertDeferred || !b.cfg.alerting(svc, now) {
+			continue
+		}
+		state.AlertDeferred = false
+		if state.IsDown {
+			out = append(out, Transition{
+				Service:     svc,
+				ServiceName: fmt.Sprintf("%s (%s)", svc.Name, svc.Env),
+				Type:        "down",
+				Error:       state.LastError,
+				DownFor:     now.Sub(state.DownSince),
+			})
+		}
+	}
+	return out
+}
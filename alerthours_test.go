@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertScheduleOpen(t *testing.T) {
+	s := AlertSchedule{Days: []string{"mon", "Tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00", Timezone: "UTC"}
+	if err := s.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	// 2024-05-01 is a Wednesday.
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 5, 1, 17, 59, 0, 0, time.UTC), true},
+		{time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 5, 1, 8, 59, 0, 0, time.UTC), false},
+		{time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, c := range cases {
+		if got := s.open(c.at); got != c.want {
+			t.Errorf("open(%s) = %v, want %v", c.at.Format(time.RFC1123), got, c.want)
+		}
+	}
+
+	for _, bad := range []AlertSchedule{
+		{Start: "18:00", End: "09:00"},
+		{Start: "9am", End: "18:00"},
+		{Start: "09:00", End: "18:00", Days: []string{"funday"}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestScheduledAlerts(t *testing.T) {
+	internal := Service{Name: "wiki", Env: "production", AlertSchedule: "business"}
+	prod := Service{Name: "api", Env: "production"}
+	b := newTestBot(internal, prod)
+
+	s := AlertSchedule{Start: "09:00", End: "18:00", Timezone: "UTC"}
+	if err := s.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	b.cfg.AlertSchedules = map[string]AlertSchedule{"business": s}
+
+	night := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	b.states[serviceKey(internal)] = &ServiceState{IsDown: true, DownSince: night, LastError: "timeout"}
+	b.states[serviceKey(prod)] = &ServiceState{IsDown: true, DownSince: night}
+
+	got := b.scheduledAlerts([]Transition{
+		{Service: internal, Type: "down", Error: "timeout"},
+		{Service: prod, Type: "down"},
+	}, night)
+	if len(got) != 1 || got[0].Service.Name != "api" {
+		t.Fatalf("expected only the round-the-clock service to alert at night, got %+v", got)
+	}
+
+	morning := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	got = b.scheduledAlerts(nil, morning)
+	if len(got) != 1 || got[0].Type != "down" || got[0].Error != "timeout" || got[0].DownFor != 6*time.Hour {
+		t.Fatalf("expected the held back outage when the schedule opened, got %+v", got)
+	}
+	if got := b.scheduledAlerts(nil, morning.Add(time.Minute)); len(got) != 0 {
+		t.Errorf("expected the held back outage to be sent once, got %+v", got)
+	}
+
+	// An outage that ends before the schedule opens isn't sent at all.
+	b.scheduledAlerts([]Transition{{Service: internal, Type: "down"}}, night)
+	b.states[serviceKey(internal)].IsDown = false
+	if got := b.scheduledAlerts([]Transition{{Service: internal, Type: "up"}}, night.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("expected the recovery to be dropped out of hours, got %+v", got)
+	}
+	if got := b.scheduledAlerts(nil, morning); len(got) != 0 {
+		t.Errorf("expected nothing once the service recovered, got %+v", got)
+	}
+}
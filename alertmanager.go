@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// alertmanagerPayload is the part of an Alertmanager webhook we use.
+type alertmanagerPayload struct {
+	Alerts []alertmanagerAlert `json:"alerts"`
+}
+
+type alertmanagerAlert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// id identifies the alert across notifications. Older Alertmanagers don't
+// send a fingerprint, so fall back to the sorted labels.
+func (a alertmanagerAlert) id() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	pairs := make([]string, 0, len(a.Labels))
+	for k, v := range a.Labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (a alertmanagerAlert) message() string {
+	if s := a.Annotations["summary"]; s != "" {
+		return s
+	}
+	return a.Labels["alertname"]
+}
+
+// ingestAlertmanager applies a webhook to the services sourced from
+// Alertmanager whose match labels the alerts carry, and returns how many
+// alerts matched a service. Routes sending here need send_resolved, or
+// alerts only clear when their endsAt passes.
+func (b *Bot) ingestAlertmanager(payload alertmanagerPayload) int {
+	matched := 0
+	for _, a := range payload.Alerts {
+		hit := false
+		for _, svc := range b.services() {
+			if svc.Source != sourceAlertmanager || !matchesLabels(svc, a.Labels) {
+				continue
+			}
+			hit = true
+			if a.Status == "firing" {
+				b.external.fire(serviceKey(svc), a.id(), externalAlert{Error: a.message(), Until: a.EndsAt})
+			} else {
+				b.external.resolve(serviceKey(svc), a.id())
+			}
+		}
+		if hit {
+			matched++
+		}
+	}
+	return matched
+}
+
+func (b *Bot) handleAlertmanager(w http.ResponseWriter, r *http.Request) {
+	var payload alertmanagerPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"matched": b.ingestAlertmanager(payload)})
+}
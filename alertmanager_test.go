@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleAlertmanager(t *testing.T) {
+	kafka := Service{Name: "kafka", Env: "production", Source: sourceAlertmanager, Match: map[string]string{"job": "kafka", "env": "prod"}}
+	b := newTestBot(kafka, Service{Name: "api", Env: "production"})
+	mux := b.routes()
+
+	post := func(body string) map[string]int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, localRequest(http.MethodPost, "/api/v1/ingest/alertmanager", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var resp map[string]int
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return resp
+	}
+
+	firing := `{"alerts":[
+		{"status":"firing","labels":{"alertname":"KafkaLag","job":"kafka","env":"prod"},"annotations":{"summary":"consumer lag above 10k"},"fingerprint":"f1","endsAt":"0001-01-01T00:00:00Z"},
+		{"status":"firing","labels":{"alertname":"KafkaLag","job":"kafka","env":"staging"},"fingerprint":"f2"}
+	]}`
+	if resp := post(firing); resp["matched"] != 1 {
+		t.Errorf("expected one alert to match, got %v", resp)
+	}
+	if r := b.external.result(kafka, time.Now()); r.Up || r.Error != "consumer lag above 10k" {
+		t.Errorf("expected kafka to be down, got %+v", r)
+	}
+
+	post(`{"alerts":[{"status":"resolved","labels":{"alertname":"KafkaLag","job":"kafka","env":"prod"},"fingerprint":"f1"}]}`)
+	if r := b.external.result(kafka, time.Now()); !r.Up {
+		t.Errorf("expected kafka to recover, got %+v", r)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, localRequest(http.MethodPost, "/api/v1/ingest/alertmanager", strings.NewReader("nope")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad body, got %d", rec.Code)
+	}
+}
+
+func TestAlertmanagerAlertID(t *testing.T) {
+	a := alertmanagerAlert{Labels: map[string]string{"b": "2", "a": "1"}}
+	if got := a.id(); got != "a=1,b=2" {
+		t.Errorf("unexpected id %q", got)
+	}
+	if got := (alertmanagerAlert{Labels: map[string]string{"alertname": "X"}}).message(); got != "X" {
+		t.Errorf("expected the alert name without a summary, got %q", got)
+	}
+}
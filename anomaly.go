@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+)
+
+// LatencyAnomalyConfig flags services that are up but much slower than
+// usual. Each service keeps an exponentially weighted mean and variance of
+// its latency; a check more than sensitivity standard deviations and at
+// least min_delta_ms above the mean is an anomaly, once min_samples checks
+// have built the baseline.
+type LatencyAnomalyConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Sensitivity float64 `json:"sensitivity"`
+	MinSamples  int     `json:"min_samples"`
+	MinDeltaMs  int     `json:"min_delta_ms"`
+	Alpha       float64 `json:"alpha"`
+}
+
+func (c *LatencyAnomalyConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Alpha < 0 || c.Alpha >= 1 {
+		return fmt.Errorf("latency_anomaly.alpha must be between 0 and 1, got %g", c.Alpha)
+	}
+	if c.Sensitivity <= 0 {
+		c.Sensitivity = 3
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 20
+	}
+	if c.MinDeltaMs <= 0 {
+		c.MinDeltaMs = 100
+	}
+	if c.Alpha == 0 {
+		c.Alpha = 0.1
+	}
+	return nil
+}
+
+// latencyAnomaly is a service that became anomalously slow this cycle.
+type latencyAnomaly struct {
+	Service  Service
+	Latency  time.Duration
+	Baseline time.Duration
+}
+
+// detectAnomalies updates every service's latency baseline and returns the
+// services whose anomaly started this cycle. Failed checks don't touch the
+// baseline; they are the fail threshold's business.
+func detectAnomalies(results []CheckResult, states map[string]*ServiceState, cfg LatencyAnomalyConfig) []latencyAnomaly {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var started []latencyAnomaly
+	for _, r := range results {
+		state := states[serviceKey(r.Service)]
+		if state == nil || !r.Up {
+			continue
+		}
+
+		ms := float64(r.Latency) / float64(time.Millisecond)
+		anomalous := false
+		if state.LatencySamples >= cfg.MinSamples {
+			delta := ms - state.LatencyMean
+			anomalous = delta >= float64(cfg.MinDeltaMs) && delta > cfg.Sensitivity*math.Sqrt(state.LatencyVar)
+		}
+
+		if anomalous && !state.LatencyAnomaly {
+			started = append(started, latencyAnomaly{
+				Service:  r.Service,
+				Latency:  r.Latency,
+				Baseline: time.Duration(state.LatencyMean * float64(time.Millisecond)),
+			})
+		}
+		state.LatencyAnomaly = anomalous
+		state.updateBaseline(ms, cfg.Alpha)
+	}
+	return started
+}
+
+// updateBaseline folds a latency sample, in milliseconds, into the
+// exponentially weighted mean and variance.
+func (s *ServiceState) updateBaseline(ms float64, alpha float64) {
+	if s.LatencySamples == 0 {
+		s.LatencyMean, s.LatencyVar = ms, 0
+	} else {
+		diff := ms - s.LatencyMean
+		incr := alpha * diff
+		s.LatencyMean += incr
+		s.LatencyVar = (1 - alpha) * (s.LatencyVar + diff*incr)
+	}
+	s.LatencySamples++
+}
+
+// sendAnomalyWarnings posts a warning for the new anomalies on every board
+// showing the service, leaving out muted services and those outside their
+// alert schedule.
+func (b *Bot) sendAnomalyWarnings(anomalies []latencyAnomaly, now time.Time) {
+	for _, bc := range b.boards {
+		var lines []string
+		for _, a := range anomalies {
+			if !bc.matches(a.Service) {
+				continue
+			}
+			if state := b.states[serviceKey(a.Service)]; state != nil && state.muted(now) || !b.cfg.alerting(a.Service, now) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("• *%s (%s)*: `%dms`, usually ~%dms", a.Service.Name, a.Service.Env, a.Latency.Milliseconds(), a.Baseline.Milliseconds()))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s *%s*\n%s", b.cfg.Theme.SlowEmoji, b.cfg.Theme.SlowTitle, strings.Join(lines, "\n"))
+		if err := b.postBoardNotice(bc, msg); err != nil {
+			slog.Error("failed to post latency warning", "board", bc.Name, "err", err)
+		}
+	}
+}
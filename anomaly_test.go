@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	cfg := LatencyAnomalyConfig{Enabled: true}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	svc := Service{Name: "api", Env: "production"}
+	states := map[string]*ServiceState{serviceKey(svc): {}}
+	check := func(ms int) []latencyAnomaly {
+		return detectAnomalies([]CheckResult{{Service: svc, Up: true, Latency: time.Duration(ms) * time.Millisecond}}, states, cfg)
+	}
+
+	// Build a baseline around 100ms with a little jitter.
+	for i := range cfg.MinSamples {
+		if got := check(95 + i%10); got != nil {
+			t.Fatalf("sample %d: expected no anomaly while warming up, got %+v", i, got)
+		}
+	}
+
+	if got := check(150); got != nil {
+		t.Errorf("expected a 50ms bump to stay under min_delta_ms, got %+v", got)
+	}
+
+	got := check(900)
+	if len(got) != 1 || got[0].Latency != 900*time.Millisecond {
+		t.Fatalf("expected an anomaly at 900ms, got %+v", got)
+	}
+	if got[0].Baseline < 90*time.Millisecond || got[0].Baseline > 120*time.Millisecond {
+		t.Errorf("expected a baseline near 100ms, got %v", got[0].Baseline)
+	}
+	if !states[serviceKey(svc)].LatencyAnomaly {
+		t.Errorf("expected the state to be flagged")
+	}
+
+	if got := check(950); got != nil {
+		t.Errorf("expected an ongoing anomaly to warn only once, got %+v", got)
+	}
+
+	check(100)
+	if states[serviceKey(svc)].LatencyAnomaly {
+		t.Errorf("expected the anomaly to clear once latency is back to normal")
+	}
+}
+
+func TestDetectAnomaliesIgnoresFailures(t *testing.T) {
+	cfg := LatencyAnomalyConfig{Enabled: true}
+	cfg.validate()
+
+	svc := Service{Name: "api", Env: "production"}
+	states := map[string]*ServiceState{serviceKey(svc): {}}
+	detectAnomalies([]CheckResult{{Service: svc, Up: false, Latency: 10 * time.Second}}, states, cfg)
+
+	if states[serviceKey(svc)].LatencySamples != 0 {
+		t.Errorf("expected failed checks to stay out of the baseline")
+	}
+}
+
+func TestRenderServiceLineShowsAnomaly(t *testing.T) {
+	svc := Service{Name: "api", Env: "production"}
+	states := map[string]*ServiceState{serviceKey(svc): {LatencyAnomaly: true, LatencyMean: 120}}
+	line := renderServiceLine(CheckResult{Service: svc, Up: true, Latency: 900 * time.Millisecond}, states, boardOptions{theme: defaultTheme})
+
+	if !strings.Contains(line, "🐢 _slow, usually ~120ms_") {
+		t.Errorf("expected the board line to flag the slowdown, got %q", line)
+	}
+}
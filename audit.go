@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// AuditLogConfig appends every check result and transition to path as JSON
+// lines. The file is rotated once it reaches max_size_mb, keeping max_files
+// older copies.
+type AuditLogConfig struct {
+	Enabled   bool   `json:"enabled"`
+	Path      string `json:"path"`
+	MaxSizeMB int    `json:"max_size_mb"`
+	MaxFiles  int    `json:"max_files"`
+}
+
+func (c *AuditLogConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Path == "" {
+		c.Path = "audit.jsonl"
+	}
+	if c.MaxSizeMB < 0 || c.MaxFiles < 0 {
+		return fmt.Errorf("audit_log.max_size_mb and audit_log.max_files can't be negative")
+	}
+	if c.MaxSizeMB == 0 {
+		c.MaxSizeMB = 100
+	}
+	if c.MaxFiles == 0 {
+		c.MaxFiles = defaultLogBackups
+	}
+	return nil
+}
+
+// auditEntry is one line of the audit log. Kind is "check", "transition" or
+// "blackout"; the fields that don't apply to it are left out.
+type auditEntry struct {
+	Time       time.Time  `json:"time"`
+	Kind       string     `json:"kind"`
+	Service    string     `json:"service"`
+	Env        string     `json:"env"`
+	Up         *bool      `json:"up,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+	LatencyMs  *int64     `json:"latency_ms,omitempty"`
+	Type       string     `json:"type,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	PrevError  string     `json:"prev_error,omitempty"`
+	Downtime   string     `json:"downtime,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	By         string     `json:"by,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+type auditLog struct {
+	w io.WriteCloser
+}
+
+func openAuditLog(cfg AuditLogConfig) (*auditLog, error) {
+	f, err := openRotatingFile(cfg.Path, int64(cfg.MaxSizeMB)<<20, cfg.MaxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &auditLog{w: f}, nil
+}
+
+// record writes the results and transitions of one cycle. A nil log is a
+// no-op, so callers don't need to check whether auditing is enabled.
+func (a *auditLog) record(at time.Time, results []CheckResult, transitions []Transition) {
+	if a == nil {
+		return
+	}
+
+	enc := json.NewEncoder(a.w)
+	for _, r := range results {
+		up, latency := r.Up, r.Latency.Milliseconds()
+		entry := auditEntry{
+			Time:       at,
+			Kind:       "check",
+			Service:    r.Service.Name,
+			Env:        r.Service.Env,
+			Up:         &up,
+			StatusCode: r.StatusCode,
+			LatencyMs:  &latency,
+			Error:      r.Error,
+		}
+		if err := enc.Encode(entry); err != nil {
+			slog.Error("failed to write audit log", "err", err)
+			return
+		}
+	}
+
+	for _, t := range transitions {
+		entry := auditEntry{
+			Time:      at,
+			Kind:      "transition",
+			Service:   t.Service.Name,
+			Env:       t.Service.Env,
+			Type:      t.Type,
+			Error:     t.Error,
+			PrevError: t.PrevError,
+			Downtime:  t.Downtime,
+		}
+		if err := enc.Encode(entry); err != nil {
+			slog.Error("failed to write audit log", "err", err)
+			return
+		}
+	}
+}
+
+// recordBlackout notes that alerts for svc were silenced until the given
+// time.
+func (a *auditLog) recordBlackout(at time.Time, svc Service, until time.Time, by string, reason string) {
+	if a == nil {
+		return
+	}
+
+	entry := auditEntry{
+		Time:    at,
+		Kind:    "blackout",
+		Service: svc.Name,
+		Env:     svc.Env,
+		Until:   &until,
+		By:      by,
+		Reason:  reason,
+	}
+	if err := json.NewEncoder(a.w).Encode(entry); err != nil {
+		slog.Error("failed to write audit log", "err", err)
+	}
+}
+
+func (a *auditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	return a.w.Close()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditLogRecord(t *testing.T) {
+	cfg := AuditLogConfig{Enabled: true, Path: filepath.Join(t.TempDir(), "audit.jsonl")}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	a, err := openAuditLog(cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	svc := Service{Name: "api", Env: "production"}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a.record(at,
+		[]CheckResult{{Service: svc, Up: false, StatusCode: 503, Latency: 120 * time.Millisecond, Error: "status 503"}},
+		[]Transition{{Service: svc, Type: "down", Error: "status 503"}},
+	)
+	a.Close()
+
+	f, err := os.Open(cfg.Path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	defer f.Close()
+
+	var entries []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(entries))
+	}
+	check, transition := entries[0], entries[1]
+	if check["kind"] != "check" || check["up"] != false || check["latency_ms"] != float64(120) || check["status_code"] != float64(503) {
+		t.Errorf("unexpected check entry: %v", check)
+	}
+	if transition["kind"] != "transition" || transition["type"] != "down" || transition["service"] != "api" {
+		t.Errorf("unexpected transition entry: %v", transition)
+	}
+	if _, ok := transition["up"]; ok {
+		t.Errorf("expected check-only fields to be omitted from transitions: %v", transition)
+	}
+	if check["time"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected time: %v", check["time"])
+	}
+}
+
+func TestAuditLogNilIsNoop(t *testing.T) {
+	var a *auditLog
+	a.record(time.Now(), []CheckResult{{Up: true}}, nil)
+	if err := a.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
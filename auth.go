@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Token scopes: read covers the status, feed, calendar, badge, event and
+// metrics endpoints; ingest covers the endpoints alert sources post to;
+// admin covers everything, including the endpoints that change state.
+const (
+	scopeRead   = "read"
+	scopeIngest = "ingest"
+	scopeAdmin  = "admin"
+)
+
+// APIAuthConfig protects the HTTP API. Token values never live in the
+// config: each is read from an environment variable or a secret file. With
+// allowed_ips set, only those addresses or CIDR ranges may call the API; the
+// health endpoints stay open for probes.
+//
+// Without tokens, the routes that change state only answer callers on the
+// same host. Behind a reverse proxy or sidecar on that host every request
+// comes from there, so such deployments must configure an admin token.
+type APIAuthConfig struct {
+	Tokens     []APIToken `json:"tokens"`
+	AllowedIPs []string   `json:"allowed_ips"`
+
+	allowed []netip.Prefix
+}
+
+type APIToken struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	Env   string `json:"env"`
+	File  string `json:"file"`
+
+	value string
+}
+
+func (c *APIAuthConfig) validate() error {
+	// The token the service management API started out with is kept as an
+	// admin token.
+	if os.Getenv("STATUS_BOT_API_TOKEN") != "" {
+		c.Tokens = append(c.Tokens, APIToken{Name: "STATUS_BOT_API_TOKEN", Scope: scopeAdmin, Env: "STATUS_BOT_API_TOKEN"})
+	}
+
+	for i := range c.Tokens {
+		t := &c.Tokens[i]
+		if t.Scope != scopeRead && t.Scope != scopeIngest && t.Scope != scopeAdmin {
+			return fmt.Errorf("api_auth token %q: scope must be %s, %s or %s", t.Name, scopeRead, scopeIngest, scopeAdmin)
+		}
+		if (t.Env == "") == (t.File == "") {
+			return fmt.Errorf("api_auth token %q: set exactly one of env and file", t.Name)
+		}
+
+		if t.Env != "" {
+			t.value = os.Getenv(t.Env)
+		} else {
+			data, err := os.ReadFile(t.File)
+			if err != nil {
+				return fmt.Errorf("api_auth token %q: %w", t.Name, err)
+			}
+			t.value = strings.TrimSpace(string(data))
+		}
+		if t.value == "" {
+			return fmt.Errorf("api_auth token %q is empty", t.Name)
+		}
+	}
+
+	c.allowed = nil
+	for _, v := range c.AllowedIPs {
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(v)
+			if addrErr != nil {
+				return fmt.Errorf("api_auth.allowed_ips: %q is not an address or CIDR range", v)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		c.allowed = append(c.allowed, prefix)
+	}
+	return nil
+}
+
+// allows reports whether the caller at remoteAddr may use the API.
+func (c APIAuthConfig) allows(remoteAddr string) bool {
+	if len(c.allowed) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range c.allowed {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeOf returns the scope of the token presented with r, or "" when there
+// is none or it is unknown. Tokens are taken from a bearer Authorization
+// header, the password of basic auth (SNS can only send that), or, with
+// query, a token query parameter for the feed readers and badges that can't
+// set headers. URLs end up in logs and browser history, so only read routes
+// take it.
+func (c APIAuthConfig) scopeOf(r *http.Request, query bool) string {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		if _, password, ok := r.BasicAuth(); ok {
+			presented = password
+		} else if query {
+			presented = r.URL.Query().Get("token")
+		}
+	}
+	if presented == "" {
+		return ""
+	}
+
+	for _, t := range c.Tokens {
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(t.value)) == 1 {
+			return t.Scope
+		}
+	}
+	return ""
+}
+
+func (c APIAuthConfig) hasScope(scope string) bool {
+	for _, t := range c.Tokens {
+		if t.Scope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// guard checks the IP allowlist and, once any token is configured, a token
+// with scope before calling h. An admin token passes for every route.
+// Without tokens the read routes stay open as before, for private networks,
+// but the others only answer callers on the same host. That goes by the
+// connection's address, so a proxy on the host passes for any caller.
+func (b *Bot) guard(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := b.cfg.APIAuth
+		if !auth.allows(r.RemoteAddr) {
+			writeError(w, http.StatusForbidden, "address not allowed")
+			return
+		}
+		if len(auth.Tokens) == 0 && scope != scopeRead && !loopback(r.RemoteAddr) {
+			writeError(w, http.StatusForbidden, "configure an admin API token to call this endpoint from another host")
+			return
+		}
+		if len(auth.Tokens) > 0 && !permits(auth.scopeOf(r, scope == scopeRead), scope) {
+			unauthorized(w)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// adminOnly is guard for the routes that change the monitored services,
+// which stay off until an admin token is configured.
+func (b *Bot) adminOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !b.cfg.APIAuth.hasScope(scopeAdmin) {
+			writeError(w, http.StatusServiceUnavailable, "configure an admin API token to enable this endpoint")
+			return
+		}
+		b.guard(scopeAdmin, h)(w, r)
+	}
+}
+
+// loopback reports whether remoteAddr is on the same host.
+func loopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.Unmap().IsLoopback()
+}
+
+func permits(have string, want string) bool {
+	return have == scopeAdmin || have != "" && have == want
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer, Basic realm="status-bot"`)
+	writeError(w, http.StatusUnauthorized, "invalid or missing token")
+}
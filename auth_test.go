@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIAuthConfigValidate(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("READ_TOKEN", "from-env")
+	t.Setenv("STATUS_BOT_API_TOKEN", "legacy")
+
+	cfg := APIAuthConfig{
+		Tokens: []APIToken{
+			{Name: "dashboards", Scope: scopeRead, Env: "READ_TOKEN"},
+			{Name: "deploys", Scope: scopeAdmin, File: secret},
+		},
+		AllowedIPs: []string{"10.0.0.0/8", "192.168.1.5"},
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(cfg.Tokens) != 3 || cfg.Tokens[0].value != "from-env" || cfg.Tokens[1].value != "from-file" || cfg.Tokens[2].Scope != scopeAdmin {
+		t.Errorf("unexpected tokens %+v", cfg.Tokens)
+	}
+
+	for addr, want := range map[string]bool{
+		"10.1.2.3:5000":         true,
+		"192.168.1.5:80":        true,
+		"192.168.1.6:80":        false,
+		"[::ffff:10.0.0.1]:443": true,
+		"[2001:db8::1]:443":     false,
+	} {
+		if got := cfg.allows(addr); got != want {
+			t.Errorf("allows(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	os.Unsetenv("STATUS_BOT_API_TOKEN")
+	for _, bad := range []APIAuthConfig{
+		{Tokens: []APIToken{{Name: "x", Scope: "write", Env: "READ_TOKEN"}}},
+		{Tokens: []APIToken{{Name: "x", Scope: scopeRead}}},
+		{Tokens: []APIToken{{Name: "x", Scope: scopeRead, Env: "UNSET_TOKEN"}}},
+		{AllowedIPs: []string{"office"}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+// localRequest is a request from the bot's own host, which may use the admin
+// routes while no token is configured.
+func localRequest(method string, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = "127.0.0.1:1234"
+	return req
+}
+
+func TestGuard(t *testing.T) {
+	b := newTestBot()
+	mux := b.routes()
+	get := func(path string, setup func(*http.Request)) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if setup != nil {
+			setup(req)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := get("/api/v1/status", nil); code != http.StatusOK {
+		t.Errorf("expected the API to stay open without tokens, got %d", code)
+	}
+	if code := get("/debug/pprof/", nil); code != http.StatusNotFound {
+		t.Errorf("expected profiles to be off by default, got %d", code)
+	}
+	b.cfg.Pprof = true
+	mux = b.routes()
+	if code := get("/debug/pprof/", nil); code != http.StatusForbidden {
+		t.Errorf("expected admin routes to refuse other hosts without tokens, got %d", code)
+	}
+	if code := get("/debug/pprof/", func(r *http.Request) { r.RemoteAddr = "[::1]:1234" }); code != http.StatusOK {
+		t.Errorf("expected admin routes to answer the local host without tokens, got %d", code)
+	}
+
+	b.cfg.APIAuth.Tokens = []APIToken{
+		{Name: "dash", Scope: scopeRead, value: "r"},
+		{Name: "sns", Scope: scopeIngest, value: "i"},
+		{Name: "ops", Scope: scopeAdmin, value: "a"},
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	if code := get("/api/v1/status", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", code)
+	}
+	if code := get("/api/v1/status", bearer("r")); code != http.StatusOK {
+		t.Errorf("expected a read token to read, got %d", code)
+	}
+	if code := get("/api/v1/status?token=r", nil); code != http.StatusOK {
+		t.Errorf("expected the token query parameter to work, got %d", code)
+	}
+	if code := get("/api/v1/status", func(r *http.Request) { r.SetBasicAuth("sns", "a") }); code != http.StatusOK {
+		t.Errorf("expected basic auth to work, got %d", code)
+	}
+	if code := get("/api/v1/services", bearer("r")); code != http.StatusUnauthorized {
+		t.Errorf("expected a read token not to reach admin routes, got %d", code)
+	}
+	if code := get("/api/v1/services", bearer("a")); code != http.StatusOK {
+		t.Errorf("expected an admin token to reach admin routes, got %d", code)
+	}
+	if code := get("/api/v1/services?token=a", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected the token query parameter to be ignored on admin routes, got %d", code)
+	}
+	if code := get("/api/v1/services", bearer("i")); code != http.StatusUnauthorized {
+		t.Errorf("expected an ingest token not to reach admin routes, got %d", code)
+	}
+	if code := get("/api/v1/status", bearer("i")); code != http.StatusUnauthorized {
+		t.Errorf("expected an ingest token not to read, got %d", code)
+	}
+
+	post := func(target string, auth func(*http.Request)) int {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"alerts":[]}`))
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post("/api/v1/ingest/alertmanager", bearer("i")); code != http.StatusOK {
+		t.Errorf("expected an ingest token to post alerts, got %d", code)
+	}
+	if code := post("/api/v1/ingest/alertmanager?token=i", nil); code != http.StatusUnauthorized {
+		t.Errorf("expected the token query parameter to be ignored on ingest routes, got %d", code)
+	}
+	if code := post("/api/v1/ingest/alertmanager", bearer("r")); code != http.StatusUnauthorized {
+		t.Errorf("expected a read token not to post alerts, got %d", code)
+	}
+	if code := get("/healthz", nil); code == http.StatusUnauthorized {
+		t.Errorf("expected health checks to stay open")
+	}
+
+	allowlist := APIAuthConfig{AllowedIPs: []string{"10.0.0.0/8"}}
+	if err := allowlist.validate(); err != nil {
+		t.Fatal(err)
+	}
+	b.cfg.APIAuth.allowed = allowlist.allowed
+	if code := get("/api/v1/status", bearer("r")); code != http.StatusForbidden {
+		t.Errorf("expected callers outside the allowlist to be refused, got %d", code)
+	}
+}
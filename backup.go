@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type BackupConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"`
+	IntervalSeconds int    `json:"interval_seconds"`
+}
+
+var (
+	errObjectNotFound = errors.New("object not found")
+	errObjectChanged  = errors.New("object changed")
+)
+
+// objectStore talks to any S3-compatible API with SigV4 request signing. GCS
+// is supported through its interoperability endpoint and HMAC keys.
+type objectStore struct {
+	client    *http.Client
+	endpoint  string
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	now       func() time.Time
+}
+
+func newObjectStore(cfg BackupConfig) (*objectStore, error) {
+	accessKey := firstEnv("BACKUP_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	secretKey := firstEnv("BACKUP_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("backup credentials are not set")
+	}
+
+	return &objectStore{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		endpoint:  strings.TrimRight(cfg.Endpoint, "/"),
+		region:    cfg.Region,
+		bucket:    cfg.Bucket,
+		prefix:    cfg.Prefix,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		now:       time.Now,
+	}, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (s *objectStore) objectURL(name string) string {
+	return fmt.Sprintf("%s/%s/%s%s", s.endpoint, s.bucket, s.prefix, name)
+}
+
+func (s *objectStore) put(ctx context.Context, name string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("put object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("put object: http_%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *objectStore) get(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	s.sign(req, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errObjectNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("get object: http_%d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// getVersion is get that also returns the object's ETag, for a conditional
+// put to replace it with.
+func (s *objectStore) getVersion(ctx context.Context, name string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("build request: %w", err)
+	}
+	s.sign(req, nil)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("get object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", errObjectNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, "", fmt.Errorf("get object: http_%d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.Header.Get("ETag"), err
+}
+
+// putIf is put that only succeeds while the object is still at etag, or
+// doesn't exist yet when etag is empty. It returns errObjectChanged when
+// someone else wrote first.
+func (s *objectStore) putIf(ctx context.Context, name string, data []byte, etag string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if etag == "" {
+		req.Header.Set("If-None-Match", "*")
+	} else {
+		req.Header.Set("If-Match", etag)
+	}
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("put object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return errObjectChanged
+	case resp.StatusCode/100 != 2:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("put object: http_%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (s *objectStore) sign(req *http.Request, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// backupFiles uploads each existing local file under its base name.
+func backupFiles(ctx context.Context, store *objectStore, paths []string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+
+		if err := store.put(ctx, filepath.Base(path), data); err != nil {
+			return fmt.Errorf("upload %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// restoreFiles downloads every file that doesn't exist locally, so a fresh
+// container picks up where the previous one left off. With overwrite, local
+// files are replaced too, for a replica taking over from another.
+func restoreFiles(ctx context.Context, store *objectStore, paths []string, overwrite bool) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil && !overwrite {
+			continue
+		}
+
+		data, err := store.get(ctx, filepath.Base(path))
+		if errors.Is(err, errObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("download %s: %w", path, err)
+		}
+
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+	return nil
+}
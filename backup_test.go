@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestObjectStore(t *testing.T) (*objectStore, map[string][]byte) {
+	t.Helper()
+
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AK/20240301/eu-west-1/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &objectStore{
+		client:    srv.Client(),
+		endpoint:  srv.URL,
+		region:    "eu-west-1",
+		bucket:    "bucket",
+		prefix:    "bot/",
+		accessKey: "AK",
+		secretKey: "SK",
+		now:       func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	return store, objects
+}
+
+func TestObjectStore_PutGet(t *testing.T) {
+	store, objects := newTestObjectStore(t)
+	ctx := context.Background()
+
+	if err := store.put(ctx, "state.json", []byte("{}")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	if _, ok := objects["/bucket/bot/state.json"]; !ok {
+		t.Fatalf("expected object under /bucket/bot/state.json, got %v", objects)
+	}
+
+	data, err := store.get(ctx, "state.json")
+	if err != nil || string(data) != "{}" {
+		t.Fatalf("get: %q, %v", data, err)
+	}
+
+	if _, err := store.get(ctx, "missing.json"); !errors.Is(err, errObjectNotFound) {
+		t.Errorf("expected errObjectNotFound, got %v", err)
+	}
+}
+
+func TestBackupAndRestoreFiles(t *testing.T) {
+	store, _ := newTestObjectStore(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	if err := os.WriteFile(path, []byte(`{"states":{}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backupFiles(ctx, store, []string{path, filepath.Join(dir, "absent")}); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+
+	os.Remove(path)
+
+	if err := restoreFiles(ctx, store, []string{path}, false); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != `{"states":{}}` {
+		t.Errorf("expected restored file, got %q, %v", data, err)
+	}
+}
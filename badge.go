@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// badgeUptimeDays is the period the uptime badge covers.
+const badgeUptimeDays = 30
+
+// badge is a status badge in the shields.io endpoint schema, so it can be
+// used as https://img.shields.io/endpoint?url=... as well as rendered here.
+type badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// serviceBadge describes svc as of the last cycle, or its uptime over the
+// last 30 days when uptime is set.
+func (b *Bot) serviceBadge(svc Service, uptime bool, now time.Time) badge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bd := badge{SchemaVersion: 1, Label: svc.Name, Message: "unknown", Color: "lightgrey"}
+
+	if uptime {
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		report := buildReport(b.history, []Service{svc}, "", "", midnight.AddDate(0, 0, 1-badgeUptimeDays), now)
+		if s := report.Services[0]; s.Checks > 0 {
+			bd.Message = fmt.Sprintf("%.2f%%", s.Uptime())
+			bd.Color = uptimeColor(s.Uptime())
+		}
+		return bd
+	}
+
+	for _, r := range b.results {
+		if serviceKey(r.Service) != serviceKey(svc) {
+			continue
+		}
+		switch {
+		case inMaintenance(svc, now):
+			bd.Message, bd.Color = "maintenance", "blue"
+		case r.Up:
+			bd.Message, bd.Color = "up", "brightgreen"
+		default:
+			bd.Message, bd.Color = "down", "red"
+		}
+	}
+	return bd
+}
+
+func uptimeColor(uptime float64) string {
+	switch {
+	case uptime >= 99.9:
+		return "brightgreen"
+	case uptime >= 99:
+		return "yellow"
+	case uptime >= 95:
+		return "orange"
+	}
+	return "red"
+}
+
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+}
+
+// svg renders the badge in the flat shields.io style. Text widths are
+// estimated, which is close enough for the short labels badges carry.
+func (bd badge) svg() string {
+	textWidth := func(s string) int { return len([]rune(s))*7 + 10 }
+	lw, mw := textWidth(bd.Label), textWidth(bd.Message)
+	label, message := html.EscapeString(bd.Label), html.EscapeString(bd.Message)
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, lw+mw, label, message)
+	fmt.Fprintf(&s, `<title>%s: %s</title>`, label, message)
+	fmt.Fprintf(&s, `<rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/>`, lw, lw, mw, badgeColors[bd.Color])
+	fmt.Fprintf(&s, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&s, `<text x="%d" y="14">%s</text><text x="%d" y="14">%s</text></g></svg>`, lw/2, label, lw+mw/2, message)
+	return s.String()
+}
+
+// handleBadge serves /badge/{service}.svg, or .json for the shields.io
+// endpoint schema. ?env= picks the environment of an ambiguous name and
+// ?show=uptime switches to the 30-day uptime.
+func (b *Bot) handleBadge(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	name, format := file, "svg"
+	if i := strings.LastIndex(file, "."); i >= 0 {
+		name, format = file[:i], file[i+1:]
+	}
+	if format != "svg" && format != "json" {
+		writeError(w, http.StatusNotFound, "badges are served as .svg or .json")
+		return
+	}
+
+	svc, err := findService(b.services(), name, r.URL.Query().Get("env"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	bd := b.serviceBadge(svc, r.URL.Query().Get("show") == "uptime", time.Now())
+	w.Header().Set("Cache-Control", "no-cache, max-age=0")
+	if format == "json" {
+		writeJSON(w, http.StatusOK, bd)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	fmt.Fprint(w, bd.svg())
+}
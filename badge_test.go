@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleBadge(t *testing.T) {
+	api := Service{Name: "api", Env: "production"}
+	b := newTestBot(api)
+	b.results = []CheckResult{{Service: api, Up: true}}
+	b.history.Days[time.Now().Format(dayLayout)] = map[string]*DayStats{serviceKey(api): {Checks: 1000, Failures: 5}}
+	mux := b.routes()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/badge/api.svg")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("expected an svg, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "api: up") || !strings.Contains(body, "#4c1") {
+		t.Errorf("unexpected svg: %s", body)
+	}
+
+	var bd badge
+	if err := json.NewDecoder(get("/badge/api.json?show=uptime").Body).Decode(&bd); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if bd.SchemaVersion != 1 || bd.Label != "api" || bd.Message != "99.50%" || bd.Color != "yellow" {
+		t.Errorf("unexpected uptime badge: %+v", bd)
+	}
+
+	if rec := get("/badge/web.svg"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown service, got %d", rec.Code)
+	}
+	if rec := get("/badge/api.png"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unsupported format, got %d", rec.Code)
+	}
+}
+
+func TestBadgeSVGEscapes(t *testing.T) {
+	svg := badge{Label: "a<b", Message: "up", Color: "brightgreen"}.svg()
+	if strings.Contains(svg, "a<b") || !strings.Contains(svg, "a&lt;b") {
+		t.Errorf("expected the label to be escaped: %s", svg)
+	}
+}
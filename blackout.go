@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var errNoBlackoutTarget = errors.New("no service or tag by that name")
+
+// Blackout silences alerting for a set of services during planned work. It
+// is a mute with an audit trail: the board keeps showing the real status and
+// alerts resume on their own once it expires.
+type Blackout struct {
+	Target   string    `json:"target"`
+	Services []string  `json:"services"`
+	Until    time.Time `json:"until"`
+	By       string    `json:"by"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// blackoutTargets returns the services named target, in any environment, or
+// carrying it as a tag.
+func blackoutTargets(services []Service, target string) []Service {
+	var out []Service
+	for _, svc := range services {
+		if strings.EqualFold(svc.Name, target) || slices.ContainsFunc(svc.Tags, func(tag string) bool { return strings.EqualFold(tag, target) }) {
+			out = append(out, svc)
+		}
+	}
+	return out
+}
+
+// blackout mutes every service matching target for d, records it in the
+// audit log and posts a notice on the boards showing those services.
+func (b *Bot) blackout(target string, d time.Duration, by string, reason string, now time.Time) (Blackout, error) {
+	services := blackoutTargets(b.services(), target)
+	if len(services) == 0 {
+		return Blackout{}, errNoBlackoutTarget
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bo := Blackout{Target: target, Until: now.Add(d), By: by, Reason: reason}
+	for _, svc := range services {
+		state, exists := b.states[serviceKey(svc)]
+		if !exists {
+			state = &ServiceState{}
+			b.states[serviceKey(svc)] = state
+		}
+		// A longer mute already in place is kept.
+		if bo.Until.After(state.MutedUntil) {
+			state.MutedUntil = bo.Until
+		}
+		bo.Services = append(bo.Services, fmt.Sprintf("%s (%s)", svc.Name, svc.Env))
+		b.audit.recordBlackout(now, svc, bo.Until, by, reason)
+	}
+
+	for _, bc := range b.boards {
+		var names []string
+		for _, svc := range services {
+			if bc.matches(svc) {
+				names = append(names, svc.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if err := b.postBoardNotice(bc, renderBlackout(bo, names, b.cfg.clock)); err != nil {
+			slog.Error("failed to post blackout notice", "board", bc.Name, "err", err)
+		}
+	}
+
+	slog.Info("blackout started", "target", target, "until", bo.Until, "by", by)
+	return bo, nil
+}
+
+func renderBlackout(bo Blackout, names []string, c clock) string {
+	msg := fmt.Sprintf("🚧 *Blackout* on `%s` until %s, started by %s: alerts for %s are silenced, the board keeps updating.",
+		bo.Target, c.format(bo.Until), bo.By, strings.Join(names, ", "))
+	if bo.Reason != "" {
+		msg += "\n> " + bo.Reason
+	}
+	return msg
+}
+
+func (b *Bot) handleBlackout(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Target   string `json:"target"`
+		Duration string `json:"duration"`
+		By       string `json:"by"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	if body.Target == "" {
+		writeError(w, http.StatusBadRequest, "target is required")
+		return
+	}
+	d, err := parseDuration(body.Duration)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.By == "" {
+		body.By = "api"
+	}
+
+	bo, err := b.blackout(body.Target, d, body.By, body.Reason, time.Now())
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, bo)
+}
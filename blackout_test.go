@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestBlackoutTargets(t *testing.T) {
+	services := []Service{
+		{Name: "db", Env: "production", Tags: []string{"storage"}},
+		{Name: "db", Env: "staging", Tags: []string{"storage"}},
+		{Name: "cache", Env: "production", Tags: []string{"Storage"}},
+		{Name: "api", Env: "production"},
+	}
+
+	if got := blackoutTargets(services, "db"); len(got) != 2 {
+		t.Errorf("expected a name to match every environment, got %+v", got)
+	}
+	if got := blackoutTargets(services, "storage"); len(got) != 3 {
+		t.Errorf("expected a tag to match case-insensitively, got %+v", got)
+	}
+	if got := blackoutTargets(services, "web"); len(got) != 0 {
+		t.Errorf("expected no match, got %+v", got)
+	}
+}
+
+func TestHandleCommand_Blackout(t *testing.T) {
+	api, calls := newTestSlack(t)
+	db := Service{Name: "db", Env: "production", Tags: []string{"storage"}}
+	web := Service{Name: "web", Env: "production"}
+
+	b := newTestBot(db, web)
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.AlertPlacement = placementChannel
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := openAuditLog(AuditLogConfig{Path: auditPath, MaxSizeMB: 1})
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	b.audit = audit
+
+	resp := b.handleCommand(context.Background(), slack.SlashCommand{Text: "blackout storage 2h DB failover", UserID: "U1"})
+	if text, _ := resp["text"].(string); !strings.Contains(text, "db (production)") {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+
+	now := time.Now()
+	if state := b.states[serviceKey(db)]; state == nil || !state.muted(now.Add(119*time.Minute)) || state.muted(now.Add(121*time.Minute)) {
+		t.Errorf("expected db to be silenced for 2h, got %+v", state)
+	}
+	if state := b.states[serviceKey(web)]; state != nil && state.muted(now) {
+		t.Errorf("expected web to keep alerting")
+	}
+
+	sent := calls()
+	if len(sent) != 1 || !strings.Contains(sent[0].Get("text"), "Blackout") || !strings.Contains(sent[0].Get("text"), "DB failover") || !strings.Contains(sent[0].Get("text"), "<@U1>") {
+		t.Fatalf("expected a blackout notice, got %v", sent)
+	}
+
+	audit.Close()
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"kind":"blackout"`) || !strings.Contains(string(data), `"reason":"DB failover"`) {
+		t.Errorf("expected an audit entry, got %s", data)
+	}
+
+	resp = b.handleCommand(context.Background(), slack.SlashCommand{Text: "blackout nothing 2h"})
+	if text, _ := resp["text"].(string); text != errNoBlackoutTarget.Error() {
+		t.Errorf("expected an unknown target error, got %v", resp)
+	}
+}
+
+func TestHandleBlackout(t *testing.T) {
+	svc := Service{Name: "db", Env: "production"}
+	b := newTestBot(svc)
+	mux := b.routes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := localRequest(http.MethodPost, "/api/v1/blackouts", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(`{"target":"db","duration":"soon"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad duration, got %d", rec.Code)
+	}
+	if rec := post(`{"target":"web","duration":"1h"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown target, got %d", rec.Code)
+	}
+
+	rec := post(`{"target":"db","duration":"30m","by":"deploy-bot"}`)
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"by":"deploy-bot"`) {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if state := b.states[serviceKey(svc)]; state == nil || !state.muted(time.Now()) {
+		t.Errorf("expected db to be silenced")
+	}
+}
+
+func TestBlackoutKeepsIncidentThreadsClosed(t *testing.T) {
+	api, calls := newTestSlack(t)
+	db := Service{Name: "db", Env: "production"}
+	b := newTestBot(db)
+	b.api = api
+	b.cfg.Theme = defaultTheme
+
+	now := time.Now()
+	longer := now.Add(3 * time.Hour)
+	b.states[serviceKey(db)] = &ServiceState{MutedUntil: longer}
+	if _, err := b.blackout("db", time.Hour, "ops", "", now); err != nil {
+		t.Fatal(err)
+	}
+	state := b.states[serviceKey(db)]
+	if !state.MutedUntil.Equal(longer) {
+		t.Errorf("expected the longer mute to be kept, got %s", state.MutedUntil)
+	}
+
+	down := []Transition{{Service: db, ServiceName: "db (production)", Type: "down", Error: "timeout"}}
+	updateIncidentThreads(api, "C1", down, b.states, b.history, b.cfg.Theme, b.cfg.clock, now)
+	if state.IncidentTS != "" || len(calls()) != 0 {
+		t.Errorf("expected no incident thread during the blackout, got %v", calls())
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+type BoardConfig struct {
+	Name    string   `json:"name"`
+	Channel string   `json:"channel"`
+	Envs    []string `json:"envs"`
+	Tags    []string `json:"tags"`
+	Layout  string   `json:"layout"`
+
+	// Sort is one of config (default), down_first, name or latency.
+	// FoldHealthy caps the number of healthy services listed.
+	Sort        string `json:"sort"`
+	FoldHealthy int    `json:"fold_healthy"`
+
+	// TSFile is where the board's message timestamps are kept; it defaults
+	// to board_ts_file followed by the board's name.
+	TSFile string `json:"ts_file"`
+
+	ref *slackboard.Ref
+}
+
+// matches reports whether svc belongs on the board. Empty filters match
+// everything; tags match when the service carries any of them.
+func (bc BoardConfig) matches(svc Service) bool {
+	if len(bc.Envs) > 0 && !slices.Contains(bc.Envs, svc.Env) {
+		return false
+	}
+
+	if len(bc.Tags) == 0 {
+		return true
+	}
+	for _, tag := range svc.Tags {
+		if slices.Contains(bc.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bc BoardConfig) filter(results []CheckResult) []CheckResult {
+	var out []CheckResult
+	for _, r := range results {
+		if bc.matches(r.Service) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (bc BoardConfig) transitions(transitions []Transition) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		if bc.matches(t.Service) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// channelFor returns the channel configured for a service's environment, or
+// the default channel.
+func channelFor(cfg Config, defaultChannel string, svc Service) string {
+	if ch := cfg.Channels[svc.Env]; ch != "" {
+		return ch
+	}
+	return defaultChannel
+}
+
+// boardTSPath is where the board message timestamp of a channel is kept. The
+// default channel keeps base itself, .board_ts unless configured otherwise.
+func boardTSPath(base string, channel string, defaultChannel string) string {
+	if channel == defaultChannel {
+		return base
+	}
+	return base + "." + channel
+}
+
+// resolveBoards returns the configured boards, or derives one board per
+// channel from the per-environment routing when none are configured.
+func resolveBoards(cfg Config, defaultChannel string) []BoardConfig {
+	if len(cfg.Boards) > 0 {
+		boards := make([]BoardConfig, len(cfg.Boards))
+		for i, bc := range cfg.Boards {
+			if bc.Channel == "" {
+				bc.Channel = defaultChannel
+			}
+			if bc.TSFile == "" {
+				bc.TSFile = cfg.BoardTSFile + "." + bc.Name
+			}
+			bc.ref = slackboard.NewRef(bc.TSFile)
+			boards[i] = bc
+		}
+		return boards
+	}
+
+	var boards []BoardConfig
+	index := make(map[string]int)
+	for _, svc := range cfg.Services {
+		ch := channelFor(cfg, defaultChannel, svc)
+		i, exists := index[ch]
+		if !exists {
+			i = len(boards)
+			index[ch] = i
+			boards = append(boards, BoardConfig{
+				Name:    ch,
+				Channel: ch,
+				ref:     slackboard.NewRef(boardTSPath(cfg.BoardTSFile, ch, defaultChannel)),
+			})
+		}
+		if !slices.Contains(boards[i].Envs, svc.Env) {
+			boards[i].Envs = append(boards[i].Envs, svc.Env)
+		}
+	}
+
+	// A board that holds every environment needs no filter at all.
+	if len(boards) == 1 {
+		boards[0].Envs = nil
+	}
+	return boards
+}
+
+// primaryChannel is the channel of the first board showing svc. Incident
+// threads live there.
+func primaryChannel(boards []BoardConfig, svc Service) string {
+	for _, bc := range boards {
+		if bc.matches(svc) {
+			return bc.Channel
+		}
+	}
+	return ""
+}
+
+// postBoardNotice posts msg where alerts for bc go: the board thread, or the
+// channel itself with alert_placement set to channel.
+func (b *Bot) postBoardNotice(bc BoardConfig, msg string) error {
+	if b.cfg.AlertPlacement == placementChannel {
+		_, _, err := b.api.PostMessage(bc.Channel, slack.MsgOptionText(msg, false))
+		return err
+	}
+	return postThreadAlert(b.api, bc.Channel, bc.ref, msg)
+}
+
+// checkBoards makes sure every board has a channel and every service shows up
+// on at least one board.
+func checkBoards(cfg Config, boards []BoardConfig) error {
+	tsFiles := make(map[string]string)
+	for _, bc := range boards {
+		if bc.Channel == "" {
+			if len(bc.Envs) > 0 && len(cfg.Boards) == 0 {
+				return fmt.Errorf("SLACK_CHANNEL_ID is not set and no channel is configured for env %s", strings.Join(bc.Envs, ", "))
+			}
+			return fmt.Errorf("board %q has no channel and SLACK_CHANNEL_ID is not set", bc.Name)
+		}
+		if other, ok := tsFiles[bc.ref.Path()]; ok {
+			return fmt.Errorf("boards %q and %q share the timestamp file %s", other, bc.Name, bc.ref.Path())
+		}
+		tsFiles[bc.ref.Path()] = bc.Name
+	}
+
+	var orphans []string
+	for _, svc := range cfg.Services {
+		if primaryChannel(boards, svc) == "" {
+			orphans = append(orphans, fmt.Sprintf("%s (%s)", svc.Name, svc.Env))
+		}
+	}
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		return fmt.Errorf("services not shown on any board: %s", strings.Join(orphans, ", "))
+	}
+	return nil
+}
+
+// defaultBoardCheckMinutes is how often the stored board message is looked up
+// when board_check_minutes isn't set.
+const defaultBoardCheckMinutes = 15
+
+// defaultMinUpdateInterval is how long, in seconds, a board whose only change
+// is its "Updated:" line is left as is when min_update_interval_seconds isn't
+// set.
+const defaultMinUpdateInterval = 300
+
+// boardExists looks the stored board message up in the channel history.
+func boardExists(api *slack.Client, channelID string, ts string) (bool, error) {
+	history, err := api.GetConversationHistory(&slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Latest:    ts,
+		Oldest:    ts,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(history.Messages) > 0 && history.Messages[0].Timestamp == ts, nil
+}
+
+// channelUnavailable reports whether err means the bot can't post to the
+// channel at all, e.g. because it was archived.
+func channelUnavailable(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return false
+	}
+	switch slackErr.Err {
+	case "is_archived", "channel_not_found", "not_in_channel":
+		return true
+	}
+	return false
+}
+
+// checkBoard periodically verifies that the board message of bc still
+// exists, forgetting it when it was deleted so the next upsert reposts and
+// re-pins it. It reports false while the channel is unavailable; such boards
+// are skipped quietly until a later check succeeds.
+func (b *Bot) checkBoard(bc BoardConfig, now time.Time) bool {
+	if b.boardChecks == nil {
+		b.boardChecks = make(map[string]time.Time)
+		b.unavailable = make(map[string]bool)
+	}
+
+	interval := time.Duration(b.cfg.BoardCheckMinutes) * time.Minute
+	if now.Sub(b.boardChecks[bc.Name]) < interval {
+		return !b.unavailable[bc.Name]
+	}
+	b.boardChecks[bc.Name] = now
+
+	ts := bc.ref.TS()
+	if ts == "" {
+		b.unavailable[bc.Name] = false
+		return true
+	}
+
+	exists, err := boardExists(b.api, bc.Channel, ts)
+	switch {
+	case channelUnavailable(err):
+		b.markUnavailable(bc, err)
+	case err != nil:
+		slog.Error("failed to verify board", "board", bc.Name, "err", err)
+	case !exists:
+		slog.Warn("board was deleted, reposting it", "board", bc.Name)
+		if err := bc.ref.Clear(); err != nil {
+			slog.Error("failed to forget deleted board", "board", bc.Name, "err", err)
+		}
+		b.unavailable[bc.Name] = false
+	default:
+		b.unavailable[bc.Name] = false
+	}
+	return !b.unavailable[bc.Name]
+}
+
+func (b *Bot) markUnavailable(bc BoardConfig, err error) {
+	if b.unavailable == nil {
+		b.unavailable = make(map[string]bool)
+	}
+	if !b.unavailable[bc.Name] {
+		slog.Warn("channel is unavailable, skipping board until it comes back", "board", bc.Name, "channel", bc.Channel, "err", err)
+	}
+	b.unavailable[bc.Name] = true
+}
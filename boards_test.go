@@ -0,0 +1,188 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+func TestResolveBoards_PerEnvChannels(t *testing.T) {
+	cfg := Config{
+		Services: []Service{
+			{Name: "api", Env: "development"},
+			{Name: "api", Env: "production"},
+			{Name: "auth", Env: "development"},
+		},
+		Channels:    map[string]string{"production": "CPROD"},
+		BoardTSFile: ".board_ts",
+	}
+
+	boards := resolveBoards(cfg, "CDEFAULT")
+	if len(boards) != 2 {
+		t.Fatalf("expected 2 boards, got %d", len(boards))
+	}
+
+	if boards[0].Channel != "CDEFAULT" || boards[0].ref.Path() != ".board_ts" {
+		t.Errorf("unexpected default board %+v", boards[0])
+	}
+
+	if boards[1].Channel != "CPROD" || boards[1].ref.Path() != ".board_ts.CPROD" {
+		t.Errorf("unexpected production board %+v", boards[1])
+	}
+
+	results := []CheckResult{{Service: cfg.Services[0]}, {Service: cfg.Services[1]}, {Service: cfg.Services[2]}}
+	if got := len(boards[0].filter(results)); got != 2 {
+		t.Errorf("expected 2 results on the default board, got %d", got)
+	}
+}
+
+func TestResolveBoards_SingleChannel(t *testing.T) {
+	cfg := Config{Services: []Service{{Name: "api", Env: "development"}, {Name: "api", Env: "production"}}, BoardTSFile: ".board_ts"}
+
+	boards := resolveBoards(cfg, "C1")
+	if len(boards) != 1 || len(boards[0].Envs) != 0 || boards[0].ref.Path() != ".board_ts" {
+		t.Errorf("expected one unfiltered board, got %+v", boards)
+	}
+}
+
+func TestResolveBoards_TSFile(t *testing.T) {
+	cfg := Config{
+		Services:    []Service{{Name: "api", Env: "production"}},
+		BoardTSFile: "/var/lib/status-bot/board_ts",
+		Boards: []BoardConfig{
+			{Name: "prod", Channel: "C1"},
+			{Name: "ops", Channel: "C2", TSFile: "/tmp/ops_ts"},
+		},
+	}
+
+	boards := resolveBoards(cfg, "")
+	if boards[0].ref.Path() != "/var/lib/status-bot/board_ts.prod" || boards[1].ref.Path() != "/tmp/ops_ts" {
+		t.Errorf("unexpected timestamp files %s and %s", boards[0].ref.Path(), boards[1].ref.Path())
+	}
+
+	cfg.Boards[1].TSFile = "/var/lib/status-bot/board_ts.prod"
+	if err := checkBoards(cfg, resolveBoards(cfg, "")); err == nil {
+		t.Errorf("expected an error for boards sharing a timestamp file")
+	}
+}
+
+func TestBoardConfig_Matches(t *testing.T) {
+	svc := Service{Name: "api", Env: "production", Tags: []string{"payments", "core"}}
+
+	cases := []struct {
+		board BoardConfig
+		want  bool
+	}{
+		{BoardConfig{}, true},
+		{BoardConfig{Envs: []string{"production"}}, true},
+		{BoardConfig{Envs: []string{"development"}}, false},
+		{BoardConfig{Tags: []string{"core"}}, true},
+		{BoardConfig{Tags: []string{"search"}}, false},
+		{BoardConfig{Envs: []string{"production"}, Tags: []string{"payments"}}, true},
+	}
+
+	for _, c := range cases {
+		if got := c.board.matches(svc); got != c.want {
+			t.Errorf("%+v.matches = %v, want %v", c.board, got, c.want)
+		}
+	}
+}
+
+func TestCheckBoards(t *testing.T) {
+	cfg := Config{
+		Services: []Service{{Name: "api", Env: "production"}, {Name: "api", Env: "development"}},
+		Channels: map[string]string{"production": "CPROD"},
+	}
+
+	if err := checkBoards(cfg, resolveBoards(cfg, "")); err == nil {
+		t.Errorf("expected an error for the unrouted development env")
+	}
+
+	if err := checkBoards(cfg, resolveBoards(cfg, "CDEFAULT")); err != nil {
+		t.Errorf("unexpected error with a default channel: %v", err)
+	}
+
+	cfg.Boards = []BoardConfig{{Name: "prod", Channel: "C1", Envs: []string{"production"}}}
+	if err := checkBoards(cfg, resolveBoards(cfg, "")); err == nil {
+		t.Errorf("expected an error for a service missing from every board")
+	}
+}
+
+func TestBoardEnvs(t *testing.T) {
+	results := []CheckResult{
+		{Service: Service{Env: "staging"}},
+		{Service: Service{Env: "production"}},
+		{Service: Service{Env: "development"}},
+		{Service: Service{Env: "staging"}},
+	}
+
+	envs := boardEnvs(results)
+	want := []string{"development", "production", "staging"}
+	if len(envs) != len(want) {
+		t.Fatalf("expected %v, got %v", want, envs)
+	}
+	for i := range want {
+		if envs[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, envs)
+		}
+	}
+}
+
+func TestCheckBoardForgetsDeletedBoard(t *testing.T) {
+	api, calls := newTestSlack(t)
+	b := newTestBot(Service{Name: "api", Env: "production"})
+	b.api = api
+	b.cfg.BoardCheckMinutes = 15
+
+	bc := BoardConfig{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(t.TempDir(), ".board_ts"))}
+	if err := bc.ref.Set([]string{"1700000000.000001"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if !b.checkBoard(bc, now) {
+		t.Fatalf("board should stay usable")
+	}
+	if got := calls(); len(got) != 1 || got[0].Get("method") != "conversations.history" {
+		t.Fatalf("expected a history lookup, got %v", got)
+	}
+	if ts := bc.ref.TS(); ts != "" {
+		t.Errorf("a board missing from the history should be forgotten, still have %q", ts)
+	}
+
+	b.checkBoard(bc, now.Add(time.Minute))
+	if got := calls(); len(got) != 1 {
+		t.Errorf("the board shouldn't be verified again before the interval, got %d calls", len(got))
+	}
+}
+
+func TestChannelUnavailable(t *testing.T) {
+	if !channelUnavailable(slack.SlackErrorResponse{Err: "is_archived"}) {
+		t.Errorf("an archived channel should be unavailable")
+	}
+	if channelUnavailable(slack.SlackErrorResponse{Err: "message_not_found"}) {
+		t.Errorf("a missing message isn't a missing channel")
+	}
+}
+
+func TestBoardStamp(t *testing.T) {
+	results := []CheckResult{{Service: Service{Name: "api", Env: "production"}, Up: true}}
+	blocks := renderBoard(results, map[string]*ServiceState{}, newRecentIncidents(5), boardOptions{theme: defaultTheme})
+
+	var stamps []slack.Block
+	for _, block := range blocks {
+		if block.ID() == slackboard.StampBlockID {
+			stamps = append(stamps, block)
+		}
+	}
+	if len(stamps) != 1 {
+		t.Fatalf("expected the Updated line to be the only stamp block, got %d", len(stamps))
+	}
+	if text := stamps[0].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.TextBlockObject).Text; !strings.HasPrefix(text, "Updated: ") {
+		t.Errorf("unexpected stamp %q", text)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The maintenance calendar lists windows that ended in the last week and
+// those coming up in the next two months. Recurring windows are expanded
+// into single events, as cron schedules don't map onto RRULEs.
+const (
+	calendarLookback = 7 * 24 * time.Hour
+	calendarHorizon  = 60 * 24 * time.Hour
+)
+
+const icalTimeFormat = "20060102T150405Z"
+
+type calendarEvent struct {
+	UID     string
+	Summary string
+	Details string
+	Start   time.Time
+	End     time.Time
+}
+
+// occurrences returns the times the window is open that overlap from..to.
+// Matches of a recurring window while it is already open extend nothing and
+// are skipped.
+func (w MaintenanceWindow) occurrences(from, to time.Time) [][2]time.Time {
+	if w.schedule == nil {
+		if w.End.After(from) && w.Start.Before(to) {
+			return [][2]time.Time{{w.Start, w.End}}
+		}
+		return nil
+	}
+
+	var out [][2]time.Time
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+	for m := from.Truncate(time.Minute).Add(-duration + time.Minute); m.Before(to); m = m.Add(time.Minute) {
+		if w.schedule.matches(m) {
+			out = append(out, [2]time.Time{m, m.Add(duration)})
+			m = m.Add(duration - time.Minute)
+		}
+	}
+	return out
+}
+
+// maintenanceEvents lists the maintenance windows of services around now,
+// optionally limited to one environment, in start order.
+func maintenanceEvents(services []Service, env string, now time.Time) []calendarEvent {
+	from, to := now.Add(-calendarLookback), now.Add(calendarHorizon)
+
+	var events []calendarEvent
+	for _, svc := range services {
+		if svc.Disabled || env != "" && !strings.EqualFold(svc.Env, env) {
+			continue
+		}
+		for _, w := range svc.Maintenance {
+			summary := fmt.Sprintf("Maintenance: %s (%s)", svc.Name, svc.Env)
+			if w.Name != "" {
+				summary += " — " + w.Name
+			}
+			for _, o := range w.occurrences(from, to) {
+				events = append(events, calendarEvent{
+					UID:     fmt.Sprintf("%s-%d@status-bot", serviceKey(svc), o[0].Unix()),
+					Summary: summary,
+					Details: fmt.Sprintf("Alerts for %s (%s) are paused during this window.", svc.Name, svc.Env),
+					Start:   o[0],
+					End:     o[1],
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(events, func(a, b calendarEvent) int { return a.Start.Compare(b.Start) })
+	return events
+}
+
+// writeICal writes events as an iCalendar (RFC 5545) document.
+func writeICal(w io.Writer, events []calendarEvent, now time.Time) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//status-bot//maintenance//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Maintenance windows",
+	}
+	stamp := now.UTC().Format(icalTimeFormat)
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID,
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+e.Start.UTC().Format(icalTimeFormat),
+			"DTEND:"+e.End.UTC().Format(icalTimeFormat),
+			"SUMMARY:"+icalEscape(e.Summary),
+			"DESCRIPTION:"+icalEscape(e.Details),
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		io.WriteString(w, icalFold(line)+"\r\n")
+	}
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func icalEscape(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+// icalFold breaks lines longer than 75 octets, continuing them on lines
+// that start with a space. Multi-byte characters aren't split.
+func icalFold(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
+
+// handleCalendar serves the maintenance windows as a calendar to subscribe
+// to. ?env= limits it to one environment.
+func (b *Bot) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	events := maintenanceEvents(b.services(), r.URL.Query().Get("env"), now)
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	writeICal(w, events, now)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceEvents(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	nightly := MaintenanceWindow{Cron: "0 2 * * *", DurationMinutes: 30}
+	if err := nightly.validate(); err != nil {
+		t.Fatal(err)
+	}
+	services := []Service{
+		{Name: "api", Env: "production", Maintenance: []MaintenanceWindow{
+			{Name: "db upgrade", Start: now.Add(24 * time.Hour), End: now.Add(26 * time.Hour)},
+			{Name: "old", Start: now.Add(-30 * 24 * time.Hour), End: now.Add(-29 * 24 * time.Hour)},
+		}},
+		{Name: "db", Env: "staging", Maintenance: []MaintenanceWindow{nightly}},
+		{Name: "off", Env: "staging", Disabled: true, Maintenance: []MaintenanceWindow{nightly}},
+	}
+
+	events := maintenanceEvents(services, "production", now)
+	if len(events) != 1 || events[0].Summary != "Maintenance: api (production) — db upgrade" {
+		t.Fatalf("unexpected production events %+v", events)
+	}
+
+	events = maintenanceEvents(services, "", now)
+	// The nightly window recurs over the week behind and the 60 days ahead.
+	if len(events) != 68 {
+		t.Fatalf("expected 68 events, got %d", len(events))
+	}
+	if first := events[0]; !first.Start.Equal(time.Date(2024, 4, 25, 2, 0, 0, 0, time.UTC)) || !first.End.Equal(first.Start.Add(30*time.Minute)) {
+		t.Errorf("unexpected first occurrence %+v", first)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Start.Before(events[i-1].Start) {
+			t.Fatalf("events out of order at %d", i)
+		}
+	}
+}
+
+func TestOccurrencesSkipOpenWindow(t *testing.T) {
+	w := MaintenanceWindow{Cron: "* * * * *", DurationMinutes: 60}
+	if err := w.validate(); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if got := w.occurrences(from, from.Add(3*time.Hour)); len(got) != 4 {
+		t.Errorf("expected matches while open to be skipped, got %d occurrences", len(got))
+	}
+}
+
+func TestWriteICal(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	writeICal(&b, []calendarEvent{{
+		UID:     "api:production-1@status-bot",
+		Summary: "Maintenance: api; db, cache",
+		Details: strings.Repeat("long description ", 10),
+		Start:   now,
+		End:     now.Add(time.Hour),
+	}}, now)
+	out := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20240501T120000Z\r\n",
+		"DTEND:20240501T130000Z\r\n",
+		`SUMMARY:Maintenance: api\; db\, cache` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestHandleCalendar(t *testing.T) {
+	now := time.Now()
+	b := newTestBot(Service{Name: "api", Env: "production", Maintenance: []MaintenanceWindow{{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}}})
+
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/maintenance.ics?env=production", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/calendar") {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if n := strings.Count(rec.Body.String(), "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("expected 1 event, got %d", n)
+	}
+}
@@ -0,0 +1,131 @@
+// Package checker runs the HTTP checks of the status bot. It knows nothing
+// about Slack or the bot's state, so other programs can use it to probe
+// their own targets.
+package checker
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Target is something to check: a GET to URL that passes with a 2xx.
+type Target struct {
+	Name string
+	Env  string
+	URL  string
+
+	// Timeout bounds each attempt. Zero leaves it to ctx and the client.
+	Timeout time.Duration
+
+	// Retries re-runs a failed check, RetryDelay apart, before the result
+	// counts as a failure.
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// Key identifies the target across cycles.
+func (t Target) Key() string {
+	return t.Name + ":" + t.Env
+}
+
+// Result is the outcome of one check. Error is a short code such as
+// "request failed" or "http_503", empty when the check passed.
+type Result struct {
+	Target     Target
+	Up         bool
+	StatusCode int
+	Latency    time.Duration
+	Error      string
+}
+
+// Options tune CheckAll.
+type Options struct {
+	// Concurrency caps the checks in flight. Zero means no limit. A Pool
+	// ignores it: its workers are the limit.
+	Concurrency int
+
+	// Spread staggers the starts evenly over this long, and Jitter pushes
+	// each back by up to this much more, so the checks don't all hit at
+	// once.
+	Spread time.Duration
+	Jitter time.Duration
+}
+
+// Check runs a single check of t, giving up after t.Timeout or when ctx is
+// done, whichever comes first.
+func Check(ctx context.Context, client *http.Client, t Target) Result {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL, nil)
+	if err != nil {
+		return Result{Target: t, Latency: time.Since(start), Error: "invalid url"}
+	}
+
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Target: t, Latency: latency, Error: "request failed"}
+	}
+	defer resp.Body.Close()
+
+	up := resp.StatusCode >= 200 && resp.StatusCode < 300
+	result := Result{
+		Target:     t,
+		Up:         up,
+		StatusCode: resp.StatusCode,
+		Latency:    latency,
+	}
+	if !up {
+		result.Error = fmt.Sprintf("http_%d", resp.StatusCode)
+	}
+	return result
+}
+
+// CheckWithRetries runs the check of t, retrying a failure up to t.Retries
+// times so a single blip doesn't cost a whole cycle.
+func CheckWithRetries(ctx context.Context, client *http.Client, t Target) Result {
+	result := Check(ctx, client, t)
+	for attempt := 0; attempt < t.Retries && !result.Up; attempt++ {
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(t.RetryDelay):
+		}
+		result = Check(ctx, client, t)
+	}
+	return result
+}
+
+// CheckAll checks every target on a pool of its own, opts.Concurrency
+// workers large, and returns the results in the same order. Programs that
+// check repeatedly should keep a Pool instead.
+func CheckAll(ctx context.Context, client *http.Client, targets []Target, opts Options) []Result {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = max(len(targets), 1)
+	}
+	p := NewPool(concurrency)
+	defer p.Close()
+	return p.CheckAll(ctx, client, targets, opts)
+}
+
+// Offset is how long after the start of the cycle the i-th of n checks
+// starts: evenly spaced over spread, plus a random share of jitter.
+func Offset(i int, n int, spread time.Duration, jitter time.Duration) time.Duration {
+	var offset time.Duration
+	if n > 0 {
+		offset = spread * time.Duration(i) / time.Duration(n)
+	}
+	if jitter > 0 {
+		offset += rand.N(jitter)
+	}
+	return offset
+}
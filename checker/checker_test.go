@@ -0,0 +1,98 @@
+package checker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	if r := Check(context.Background(), srv.Client(), Target{Name: "api", URL: srv.URL}); !r.Up || r.StatusCode != 200 || r.Error != "" {
+		t.Errorf("expected a passing check, got %+v", r)
+	}
+	if r := Check(context.Background(), srv.Client(), Target{Name: "api", URL: srv.URL + "/down"}); r.Up || r.Error != "http_503" {
+		t.Errorf("expected http_503, got %+v", r)
+	}
+	if r := Check(context.Background(), srv.Client(), Target{Name: "api", URL: "http://127.0.0.1:1"}); r.Up || r.Error != "request failed" {
+		t.Errorf("expected request failed, got %+v", r)
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	started := time.Now()
+	r := Check(context.Background(), srv.Client(), Target{Name: "api", URL: srv.URL, Timeout: 50 * time.Millisecond})
+	if r.Up || r.Error != "request failed" {
+		t.Errorf("expected the check to time out, got %+v", r)
+	}
+	if took := time.Since(started); took > time.Second {
+		t.Errorf("expected the check to give up after its timeout, took %v", took)
+	}
+}
+
+func TestCheckWithRetries(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	target := Target{Name: "api", URL: srv.URL, Retries: 1}
+	if r := CheckWithRetries(context.Background(), srv.Client(), target); r.Up || hits != 2 {
+		t.Errorf("expected a failure after one retry, got up=%v after %d requests", r.Up, hits)
+	}
+
+	hits = 0
+	target.Retries = 3
+	if r := CheckWithRetries(context.Background(), srv.Client(), target); !r.Up || hits != 3 {
+		t.Errorf("expected success on the third attempt, got up=%v after %d requests", r.Up, hits)
+	}
+
+	hits = 0
+	target.Retries = 0
+	if r := CheckWithRetries(context.Background(), srv.Client(), target); r.Up || hits != 1 {
+		t.Errorf("expected a single attempt without retries, got %d", hits)
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	targets := []Target{{Name: "a", URL: srv.URL}, {Name: "b", URL: srv.URL}, {Name: "c", URL: "::"}}
+	results := CheckAll(context.Background(), srv.Client(), targets, Options{})
+	if len(results) != 3 || !results[0].Up || results[1].Target.Name != "b" || results[2].Error != "invalid url" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	spread := 10 * time.Second
+	for i, want := range []time.Duration{0, 2500 * time.Millisecond, 5 * time.Second, 7500 * time.Millisecond} {
+		if got := Offset(i, 4, spread, 0); got != want {
+			t.Errorf("Offset(%d) = %v, want %v", i, got, want)
+		}
+	}
+
+	for range 100 {
+		if got := Offset(1, 4, spread, time.Second); got < 2500*time.Millisecond || got >= 3500*time.Millisecond {
+			t.Fatalf("expected jitter within a second, got %v", got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Pool runs checks on a fixed set of workers fed from a queue. Unlike a
+// goroutine per check, its cost doesn't grow with the number of targets,
+// and checks submitted by different callers are served in the order they
+// were queued.
+type Pool struct {
+	jobs chan job
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+type job struct {
+	ctx    context.Context
+	client *http.Client
+	target Target
+	done   func(Result)
+}
+
+// NewPool starts workers workers. Close stops them.
+func NewPool(workers int) *Pool {
+	workers = max(workers, 1)
+	p := &Pool{jobs: make(chan job, workers)}
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for j := range p.jobs {
+		if j.ctx.Err() != nil {
+			j.done(Result{Target: j.target, Error: "request failed"})
+			continue
+		}
+		j.done(CheckWithRetries(j.ctx, j.client, j.target))
+	}
+}
+
+// Check queues a check of t and calls done with the result from a worker.
+// It waits while the queue is full; when ctx is done first, done gets a
+// failed result right away.
+func (p *Pool) Check(ctx context.Context, client *http.Client, t Target, done func(Result)) {
+	select {
+	case p.jobs <- job{ctx: ctx, client: client, target: t, done: done}:
+	case <-ctx.Done():
+		done(Result{Target: t, Error: "request failed"})
+	}
+}
+
+// CheckAll checks every target and returns the results in the same order.
+// The checks are queued at their Offset from now, so with a spread they
+// start staggered instead of all at once.
+func (p *Pool) CheckAll(ctx context.Context, client *http.Client, targets []Target, opts Options) []Result {
+	offsets := make([]time.Duration, len(targets))
+	order := make([]int, len(targets))
+	for i := range targets {
+		offsets[i] = Offset(i, len(targets), opts.Spread, opts.Jitter)
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(offsets[a], offsets[b])
+	})
+
+	results := make([]Result, len(targets))
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	start := time.Now()
+	for n, i := range order {
+		if wait := offsets[i] - time.Since(start); wait > 0 {
+			select {
+			case <-ctx.Done():
+				for _, i := range order[n:] {
+					results[i] = Result{Target: targets[i], Error: "request failed"}
+					wg.Done()
+				}
+				wg.Wait()
+				return results
+			case <-time.After(wait):
+			}
+		}
+		p.Check(ctx, client, targets[i], func(r Result) {
+			results[i] = r
+			wg.Done()
+		})
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Close stops the workers once the queued checks are done. The pool must
+// not be used after.
+func (p *Pool) Close() {
+	p.once.Do(func() {
+		close(p.jobs)
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,80 @@
+package checker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolCheckAll(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	p := NewPool(3)
+	defer p.Close()
+
+	targets := make([]Target, 20)
+	for i := range targets {
+		targets[i] = Target{Name: string(rune('a' + i)), URL: srv.URL}
+	}
+	// Two callers share the workers.
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results := p.CheckAll(context.Background(), srv.Client(), targets, Options{})
+			for i, r := range results {
+				if !r.Up || r.Target.Name != targets[i].Name {
+					t.Errorf("result %d: unexpected %+v", i, r)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 checks in flight, got %d", peak)
+	}
+}
+
+func TestPoolCheckAll_Spread(t *testing.T) {
+	var mu sync.Mutex
+	var seen []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	p := NewPool(2)
+	defer p.Close()
+
+	targets := []Target{{Name: "a", URL: srv.URL}, {Name: "b", URL: srv.URL}}
+	p.CheckAll(context.Background(), srv.Client(), targets, Options{Spread: 200 * time.Millisecond})
+	if len(seen) != 2 || seen[1].Sub(seen[0]) < 80*time.Millisecond {
+		t.Errorf("expected the second check to start about 100ms after the first, got %v", seen)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := p.CheckAll(ctx, srv.Client(), targets, Options{Spread: time.Hour})
+	if results[1].Up || results[1].Error != "request failed" || results[1].Target.Name != "b" {
+		t.Errorf("expected a canceled check to fail, got %+v", results[1])
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// runOptions are the command-line choices that change how the bot runs.
+// once runs a single cycle and returns, leaving out Socket Mode and the HTTP
+// server. dryRun prints Slack writes and alerts instead of sending them. The
+// rest override the environment and the config file, for ad-hoc instances.
+type runOptions struct {
+	once      bool
+	dryRun    bool
+	channel   string
+	interval  time.Duration
+	tokenFile string
+}
+
+// parseFlags reads the global flags from args and returns the command that
+// follows them, if any, with its own arguments.
+func parseFlags(args []string) (runOptions, []string, error) {
+	var opts runOptions
+	fs := flag.NewFlagSet("slack-status-bot", flag.ContinueOnError)
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print Slack messages and alerts instead of sending them")
+	fs.StringVar(&opts.channel, "channel", "", "channel ID to post the board to, instead of SLACK_CHANNEL_ID")
+	fs.DurationVar(&opts.interval, "interval", 0, "time between check cycles, instead of interval_seconds")
+	fs.StringVar(&opts.tokenFile, "token-file", "", "file holding the bot token, instead of SLACK_BOT_TOKEN")
+	if err := fs.Parse(args); err != nil {
+		return runOptions{}, nil, err
+	}
+
+	if opts.interval < 0 || opts.interval%time.Second != 0 {
+		return runOptions{}, nil, fmt.Errorf("-interval must be a positive number of seconds, got %s", opts.interval)
+	}
+	return opts, fs.Args(), nil
+}
+
+// slackToken returns the bot token from --token-file, or SLACK_BOT_TOKEN.
+func (o runOptions) slackToken() (string, error) {
+	if o.tokenFile == "" {
+		token := os.Getenv("SLACK_BOT_TOKEN")
+		if token == "" {
+			return "", fmt.Errorf("SLACK_BOT_TOKEN is not set")
+		}
+		return token, nil
+	}
+
+	data, err := os.ReadFile(o.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", o.tokenFile)
+	}
+	return token, nil
+}
+
+// slackChannel returns the default channel from --channel, or
+// SLACK_CHANNEL_ID.
+func (o runOptions) slackChannel() string {
+	if o.channel != "" {
+		return o.channel
+	}
+	return os.Getenv("SLACK_CHANNEL_ID")
+}
+
+// overrideConfig applies --interval to cfg, which loadConfig has already
+// validated.
+func (o runOptions) overrideConfig(cfg *Config) error {
+	if o.interval == 0 {
+		return nil
+	}
+	seconds := int(o.interval / time.Second)
+	if cfg.CheckSpreadSeconds >= seconds {
+		return fmt.Errorf("-interval must be longer than check_spread_seconds")
+	}
+	cfg.IntervalSeconds = seconds
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseFlags(t *testing.T) {
+	opts, args, err := parseFlags([]string{"--channel", "C42", "--interval", "2m", "--token-file", "/run/secrets/slack", "--dry-run", "once"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.channel != "C42" || opts.interval != 2*time.Minute || opts.tokenFile != "/run/secrets/slack" || !opts.dryRun {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if len(args) != 1 || args[0] != "once" {
+		t.Errorf("expected the command to follow, got %v", args)
+	}
+
+	for _, bad := range []string{"-5s", "1500ms"} {
+		if _, _, err := parseFlags([]string{"--interval", bad}); err == nil {
+			t.Errorf("expected --interval %s to be rejected", bad)
+		}
+	}
+}
+
+func TestRunOptionsOverrides(t *testing.T) {
+	t.Setenv("SLACK_BOT_TOKEN", "xoxb-env")
+	t.Setenv("SLACK_CHANNEL_ID", "CENV")
+
+	var opts runOptions
+	if token, err := opts.slackToken(); err != nil || token != "xoxb-env" {
+		t.Errorf("expected the env token, got %q, %v", token, err)
+	}
+	if ch := opts.slackChannel(); ch != "CENV" {
+		t.Errorf("expected the env channel, got %q", ch)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("xoxb-file\n"), 0600)
+	opts = runOptions{channel: "CFLAG", tokenFile: path, interval: 90 * time.Second}
+	if token, err := opts.slackToken(); err != nil || token != "xoxb-file" {
+		t.Errorf("expected the file token, got %q, %v", token, err)
+	}
+	if ch := opts.slackChannel(); ch != "CFLAG" {
+		t.Errorf("expected the flag channel, got %q", ch)
+	}
+
+	cfg := Config{IntervalSeconds: 30}
+	if err := opts.overrideConfig(&cfg); err != nil || cfg.IntervalSeconds != 90 {
+		t.Errorf("expected a 90s interval, got %d, %v", cfg.IntervalSeconds, err)
+	}
+	cfg = Config{IntervalSeconds: 300, CheckSpreadSeconds: 120}
+	if err := opts.overrideConfig(&cfg); err == nil {
+		t.Errorf("expected an interval shorter than the spread to be rejected")
+	}
+
+	os.WriteFile(path, nil, 0600)
+	if _, err := opts.slackToken(); err == nil {
+		t.Errorf("expected an empty token file to be rejected")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// clock formats timestamps shown on the board. With viewerLocal, Slack
+// renders them in each viewer's own timezone and the configured zone and
+// format only apply to the fallback text.
+type clock struct {
+	loc         *time.Location
+	layout      string
+	viewerLocal bool
+}
+
+// newClock returns a clock for timezone, or for the local time when it is
+// empty: time.LoadLocation would take that to mean UTC.
+func newClock(timezone string, layout string, viewerLocal bool) (clock, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return clock{}, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	if layout == "" {
+		layout = defaultTimeFormat
+	}
+	return clock{loc: loc, layout: layout, viewerLocal: viewerLocal}, nil
+}
+
+func (c clock) format(t time.Time) string {
+	loc, layout := c.loc, c.layout
+	if loc == nil {
+		loc = time.Local
+	}
+	if layout == "" {
+		layout = defaultTimeFormat
+	}
+
+	text := t.In(loc).Format(layout)
+	if c.viewerLocal {
+		return fmt.Sprintf("<!date^%d^{date_num} {time_secs}|%s>", t.Unix(), text)
+	}
+	return text
+}
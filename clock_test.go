@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockFormat(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	c, err := newClock("Asia/Tokyo", "Jan 2 15:04 MST", false)
+	if err != nil {
+		t.Fatalf("newClock: %v", err)
+	}
+	if got := c.format(at); got != "Mar 1 21:30 JST" {
+		t.Errorf("unexpected time %q", got)
+	}
+
+	c.viewerLocal = true
+	if got := c.format(at); got != "<!date^1709296200^{date_num} {time_secs}|Mar 1 21:30 JST>" {
+		t.Errorf("unexpected viewer-local time %q", got)
+	}
+
+	if _, err := newClock("Mars/Olympus", "", false); err == nil {
+		t.Errorf("expected an error for an unknown timezone")
+	}
+
+	c, err = newClock("", "", false)
+	if err != nil {
+		t.Fatalf("newClock: %v", err)
+	}
+	if c.loc != time.Local || c.format(at) != at.In(time.Local).Format(defaultTimeFormat) {
+		t.Errorf("expected no timezone to mean local time, got %v", c.loc)
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CloudWatchConfig restricts the SNS topics alarms are accepted from. With
+// no topics listed, any topic is accepted.
+type CloudWatchConfig struct {
+	TopicARNs []string `json:"topic_arns"`
+}
+
+// snsMessage is the envelope SNS posts to HTTP subscribers.
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// snsHost matches the hosts SNS signs and confirms subscriptions from, e.g.
+// sns.eu-west-1.amazonaws.com.
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsURL reports whether raw is an https link to SNS itself.
+func snsURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.User == nil && snsHost.MatchString(u.Host)
+}
+
+// signedText is what SNS signs for msg: the fields of its type, each name
+// and value followed by a newline, in this order.
+func (msg snsMessage) signedText() string {
+	var fields [][2]string
+	switch msg.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", msg.Message}, {"MessageId", msg.MessageID}}
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", msg.Timestamp}, [2]string{"TopicArn", msg.TopicArn}, [2]string{"Type", msg.Type})
+	default:
+		fields = [][2]string{
+			{"Message", msg.Message}, {"MessageId", msg.MessageID}, {"SubscribeURL", msg.SubscribeURL},
+			{"Timestamp", msg.Timestamp}, {"Token", msg.Token}, {"TopicArn", msg.TopicArn}, {"Type", msg.Type},
+		}
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0] + "\n" + f[1] + "\n")
+	}
+	return b.String()
+}
+
+// snsCertCache keeps the certificates SNS signs with, by URL. They are
+// fetched once: SNS rotates them under new URLs.
+type snsCertCache struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+var snsSigningCerts = &snsCertCache{client: &http.Client{Timeout: 10 * time.Second}}
+
+func (c *snsCertCache) get(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	c.mu.Lock()
+	cert, ok := c.certs[certURL]
+	c.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch signing certificate: sns returned %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("fetch signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing certificate is not PEM")
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.certs == nil {
+		c.certs = map[string]*x509.Certificate{}
+	}
+	c.certs[certURL] = cert
+	c.mu.Unlock()
+	return cert, nil
+}
+
+// verifySNS checks that msg was signed by SNS, with a certificate served
+// by SNS itself.
+func verifySNS(ctx context.Context, msg snsMessage) error {
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("unsupported signature version %q", msg.SignatureVersion)
+	}
+	if !snsURL(msg.SigningCertURL) {
+		return fmt.Errorf("signing certificate at %q is not from SNS", msg.SigningCertURL)
+	}
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	cert, err := snsSigningCerts.get(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate has no RSA key")
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(msg.signedText()))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(msg.signedText()))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// cloudWatchAlarm is the state change CloudWatch publishes for an alarm.
+type cloudWatchAlarm struct {
+	AlarmName      string `json:"AlarmName"`
+	AWSAccountID   string `json:"AWSAccountId"`
+	Region         string `json:"Region"`
+	NewStateValue  string `json:"NewStateValue"`
+	NewStateReason string `json:"NewStateReason"`
+	Trigger        struct {
+		Namespace  string `json:"Namespace"`
+		MetricName string `json:"MetricName"`
+		Dimensions []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"Dimensions"`
+	} `json:"Trigger"`
+}
+
+// labels describes the alarm for matching: alarm_name, namespace,
+// metric_name, region and account, plus one label per metric dimension,
+// e.g. DBInstanceIdentifier.
+func (a cloudWatchAlarm) labels() map[string]string {
+	labels := map[string]string{
+		"alarm_name":  a.AlarmName,
+		"namespace":   a.Trigger.Namespace,
+		"metric_name": a.Trigger.MetricName,
+		"region":      a.Region,
+		"account":     a.AWSAccountID,
+	}
+	for _, d := range a.Trigger.Dimensions {
+		labels[d.Name] = d.Value
+	}
+	return labels
+}
+
+// ingestCloudWatch applies an alarm state change to the services sourced
+// from CloudWatch that match it, and returns how many did. ALARM takes them
+// down and OK brings them back; INSUFFICIENT_DATA leaves them as they are.
+func (b *Bot) ingestCloudWatch(alarm cloudWatchAlarm) int {
+	matched := 0
+	for _, svc := range b.services() {
+		if svc.Source != sourceCloudWatch || !matchesLabels(svc, alarm.labels()) {
+			continue
+		}
+		matched++
+		switch alarm.NewStateValue {
+		case "ALARM":
+			b.external.fire(serviceKey(svc), alarm.AlarmName, externalAlert{Error: alarm.AlarmName})
+		case "OK":
+			b.external.resolve(serviceKey(svc), alarm.AlarmName)
+		}
+	}
+	return matched
+}
+
+// handleCloudWatch accepts alarm state changes from an SNS subscription, or
+// posted directly. SNS messages are only acted on once their signature
+// checks out. Subscription confirmations are followed so the topic can be
+// subscribed to without extra steps.
+func (b *Bot) handleCloudWatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	if topics := b.cfg.CloudWatch.TopicARNs; msg.Type != "" && len(topics) > 0 && !slices.Contains(topics, msg.TopicArn) {
+		writeError(w, http.StatusForbidden, "topic not allowed")
+		return
+	}
+	if msg.Type != "" {
+		if err := verifySNS(r.Context(), msg); err != nil {
+			slog.Warn("refused unverified SNS message", "topic", msg.TopicArn, "err", err)
+			writeError(w, http.StatusForbidden, "invalid SNS signature")
+			return
+		}
+	}
+
+	var alarm cloudWatchAlarm
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		if err := confirmSNSSubscription(r.Context(), msg.SubscribeURL); err != nil {
+			slog.Error("failed to confirm SNS subscription", "topic", msg.TopicArn, "err", err)
+			writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		slog.Info("confirmed SNS subscription", "topic", msg.TopicArn)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "confirmed"})
+		return
+	case "Notification":
+		if err := json.Unmarshal([]byte(msg.Message), &alarm); err != nil {
+			writeError(w, http.StatusBadRequest, "notification is not a CloudWatch alarm")
+			return
+		}
+	case "":
+		// Not wrapped by SNS: the body is the alarm itself.
+		json.Unmarshal(body, &alarm)
+	default:
+		writeJSON(w, http.StatusOK, map[string]int{"matched": 0})
+		return
+	}
+
+	if alarm.AlarmName == "" {
+		writeError(w, http.StatusBadRequest, "alarm name is missing")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"matched": b.ingestCloudWatch(alarm)})
+}
+
+// confirmSNSSubscription visits the confirmation link of a subscription. Only
+// links to SNS itself are followed.
+func confirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	if !snsURL(subscribeURL) {
+		return fmt.Errorf("refusing to confirm subscription at %q", subscribeURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sns returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningCertURL = "https://sns.eu-west-1.amazonaws.com/SimpleNotificationService-test.pem"
+
+// testSNSSigner returns a function signing messages the way SNS does, with
+// a certificate already cached under testSigningCertURL.
+func testSNSSigner(t *testing.T) func(msg snsMessage) snsMessage {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "sns.amazonaws.com"}, NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snsSigningCerts.mu.Lock()
+	snsSigningCerts.certs = map[string]*x509.Certificate{testSigningCertURL: cert}
+	snsSigningCerts.mu.Unlock()
+	t.Cleanup(func() {
+		snsSigningCerts.mu.Lock()
+		snsSigningCerts.certs = nil
+		snsSigningCerts.mu.Unlock()
+	})
+
+	return func(msg snsMessage) snsMessage {
+		msg.MessageID, msg.Timestamp = "b1c2", "2024-05-01T12:00:00.000Z"
+		msg.SignatureVersion, msg.SigningCertURL = "2", testSigningCertURL
+		digest := sha256.Sum256([]byte(msg.signedText()))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Signature = base64.StdEncoding.EncodeToString(signature)
+		return msg
+	}
+}
+
+const testAlarm = `{"AlarmName":"orders-db-cpu","AWSAccountId":"123456789012","Region":"EU (Ireland)","NewStateValue":"%s","NewStateReason":"Threshold crossed",` +
+	`"Trigger":{"Namespace":"AWS/RDS","MetricName":"CPUUtilization","Dimensions":[{"name":"DBInstanceIdentifier","value":"orders"}]}}`
+
+func TestHandleCloudWatch(t *testing.T) {
+	db := Service{Name: "orders-db", Env: "production", Source: sourceCloudWatch, Match: map[string]string{"namespace": "AWS/RDS", "DBInstanceIdentifier": "orders"}}
+	b := newTestBot(db)
+	b.cfg.CloudWatch.TopicARNs = []string{"arn:aws:sns:eu-west-1:123456789012:alarms"}
+	mux := b.routes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, localRequest(http.MethodPost, "/api/v1/ingest/cloudwatch", strings.NewReader(body)))
+		return rec
+	}
+	sign := testSNSSigner(t)
+	notification := func(topic string, state string) string {
+		alarm := strings.Replace(testAlarm, "%s", state, 1)
+		msg, _ := json.Marshal(sign(snsMessage{Type: "Notification", TopicArn: topic, Message: alarm}))
+		return string(msg)
+	}
+
+	if rec := post(notification("arn:aws:sns:eu-west-1:123456789012:other", "ALARM")); rec.Code != http.StatusForbidden {
+		t.Errorf("expected other topics to be refused, got %d", rec.Code)
+	}
+
+	forged := sign(snsMessage{Type: "Notification", TopicArn: "arn:aws:sns:eu-west-1:123456789012:alarms", Message: strings.Replace(testAlarm, "%s", "OK", 1)})
+	forged.Message = strings.Replace(testAlarm, "%s", "ALARM", 1)
+	for _, msg := range []snsMessage{
+		{Type: "Notification", TopicArn: "arn:aws:sns:eu-west-1:123456789012:alarms", Message: forged.Message},
+		forged,
+	} {
+		body, _ := json.Marshal(msg)
+		if rec := post(string(body)); rec.Code != http.StatusForbidden {
+			t.Errorf("expected an unverified message to be refused, got %d", rec.Code)
+		}
+	}
+	if r := b.external.result(db, time.Now()); !r.Up {
+		t.Errorf("expected unverified messages to be ignored, got %+v", r)
+	}
+
+	rec := post(notification("arn:aws:sns:eu-west-1:123456789012:alarms", "ALARM"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"matched":1`) {
+		t.Fatalf("expected the alarm to match, got %d: %s", rec.Code, rec.Body)
+	}
+	if r := b.external.result(db, time.Now()); r.Up || r.Error != "orders-db-cpu" {
+		t.Errorf("expected the database to be down, got %+v", r)
+	}
+
+	post(notification("arn:aws:sns:eu-west-1:123456789012:alarms", "INSUFFICIENT_DATA"))
+	if r := b.external.result(db, time.Now()); r.Up {
+		t.Errorf("expected missing data to leave the alarm as it was")
+	}
+
+	// Alarms can also be posted without the SNS envelope.
+	if rec := post(strings.Replace(testAlarm, "%s", "OK", 1)); rec.Code != http.StatusOK {
+		t.Fatalf("expected a bare alarm to be accepted, got %d: %s", rec.Code, rec.Body)
+	}
+	if r := b.external.result(db, time.Now()); !r.Up {
+		t.Errorf("expected the database to recover, got %+v", r)
+	}
+}
+
+func TestConfirmSNSSubscriptionRefusesOtherHosts(t *testing.T) {
+	for _, u := range []string{
+		"http://sns.eu-west-1.amazonaws.com/",
+		"https://evil.example.com/?x=.amazonaws.com",
+		"https://sns.example.com/",
+		"https://sns.evil.example.amazonaws.com/",
+		"https://sns.eu-west-1.amazonaws.com.evil.example/",
+	} {
+		if err := confirmSNSSubscription(context.Background(), u); err == nil {
+			t.Errorf("expected %q to be refused", u)
+		}
+	}
+}
+
+func TestVerifySNSRefusesOtherCertificateHosts(t *testing.T) {
+	sign := testSNSSigner(t)
+	msg := sign(snsMessage{Type: "Notification", TopicArn: "arn:aws:sns:eu-west-1:123456789012:alarms", Message: "{}"})
+	if err := verifySNS(context.Background(), msg); err != nil {
+		t.Fatalf("expected a signed message to verify: %v", err)
+	}
+
+	msg.SigningCertURL = "https://evil.example.com/cert.pem"
+	if err := verifySNS(context.Background(), msg); err == nil {
+		t.Errorf("expected a certificate from another host to be refused")
+	}
+}
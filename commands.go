@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+)
+
+const commandHelp = "Usage:\n" +
+	"• `/status` — show the current board\n" +
+	"• `/status refresh` — re-check everything and update the board now\n" +
+	"• `/status check <service> [env]` — re-check a service now\n" +
+	"• `/status mute <service> <duration> [env]` — silence alerts, e.g. `30m`, `2h`, `1d`\n" +
+	"• `/status blackout <service|tag> <duration> [reason]` — silence alerts for planned work, with a notice\n" +
+	"• `/status note <service> <text>` — annotate the open incident\n" +
+	"• `/status add` — add a service\n" +
+	"• `/status edit <service> [env]` / `/status remove <service> [env]` — change or drop a service"
+
+// parseDuration extends time.ParseDuration with a "d" suffix for days.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func ephemeral(text string) map[string]any {
+	return map[string]any{"response_type": "ephemeral", "text": text}
+}
+
+// handleCommand answers a /status invocation. The returned payload is sent
+// back as the acknowledgement; slow work replies later through the
+// command's response URL.
+func (b *Bot) handleCommand(ctx context.Context, cmd slack.SlashCommand) map[string]any {
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 {
+		b.mu.Lock()
+		blocks := b.boardBlocks(b.results, BoardConfig{}, time.Now())
+		b.mu.Unlock()
+		return map[string]any{"response_type": "ephemeral", "blocks": blocks}
+	}
+
+	switch args[0] {
+	case "refresh":
+		go b.refresh(ctx, cmd.ResponseURL)
+		return ephemeral("Refreshing the board…")
+
+	case "add":
+		go b.openServiceModal(cmd.TriggerID, Service{})
+		return map[string]any{}
+
+	case "edit", "remove":
+		if len(args) < 2 {
+			return ephemeral(commandHelp)
+		}
+		svc, err := findService(b.services(), args[1], argAt(args, 2))
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		if args[0] == "edit" {
+			go b.openServiceModal(cmd.TriggerID, svc)
+			return map[string]any{}
+		}
+		if err := b.removeService(serviceKey(svc)); err != nil {
+			return ephemeral(err.Error())
+		}
+		return ephemeral(fmt.Sprintf("🗑️ *%s (%s)* is no longer monitored", svc.Name, svc.Env))
+
+	case "check":
+		if len(args) < 2 {
+			return ephemeral(commandHelp)
+		}
+		svc, err := findService(b.services(), args[1], argAt(args, 2))
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		go b.replyWithCheck(ctx, cmd.ResponseURL, svc)
+		return ephemeral(fmt.Sprintf("Checking *%s*…", svc.Name))
+
+	case "mute":
+		if len(args) < 3 {
+			return ephemeral(commandHelp)
+		}
+		svc, err := findService(b.services(), args[1], argAt(args, 3))
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		d, err := parseDuration(args[2])
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		until := b.mute(svc, time.Now().Add(d))
+		return ephemeral(fmt.Sprintf("🔇 Alerts for *%s (%s)* muted until %s", svc.Name, svc.Env, until.Format("2006-01-02 15:04")))
+
+	case "blackout":
+		if len(args) < 3 {
+			return ephemeral(commandHelp)
+		}
+		d, err := parseDuration(args[2])
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		bo, err := b.blackout(args[1], d, "<@"+cmd.UserID+">", strings.Join(args[3:], " "), time.Now())
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		return ephemeral(fmt.Sprintf("🚧 Alerts for %s silenced until %s", strings.Join(bo.Services, ", "), b.cfg.clock.format(bo.Until)))
+
+	case "note":
+		if len(args) < 3 {
+			return ephemeral(commandHelp)
+		}
+		svc, err := findService(b.services(), args[1], "")
+		if err != nil {
+			return ephemeral(err.Error())
+		}
+		text := strings.Join(args[2:], " ")
+		if _, err := b.addNote(svc, Note{Author: "<@" + cmd.UserID + ">", Text: text, At: time.Now()}); err != nil {
+			return ephemeral(err.Error())
+		}
+		return ephemeral(fmt.Sprintf("📝 Note added to the *%s* incident", svc.Name))
+	}
+
+	return ephemeral(commandHelp)
+}
+
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+func (b *Bot) mute(svc Service, until time.Time) time.Time {
+	return b.muteKey(serviceKey(svc), until)
+}
+
+func (b *Bot) muteKey(key string, until time.Time) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, exists := b.states[key]
+	if !exists {
+		state = &ServiceState{}
+		b.states[key] = state
+	}
+	state.MutedUntil = until
+	return until
+}
+
+// muteUntilFixed mutes the service identified by key until it recovers. It
+// reports false when the service isn't down, since the mute would then never
+// be lifted.
+func (b *Bot) muteUntilFixed(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.states[key]
+	if state == nil || !state.IsDown {
+		return false
+	}
+	state.MutedUntilFixed = true
+	return true
+}
+
+func (b *Bot) unmute(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state := b.states[key]; state != nil {
+		state.MutedUntil = time.Time{}
+		state.MutedUntilFixed = false
+	}
+}
+
+func (b *Bot) openServiceModal(triggerID string, svc Service) {
+	if _, err := b.api.OpenView(triggerID, serviceModal(svc)); err != nil {
+		slog.Error("failed to open service modal", "err", err)
+	}
+}
+
+// refresh re-checks every service, runs an out-of-band cycle and reports
+// back once the board is up to date.
+func (b *Bot) refresh(ctx context.Context, responseURL string) {
+	b.recheck(ctx)
+
+	text := "✅ Board refreshed"
+	if err := b.runCycle(ctx); err != nil {
+		text = fmt.Sprintf("⚠️ Refresh finished with errors: %v", err)
+	}
+
+	msg := &slack.WebhookMessage{ResponseType: "ephemeral", Text: text}
+	if err := slack.PostWebhookContext(ctx, responseURL, msg); err != nil {
+		slog.Error("failed to reply to command", "err", err)
+	}
+}
+
+// recheck has the service loops, when they run, check every service now, so
+// the next cycle doesn't only pick up what they found on their own timers.
+// Without loops every cycle checks everything anyway.
+func (b *Bot) recheck(ctx context.Context) {
+	b.cycleMu.Lock()
+	loops := b.loops
+	b.cycleMu.Unlock()
+	if loops != nil {
+		loops.checkNow(ctx, time.Duration(b.cfg.IntervalSeconds)*time.Second)
+	}
+}
+
+func (b *Bot) replyWithCheck(ctx context.Context, responseURL string, svc Service) {
+	r := b.checkOne(ctx, svc)
+
+	b.mu.Lock()
+	line := renderServiceLine(r, b.states, boardOptions{theme: b.cfg.Theme, templates: b.cfg.Templates})
+	b.mu.Unlock()
+
+	msg := &slack.WebhookMessage{ResponseType: "ephemeral", Text: line}
+	if err := slack.PostWebhookContext(ctx, responseURL, msg); err != nil {
+		slog.Error("failed to reply to command", "err", err)
+	}
+}
+
+// runSocketMode receives slash commands over a Socket Mode connection until
+// ctx is cancelled.
+func (b *Bot) runSocketMode(ctx context.Context) {
+	client := socketmode.New(b.api)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-client.Events:
+				if !ok {
+					return
+				}
+				b.handleSocketEvent(ctx, client, evt)
+			}
+		}
+	}()
+
+	if err := client.RunContext(ctx); err != nil && ctx.Err() == nil {
+		slog.Error("socket mode error", "err", err)
+	}
+}
+
+func (b *Bot) handleSocketEvent(ctx context.Context, client *socketmode.Client, evt socketmode.Event) {
+	switch evt.Type {
+	case socketmode.EventTypeConnected:
+		slog.Info("connected to Slack with Socket Mode")
+	case socketmode.EventTypeConnectionError:
+		var err error
+		if e, ok := evt.Data.(*slack.ConnectionErrorEvent); ok {
+			err = e.ErrorObj
+		}
+		slog.Warn("socket mode connection failed, retrying", "err", err)
+	case socketmode.EventTypeInteractive:
+		callback, ok := evt.Data.(slack.InteractionCallback)
+		if !ok {
+			return
+		}
+		if callback.Type == slack.InteractionTypeViewSubmission {
+			if resp := b.handleViewSubmission(callback); resp != nil {
+				client.Ack(*evt.Request, resp)
+			} else {
+				client.Ack(*evt.Request)
+			}
+			return
+		}
+		client.Ack(*evt.Request)
+		b.handleInteraction(callback)
+	case socketmode.EventTypeEventsAPI:
+		event, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			return
+		}
+		client.Ack(*evt.Request)
+		if ev, ok := event.InnerEvent.Data.(*slackevents.ReactionAddedEvent); ok {
+			b.handleReaction(ev)
+		}
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := evt.Data.(slack.SlashCommand)
+		if !ok {
+			return
+		}
+		client.Ack(*evt.Request, b.handleCommand(ctx, cmd))
+	}
+}
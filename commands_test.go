@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+func TestRefreshWithLoops(t *testing.T) {
+	api, _ := newTestSlack(t)
+
+	var down atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer target.Close()
+
+	replies := make(chan string, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		replies <- msg.Text
+	}))
+	defer hook.Close()
+
+	dir := t.TempDir()
+	b := newTestBot(Service{Name: "api", Env: "production", URL: target.URL})
+	b.api = api
+	b.client = target.Client()
+	b.cfg.IntervalSeconds = 60
+	b.cfg.StateFile = filepath.Join(dir, "state.json")
+	b.cfg.Theme = defaultTheme
+	b.recent = newRecentIncidents(3)
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(dir, ".board_ts"))}}
+	b.pool = checker.NewPool(1)
+	defer b.pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer b.startLoops(ctx)()
+	if err := b.runCycle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.results) != 1 || !b.results[0].Up {
+		t.Fatalf("expected the service to be up, got %+v", b.results)
+	}
+
+	down.Store(true)
+	b.refresh(ctx, hook.URL)
+	<-replies
+	if len(b.results) != 1 || b.results[0].Up {
+		t.Errorf("expected the refresh to check the service again, got %+v", b.results)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30m": 30 * time.Minute,
+		"2h":  2 * time.Hour,
+		"1d":  24 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := parseDuration(in)
+		if err != nil || got != want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "-1h", "0d"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q) should fail", in)
+		}
+	}
+}
+
+func TestHandleCommand_Mute(t *testing.T) {
+	b := newTestBot(Service{Name: "api", Env: "production"})
+
+	resp := b.handleCommand(context.Background(), slack.SlashCommand{Text: "mute api 1h"})
+	if text, _ := resp["text"].(string); !strings.Contains(text, "muted until") {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+
+	state := b.states["api:production"]
+	if state == nil || !state.muted(time.Now().Add(59*time.Minute)) || state.muted(time.Now().Add(61*time.Minute)) {
+		t.Errorf("expected service to be muted for 1h, got %+v", state)
+	}
+
+	transitions := []Transition{{Service: Service{Name: "api", Env: "production"}, Type: "down"}}
+	if got := unmuted(transitions, b.states, time.Now()); len(got) != 0 {
+		t.Errorf("expected muted transition to be dropped, got %d", len(got))
+	}
+}
+
+func TestHandleCommand_Help(t *testing.T) {
+	b := newTestBot(Service{Name: "api", Env: "production"})
+
+	for _, text := range []string{"help", "check", "mute api"} {
+		resp := b.handleCommand(context.Background(), slack.SlashCommand{Text: text})
+		if resp["text"] != commandHelp {
+			t.Errorf("%q: expected help text, got %v", text, resp)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	api, calls := newTestSlack(t)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	replies := make(chan string, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		replies <- msg.Text
+	}))
+	defer hook.Close()
+
+	dir := t.TempDir()
+	b := newTestBot(Service{Name: "api", Env: "production", URL: target.URL})
+	b.api = api
+	b.client = target.Client()
+	b.cfg.Concurrency = 1
+	b.cfg.StateFile = filepath.Join(dir, "state.json")
+	b.cfg.Theme = defaultTheme
+	b.recent = newRecentIncidents(3)
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(dir, ".board_ts"))}}
+
+	b.refresh(context.Background(), hook.URL)
+
+	if text := <-replies; text != "✅ Board refreshed" {
+		t.Errorf("unexpected reply %q", text)
+	}
+	if b.boards[0].ref.TS() == "" || len(calls()) == 0 {
+		t.Errorf("expected the board to be posted")
+	}
+}
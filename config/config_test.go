@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	Interval int `json:"interval_seconds"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("interval_seconds must be greater than 0")
+	}
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var cfg testConfig
+	if err := Load(write("ok.json", `{"interval_seconds": 30}`), &cfg); err != nil || cfg.Interval != 30 {
+		t.Fatalf("expected interval 30, got %d (%v)", cfg.Interval, err)
+	}
+	if err := Load(write("zero.json", `{}`), &testConfig{}); err == nil {
+		t.Errorf("expected the config to be validated")
+	}
+	if err := Load(write("bad.json", `{`), &testConfig{}); err == nil || !strings.HasPrefix(err.Error(), "parse json") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+	if err := Load(filepath.Join(dir, "missing.json"), &testConfig{}); err == nil || !strings.HasPrefix(err.Error(), "read file") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
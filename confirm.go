@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ConfirmationConfig re-checks a service on the failure that would take it
+// down, so a hiccup on the probing host doesn't raise an alert. The probe
+// opens a fresh connection and can resolve names through another DNS server
+// or go out through an HTTP proxy.
+type ConfirmationConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Resolver string `json:"resolver"`
+	Proxy    string `json:"proxy"`
+}
+
+func (c *ConfirmationConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Resolver != "" {
+		if _, _, err := net.SplitHostPort(c.Resolver); err != nil {
+			return fmt.Errorf("confirmation.resolver must be host:port: %w", err)
+		}
+	}
+	if c.Proxy != "" {
+		if u, err := url.Parse(c.Proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("confirmation.proxy must be a URL, got %q", c.Proxy)
+		}
+	}
+	return nil
+}
+
+// newConfirmationClient builds the client the confirmation probes use.
+// timeout bounds connecting; the probes bound the whole request themselves.
+func newConfirmationClient(cfg ConfirmationConfig, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if cfg.Resolver != "" {
+		dialer.Resolver = newResolver(cfg.Resolver)
+	}
+
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DialContext:       dialer.DialContext,
+		DisableKeepAlives: true,
+	}
+	if cfg.Proxy != "" {
+		proxy, _ := url.Parse(cfg.Proxy)
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Transport: transport}
+}
+
+// confirmFailures probes again every failed service that this failure would
+// take down, and replaces its result with the probe's when the probe passes.
+func (b *Bot) confirmFailures(ctx context.Context, results []CheckResult) {
+	if b.confirm == nil {
+		return
+	}
+
+	b.mu.Lock()
+	var pending []int
+	for i, r := range results {
+		state := b.states[serviceKey(r.Service)]
+		if !r.Up && r.Service.Source == "" && state != nil && !state.IsDown && state.FailCount+1 >= failThreshold {
+			pending = append(pending, i)
+		}
+	}
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			probe := checkService(ctx, b.confirm, results[i].Service, b.cfg)
+			if probe.Up {
+				slog.Info("confirmation probe passed, not declaring down", "service", probe.Service.Name, "env", probe.Service.Env, "error", results[i].Error)
+				results[i] = probe
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfirmFailures(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	flaky := Service{Name: "api", Env: "production", URL: srv.URL}
+	early := Service{Name: "web", Env: "production", URL: srv.URL}
+	down := Service{Name: "db", Env: "production", URL: srv.URL}
+
+	b := newTestBot(flaky, early, down)
+	b.states[serviceKey(flaky)] = &ServiceState{FailCount: failThreshold - 1}
+	b.states[serviceKey(early)] = &ServiceState{FailCount: 1}
+	b.states[serviceKey(down)] = &ServiceState{IsDown: true, FailCount: failThreshold}
+
+	results := []CheckResult{
+		{Service: flaky, Error: "request failed"},
+		{Service: early, Error: "request failed"},
+		{Service: down, Error: "request failed"},
+	}
+
+	b.confirmFailures(context.Background(), results)
+	if requests.Load() != 0 {
+		t.Fatalf("expected no probes while disabled, got %d", requests.Load())
+	}
+
+	b.confirm = newConfirmationClient(ConfirmationConfig{Enabled: true}, time.Second)
+	b.confirmFailures(context.Background(), results)
+
+	if requests.Load() != 1 {
+		t.Errorf("expected only the failure crossing the threshold to be probed, got %d probes", requests.Load())
+	}
+	if !results[0].Up || results[0].StatusCode != http.StatusOK {
+		t.Errorf("expected the passing probe to replace the failure, got %+v", results[0])
+	}
+	if results[1].Up || results[2].Up {
+		t.Errorf("expected the other failures to stand, got %+v", results[1:])
+	}
+}
+
+func TestConfirmationConfigValidate(t *testing.T) {
+	for _, cfg := range []ConfirmationConfig{
+		{Enabled: true, Resolver: "1.1.1.1"},
+		{Enabled: true, Proxy: "not a url"},
+	} {
+		if err := cfg.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+
+	ok := ConfirmationConfig{Enabled: true, Resolver: "1.1.1.1:53", Proxy: "http://proxy.internal:3128"}
+	if err := ok.validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
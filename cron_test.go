@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	s, err := parseCron("*/15 2-4 * * 1,7")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2024, 3, 4, 2, 30, 0, 0, time.UTC), true},  // Monday
+		{time.Date(2024, 3, 3, 4, 45, 0, 0, time.UTC), true},  // Sunday, written as 7
+		{time.Date(2024, 3, 4, 2, 31, 0, 0, time.UTC), false}, // off the step
+		{time.Date(2024, 3, 5, 2, 30, 0, 0, time.UTC), false}, // Tuesday
+		{time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), false},  // after the hours
+	}
+	for _, tt := range tests {
+		if got := s.matches(tt.at); got != tt.want {
+			t.Errorf("matches(%s) = %v, want %v", tt.at.Format(time.RFC1123), got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := parseCron(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+type DigestConfig struct {
+	Enabled bool     `json:"enabled"`
+	Time    string   `json:"time"`
+	Channel string   `json:"channel"`
+	Users   []string `json:"users"`
+}
+
+const defaultDigestTime = "09:00"
+
+// digestDue reports whether the daily digest should be sent at now, given the
+// day it was last sent.
+func digestDue(cfg DigestConfig, lastDigest string, now time.Time) bool {
+	if !cfg.Enabled {
+		return false
+	}
+
+	today := now.Format(dayLayout)
+	if lastDigest == today {
+		return false
+	}
+
+	at, err := time.Parse("15:04", cfg.Time)
+	if err != nil {
+		return false
+	}
+
+	sendAt := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	return !now.Before(sendAt)
+}
+
+type offender struct {
+	name      string
+	incidents int
+	downtime  time.Duration
+}
+
+func renderDigest(history *History, now time.Time) string {
+	from := now.Add(-24 * time.Hour)
+	incidents := history.incidentsBetween(from, now)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 *Daily status digest* (%s)\n", now.Format(dayLayout))
+	fmt.Fprintf(&b, "Incidents in the last 24h: *%d*\n", len(incidents))
+
+	byService := make(map[string]*offender)
+	for _, i := range incidents {
+		o, exists := byService[i.ServiceKey]
+		if !exists {
+			o = &offender{name: i.ServiceName}
+			byService[i.ServiceKey] = o
+		}
+		o.incidents++
+		o.downtime += i.Duration(now)
+	}
+
+	offenders := make([]*offender, 0, len(byService))
+	for _, o := range byService {
+		offenders = append(offenders, o)
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].downtime != offenders[j].downtime {
+			return offenders[i].downtime > offenders[j].downtime
+		}
+		return offenders[i].name < offenders[j].name
+	})
+	if len(offenders) > 3 {
+		offenders = offenders[:3]
+	}
+
+	if len(offenders) > 0 {
+		b.WriteString("\n*Worst offenders*\n")
+		for _, o := range offenders {
+			fmt.Fprintf(&b, "• *%s*: %d incident(s), down %s\n", o.name, o.incidents, formatDuration(o.downtime))
+		}
+	}
+
+	yesterday := now.AddDate(0, 0, -1).Format(dayLayout)
+	dayBefore := now.AddDate(0, 0, -2).Format(dayLayout)
+	if change, ok := latencyChange(history.Days[dayBefore], history.Days[yesterday]); ok {
+		fmt.Fprintf(&b, "\nAverage latency change (%s vs %s): *%+.0f%%*\n", yesterday, dayBefore, change)
+	}
+
+	var open []string
+	for _, i := range history.Incidents {
+		if i.Open() {
+			open = append(open, fmt.Sprintf("• *%s*: `%s` (%s)", i.ServiceName, i.Error, formatDuration(i.Duration(now))))
+		}
+	}
+
+	if len(open) > 0 {
+		b.WriteString("\n*Open incidents*\n" + strings.Join(open, "\n"))
+	} else {
+		b.WriteString("\nNo open incidents ✅")
+	}
+
+	return b.String()
+}
+
+// latencyChange returns the percentage change of the mean latency across all
+// services between two days.
+func latencyChange(before, after map[string]*DayStats) (float64, bool) {
+	avg := func(stats map[string]*DayStats) time.Duration {
+		var total time.Duration
+		var n int
+		for _, s := range stats {
+			if l := s.AvgLatency(); l > 0 {
+				total += l
+				n++
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return total / time.Duration(n)
+	}
+
+	b, a := avg(before), avg(after)
+	if b == 0 || a == 0 {
+		return 0, false
+	}
+	return (float64(a) - float64(b)) / float64(b) * 100, true
+}
+
+// sendDigestIfDue sends the digest once it is due, and records it as sent
+// once it was delivered.
+func (b *Bot) sendDigestIfDue(now time.Time) {
+	if !digestDue(b.cfg.Digest, b.history.LastDigest, now) {
+		return
+	}
+	if err := sendDigest(b.api, b.cfg.Digest, b.defaultChannel(), renderDigest(b.history, now)); err != nil {
+		slog.Error("failed to send digest, retrying next cycle", "err", err)
+		return
+	}
+	b.history.LastDigest = now.Format(dayLayout)
+}
+
+// sendDigest posts the digest to its channel and users. It fails only when
+// nobody got it, so a Slack outage has it retried on the next cycle, while a
+// single unreachable user doesn't have it sent to everyone else again.
+func sendDigest(api *slack.Client, cfg DigestConfig, defaultChannel string, message string) error {
+	channels := []string{}
+	if cfg.Channel != "" {
+		channels = append(channels, cfg.Channel)
+	} else if len(cfg.Users) == 0 {
+		channels = append(channels, defaultChannel)
+	}
+
+	var errs []error
+	for _, user := range cfg.Users {
+		ch, _, _, err := api.OpenConversation(&slack.OpenConversationParameters{Users: []string{user}})
+		if err != nil {
+			slog.Error("failed to open DM", "user", user, "err", err)
+			errs = append(errs, err)
+			continue
+		}
+		channels = append(channels, ch.ID)
+	}
+
+	delivered := false
+	for _, ch := range channels {
+		if _, _, err := api.PostMessage(ch, slack.MsgOptionText(message, false)); err != nil {
+			slog.Error("failed to post digest", "err", err)
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return fmt.Errorf("digest not delivered: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestDigestDue(t *testing.T) {
+	cfg := DigestConfig{Enabled: true, Time: "09:00"}
+	morning := time.Date(2024, 3, 1, 8, 59, 0, 0, time.UTC)
+	later := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	if digestDue(cfg, "", morning) {
+		t.Errorf("digest should not be due before its time")
+	}
+
+	if !digestDue(cfg, "2024-02-29", later) {
+		t.Errorf("digest should be due at its time")
+	}
+
+	if digestDue(cfg, "2024-03-01", later) {
+		t.Errorf("digest should only be sent once a day")
+	}
+
+	if digestDue(DigestConfig{Time: "09:00"}, "", later) {
+		t.Errorf("disabled digest should never be due")
+	}
+}
+
+func TestRenderDigest(t *testing.T) {
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	h := newHistory()
+	h.Incidents = []Incident{
+		{ServiceKey: "api:production", ServiceName: "api (production)", Error: "http_503", StartedAt: now.Add(-2 * time.Hour), EndedAt: now.Add(-time.Hour)},
+		{ServiceKey: "auth:production", ServiceName: "auth (production)", Error: "request failed", StartedAt: now.Add(-10 * time.Minute)},
+	}
+
+	digest := renderDigest(h, now)
+
+	for _, want := range []string{"Incidents in the last 24h: *2*", "*api (production)*: 1 incident(s), down 1h", "*Open incidents*", "auth (production)"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("digest missing %q:\n%s", want, digest)
+		}
+	}
+}
+
+func TestSendDigestIfDue_RetriesFailedPost(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			fmt.Fprint(w, `{"ok":false,"error":"internal_error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1700000000.000001"}`)
+	}))
+	defer srv.Close()
+
+	b := newTestBot()
+	b.api = slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.Digest = DigestConfig{Enabled: true, Time: "09:00"}
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	b.sendDigestIfDue(now)
+	if b.history.LastDigest != "" || posts.Load() == 0 {
+		t.Fatalf("expected a failed digest not to be recorded as sent, got %q after %d posts", b.history.LastDigest, posts.Load())
+	}
+
+	failing.Store(false)
+	b.sendDigestIfDue(now.Add(time.Minute))
+	if b.history.LastDigest != "2024-03-01" {
+		t.Errorf("expected the digest to be sent on the next cycle, got %q", b.history.LastDigest)
+	}
+}
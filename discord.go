@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+// DiscordConfig mirrors the board and alerts into Discord. With channel_id the
+// bot token in DISCORD_BOT_TOKEN is used; otherwise messages go through the
+// webhook in DISCORD_WEBHOOK_URL.
+type DiscordConfig struct {
+	Enabled   bool   `json:"enabled"`
+	ChannelID string `json:"channel_id"`
+}
+
+const (
+	discordAPI       = "https://discord.com/api/v10"
+	discordBoardPath = ".discord_board_id"
+
+	discordGreen = 0x2ecc71
+	discordRed   = 0xe74c3c
+)
+
+var errDiscordMessageGone = errors.New("discord message not found")
+
+type discordEmbed struct {
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Color       int       `json:"color,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type discordMessage struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+// discordNotifier keeps one board message up to date by editing it, and
+// posts down/up alerts as new messages.
+type discordNotifier struct {
+	client    *http.Client
+	messages  string
+	auth      string
+	theme     Theme
+	boardPath string
+}
+
+func newDiscordNotifier(cfg DiscordConfig, theme Theme) (*discordNotifier, error) {
+	n := &discordNotifier{
+		client:    &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+		theme:     theme,
+		boardPath: discordBoardPath,
+	}
+
+	if cfg.ChannelID != "" {
+		token := os.Getenv("DISCORD_BOT_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("DISCORD_BOT_TOKEN is not set")
+		}
+		n.messages = discordAPI + "/channels/" + cfg.ChannelID + "/messages"
+		n.auth = "Bot " + token
+		return n, nil
+	}
+
+	webhook := os.Getenv("DISCORD_WEBHOOK_URL")
+	if webhook == "" {
+		return nil, fmt.Errorf("DISCORD_WEBHOOK_URL is not set")
+	}
+	n.messages = strings.TrimRight(webhook, "/") + "/messages"
+	return n, nil
+}
+
+func (n *discordNotifier) Name() string {
+	return "discord"
+}
+
+func (n *discordNotifier) Notify(ctx context.Context, c Cycle) error {
+	if err := n.upsertBoard(ctx, discordBoard(c, n.theme)); err != nil {
+		return fmt.Errorf("update board: %w", err)
+	}
+
+	if alert, ok := discordAlert(c.Transitions, n.theme); ok {
+		if _, err := n.post(ctx, alert); err != nil {
+			return fmt.Errorf("post alert: %w", err)
+		}
+	}
+	return nil
+}
+
+// upsertBoard edits the stored board message, reposting it when it was
+// deleted.
+func (n *discordNotifier) upsertBoard(ctx context.Context, msg discordMessage) error {
+	if id := slackboard.LoadTS(n.boardPath); id != "" {
+		err := n.send(ctx, http.MethodPatch, n.messages+"/"+id, msg, nil)
+		if !errors.Is(err, errDiscordMessageGone) {
+			return err
+		}
+	}
+
+	id, err := n.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return slackboard.SaveTS(n.boardPath, id)
+}
+
+// post creates a message and returns its ID. Webhooks only answer with the
+// message when asked to wait for it.
+func (n *discordNotifier) post(ctx context.Context, msg discordMessage) (string, error) {
+	endpoint := n.messages
+	if n.auth == "" {
+		endpoint = strings.TrimSuffix(endpoint, "/messages") + "?wait=true"
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := n.send(ctx, http.MethodPost, endpoint, msg, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (n *discordNotifier) send(ctx context.Context, method string, endpoint string, msg discordMessage, out any) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.auth != "" {
+		req.Header.Set("Authorization", n.auth)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodPatch {
+		return errDiscordMessageGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// discordBoard renders the results as a single embed, red while anything is
+// down.
+func discordBoard(c Cycle, theme Theme) discordMessage {
+	title := theme.BoardTitle
+	if title == "" {
+		title = "Service status"
+	}
+
+	color := discordGreen
+	var lines []string
+	for _, r := range c.Results {
+		line := fmt.Sprintf("%s **%s** (%s) %dms", theme.UpEmoji, r.Service.Name, r.Service.Env, r.Latency.Milliseconds())
+		if !r.Up {
+			color = discordRed
+			line = fmt.Sprintf("%s **%s** (%s) `%s`", theme.DownEmoji, r.Service.Name, r.Service.Env, r.Error)
+		}
+		lines = append(lines, line)
+	}
+
+	return discordMessage{Embeds: []discordEmbed{{
+		Title:       title,
+		Description: strings.Join(lines, "\n"),
+		Color:       color,
+		Timestamp:   c.At,
+	}}}
+}
+
+// discordAlert groups the cycle's transitions into one message.
+func discordAlert(transitions []Transition, theme Theme) (discordMessage, bool) {
+	var down, up []string
+	for _, t := range transitions {
+		switch t.Type {
+		case "down":
+			down = append(down, fmt.Sprintf("• **%s**: `%s`", t.ServiceName, t.Error))
+		case "up":
+			line := fmt.Sprintf("• **%s** is back up", t.ServiceName)
+			if t.Downtime != "" {
+				line += " after " + t.Downtime
+			}
+			up = append(up, line)
+		}
+	}
+
+	var parts []string
+	if len(down) > 0 {
+		parts = append(parts, fmt.Sprintf("%s **%s**\n%s", theme.DownEmoji, theme.DownTitle, strings.Join(down, "\n")))
+	}
+	if len(up) > 0 {
+		parts = append(parts, fmt.Sprintf("%s **%s**\n%s", theme.UpEmoji, theme.UpTitle, strings.Join(up, "\n")))
+	}
+	if len(parts) == 0 {
+		return discordMessage{}, false
+	}
+	return discordMessage{Content: strings.Join(parts, "\n\n")}, true
+}
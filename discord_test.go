@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+func TestDiscordNotifier(t *testing.T) {
+	var requests []string
+	var posted []discordMessage
+	gone := map[string]bool{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		var msg discordMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+
+		switch r.Method {
+		case http.MethodPost:
+			if r.URL.Query().Get("wait") != "true" {
+				t.Errorf("expected webhook posts to wait for the message")
+			}
+			posted = append(posted, msg)
+			fmt.Fprintf(w, `{"id":"%d"}`, len(posted))
+		case http.MethodPatch:
+			if gone[r.URL.Path] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	n := &discordNotifier{
+		client:    srv.Client(),
+		messages:  srv.URL + "/webhooks/1/abc/messages",
+		theme:     defaultTheme,
+		boardPath: filepath.Join(t.TempDir(), ".discord_board_id"),
+	}
+
+	svc := Service{Name: "api", Env: "production"}
+	down := Cycle{
+		At:          time.Now(),
+		Results:     []CheckResult{{Service: svc, Error: "timeout"}},
+		Transitions: []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}},
+	}
+	if err := n.Notify(context.Background(), down); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(posted) != 2 || posted[0].Embeds[0].Color != discordRed {
+		t.Fatalf("expected a red board and an alert, got %+v", posted)
+	}
+	if !strings.Contains(posted[1].Content, "api (production)") {
+		t.Errorf("expected the alert to name the service, got %q", posted[1].Content)
+	}
+	if slackboard.LoadTS(n.boardPath) != "1" {
+		t.Errorf("expected the board message ID to be stored")
+	}
+
+	up := Cycle{At: time.Now(), Results: []CheckResult{{Service: svc, Up: true}}}
+	if err := n.Notify(context.Background(), up); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if last := requests[len(requests)-1]; last != "PATCH /webhooks/1/abc/messages/1" {
+		t.Errorf("expected the board to be edited, got %s", last)
+	}
+
+	gone["/webhooks/1/abc/messages/1"] = true
+	if err := n.Notify(context.Background(), up); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if slackboard.LoadTS(n.boardPath) != "3" {
+		t.Errorf("expected a deleted board to be reposted, got ID %q", slackboard.LoadTS(n.boardPath))
+	}
+}
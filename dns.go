@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSConfig controls how checks resolve the hosts they connect to. Resolver
+// sends the queries to that server instead of the system's. With Cache on,
+// answers are reused for as long as their records' TTL, clamped to
+// min_ttl_seconds and max_ttl_seconds, so frequent checks of many hosts
+// don't cost a lookup each.
+type DNSConfig struct {
+	Cache         bool   `json:"cache"`
+	Resolver      string `json:"resolver"`
+	MinTTLSeconds int    `json:"min_ttl_seconds"`
+	MaxTTLSeconds int    `json:"max_ttl_seconds"`
+}
+
+const (
+	defaultDNSMinTTL = 5
+	defaultDNSMaxTTL = 300
+)
+
+func (c *DNSConfig) validate() error {
+	if c.Resolver != "" {
+		if _, _, err := net.SplitHostPort(c.Resolver); err != nil {
+			return fmt.Errorf("dns.resolver must be host:port: %w", err)
+		}
+	}
+	if c.MinTTLSeconds < 0 || c.MaxTTLSeconds < 0 {
+		return fmt.Errorf("dns.min_ttl_seconds and dns.max_ttl_seconds can't be negative")
+	}
+	if c.MinTTLSeconds == 0 {
+		c.MinTTLSeconds = defaultDNSMinTTL
+	}
+	if c.MaxTTLSeconds == 0 {
+		c.MaxTTLSeconds = max(defaultDNSMaxTTL, c.MinTTLSeconds)
+	}
+	if c.MaxTTLSeconds < c.MinTTLSeconds {
+		return fmt.Errorf("dns.max_ttl_seconds must be at least dns.min_ttl_seconds")
+	}
+	return nil
+}
+
+// checkDialer returns the dial function of the check transport, or nil for
+// the default one.
+func checkDialer(cfg DNSConfig) func(ctx context.Context, network, address string) (net.Conn, error) {
+	switch {
+	case cfg.Cache:
+		return newDNSCache(cfg).dialContext(&net.Dialer{})
+	case cfg.Resolver != "":
+		return (&net.Dialer{Resolver: newResolver(cfg.Resolver)}).DialContext
+	}
+	return nil
+}
+
+// newResolver returns a resolver that queries server, or the system's
+// servers when it is empty. It notes the TTL of the answers for lookups
+// that ask for it through their context.
+func newResolver(server string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if server != "" {
+				address = server
+			}
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			// Answers over TCP come in pieces; their lookups fall back to
+			// the minimum TTL.
+			if ttl, ok := ctx.Value(dnsTTLKey{}).(*dnsTTL); ok {
+				if udp, ok := conn.(*net.UDPConn); ok {
+					return dnsTTLConn{UDPConn: udp, ttl: ttl}, nil
+				}
+			}
+			return conn, nil
+		},
+	}
+}
+
+type dnsTTLKey struct{}
+
+// dnsTTL is the lowest TTL among the answers of one lookup.
+type dnsTTL struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen bool
+}
+
+func (t *dnsTTL) observe(msg []byte) {
+	var p dnsmessage.Parser
+	if _, err := p.Start(msg); err != nil {
+		return
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+	for {
+		h, err := p.AnswerHeader()
+		if err != nil {
+			return
+		}
+		ttl := time.Duration(h.TTL) * time.Second
+		t.mu.Lock()
+		if !t.seen || ttl < t.ttl {
+			t.ttl, t.seen = ttl, true
+		}
+		t.mu.Unlock()
+		if err := p.SkipAnswer(); err != nil {
+			return
+		}
+	}
+}
+
+// dnsTTLConn passes DNS responses through, noting their TTLs. It stays a
+// packet conn so the resolver keeps speaking UDP to it.
+type dnsTTLConn struct {
+	*net.UDPConn
+	ttl *dnsTTL
+}
+
+func (c dnsTTLConn) Read(b []byte) (int, error) {
+	n, err := c.UDPConn.Read(b)
+	if n > 0 {
+		c.ttl.observe(b[:n])
+	}
+	return n, err
+}
+
+// dnsCache resolves hosts and keeps their addresses for as long as the
+// answers' TTL allows. Failed lookups aren't cached, so a host that stops
+// resolving fails its next check.
+type dnsCache struct {
+	resolver *net.Resolver
+	minTTL   time.Duration
+	maxTTL   time.Duration
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []netip.Addr
+	expires time.Time
+}
+
+func newDNSCache(cfg DNSConfig) *dnsCache {
+	return &dnsCache{
+		resolver: newResolver(cfg.Resolver),
+		minTTL:   time.Duration(cfg.MinTTLSeconds) * time.Second,
+		maxTTL:   time.Duration(cfg.MaxTTLSeconds) * time.Second,
+		entries:  make(map[string]dnsEntry),
+	}
+}
+
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]netip.Addr, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	ttl := &dnsTTL{}
+	addrs, err := c.resolver.LookupNetIP(context.WithValue(ctx, dnsTTLKey{}, ttl), "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := c.minTTL
+	ttl.mu.Lock()
+	if ttl.seen {
+		keep = min(max(ttl.ttl, c.minTTL), c.maxTTL)
+	}
+	ttl.mu.Unlock()
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(keep)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext dials through dialer, resolving the host from the cache and
+// trying its addresses in turn.
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := netip.ParseAddr(host); err == nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var firstErr error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return nil, firstErr
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newTestDNS starts a DNS server answering every A query with 127.0.0.1 and
+// ttl, and returns its address and a count of the queries it got.
+func newTestDNS(t *testing.T, ttl uint32) (string, *atomic.Int32) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	var queries atomic.Int32
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			queries.Add(1)
+
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionDesired: h.RecursionDesired, RecursionAvailable: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			if q.Type == dnsmessage.TypeA {
+				b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl}, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+			}
+			msg, err := b.Finish()
+			if err != nil {
+				continue
+			}
+			pc.WriteTo(msg, from)
+		}
+	}()
+	return pc.LocalAddr().String(), &queries
+}
+
+func TestDNSCache(t *testing.T) {
+	server, queries := newTestDNS(t, 60)
+	cfg := DNSConfig{Cache: true, Resolver: server}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	cache := newDNSCache(cfg)
+
+	started := time.Now()
+	addrs, err := cache.lookup(context.Background(), "api.example.test")
+	if err != nil || len(addrs) != 1 || addrs[0].String() != "127.0.0.1" {
+		t.Fatalf("expected 127.0.0.1, got %v (%v)", addrs, err)
+	}
+	asked := queries.Load()
+	if _, err := cache.lookup(context.Background(), "api.example.test"); err != nil || queries.Load() != asked {
+		t.Errorf("expected the second lookup to come from the cache, got %d queries after %d", queries.Load(), asked)
+	}
+
+	expires := cache.entries["api.example.test"].expires
+	if keep := expires.Sub(started); keep < 59*time.Second || keep > 61*time.Second {
+		t.Errorf("expected the answer to be kept for its 60s TTL, got %v", keep)
+	}
+}
+
+func TestDNSCache_ClampsTTL(t *testing.T) {
+	server, _ := newTestDNS(t, 1)
+	cfg := DNSConfig{Cache: true, Resolver: server, MinTTLSeconds: 30}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	cache := newDNSCache(cfg)
+
+	started := time.Now()
+	if _, err := cache.lookup(context.Background(), "api.example.test"); err != nil {
+		t.Fatal(err)
+	}
+	if keep := cache.entries["api.example.test"].expires.Sub(started); keep < 29*time.Second {
+		t.Errorf("expected a 1s TTL to be raised to the 30s minimum, got %v", keep)
+	}
+}
+
+func TestCheckDialer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	server, _ := newTestDNS(t, 60)
+
+	if checkDialer(DNSConfig{}) != nil {
+		t.Errorf("expected the default dialer without dns settings")
+	}
+
+	u, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: &http.Transport{DialContext: checkDialer(DNSConfig{Cache: true, Resolver: server, MinTTLSeconds: 5, MaxTTLSeconds: 300})}}
+	svc := Service{Name: "api", URL: "http://api.example.test:" + u.Port()}
+	if r := checkService(context.Background(), client, svc, Config{TimeoutMs: 2000}); !r.Up {
+		t.Errorf("expected the check to reach the server through the cache, got %+v", r)
+	}
+}
+
+func TestDNSConfigValidate(t *testing.T) {
+	cfg := DNSConfig{}
+	if err := cfg.validate(); err != nil || cfg.MinTTLSeconds != defaultDNSMinTTL || cfg.MaxTTLSeconds != defaultDNSMaxTTL {
+		t.Errorf("expected the default TTL bounds, got %+v (%v)", cfg, err)
+	}
+	for _, bad := range []DNSConfig{
+		{Resolver: "10.0.0.1"},
+		{MinTTLSeconds: -1},
+		{MinTTLSeconds: 60, MaxTTLSeconds: 30},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
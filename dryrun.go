@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+// dryRunReads are the Slack API methods a dry run still calls: they only
+// read, and the cycle needs their answers.
+var dryRunReads = []string{
+	"auth.", "bots.", "team.", "users.info", "users.lookupByEmail", "usergroups.",
+	"conversations.history", "conversations.info", "conversations.replies", "chat.getPermalink",
+}
+
+// dryRunTransport prints the Slack API calls that would change something
+// instead of sending them, and answers them as Slack would.
+type dryRunTransport struct {
+	base http.RoundTripper
+	out  io.Writer
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := strings.TrimPrefix(req.URL.Path, "/api/")
+	for _, prefix := range dryRunReads {
+		if strings.HasPrefix(method, prefix) {
+			return t.base.RoundTrip(req)
+		}
+	}
+
+	form, err := requestForm(req)
+	if err != nil {
+		return nil, fmt.Errorf("dry run: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	ts := form.Get("ts")
+	if ts == "" {
+		ts = fmt.Sprintf("9999999999.%06d", t.seq)
+	}
+	t.print(method, form)
+
+	var body any = map[string]any{"ok": true, "channel": form.Get("channel"), "ts": ts, "scheduled_message_id": "dry-run"}
+	if strings.HasPrefix(method, "conversations.") {
+		body = map[string]any{"ok": true, "channel": map[string]string{"id": form.Get("channel")}}
+	}
+	data, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    req,
+	}, nil
+}
+
+func (t *dryRunTransport) print(method string, form url.Values) {
+	var target []string
+	for _, field := range []string{"channel", "ts", "thread_ts", "post_at", "users"} {
+		if v := form.Get(field); v != "" {
+			target = append(target, field+"="+v)
+		}
+	}
+	fmt.Fprintf(t.out, "--- %s %s\n", method, strings.Join(target, " "))
+
+	if text := form.Get("text"); text != "" {
+		fmt.Fprintln(t.out, text)
+	}
+	if raw := form.Get("blocks"); raw != "" {
+		var blocks slack.Blocks
+		if err := json.Unmarshal([]byte(raw), &blocks); err != nil {
+			fmt.Fprintln(t.out, raw)
+		} else {
+			fmt.Fprint(t.out, blocksText(blocks.BlockSet))
+		}
+	}
+	if profile := form.Get("profile"); profile != "" {
+		fmt.Fprintln(t.out, profile)
+	}
+	fmt.Fprintln(t.out)
+}
+
+// requestForm returns the parameters of a Slack API call, which slack-go
+// sends either form-encoded or as JSON. The request body stays readable.
+func requestForm(req *http.Request) (url.Values, error) {
+	if req.Body == nil {
+		return req.URL.Query(), nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+		return url.ParseQuery(string(data))
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	form := url.Values{}
+	for k, v := range fields {
+		if s, ok := v.(string); ok {
+			form.Set(k, s)
+			continue
+		}
+		raw, _ := json.Marshal(v)
+		form.Set(k, string(raw))
+	}
+	return form, nil
+}
+
+// blocksText renders Block Kit blocks as plain text, one line per section,
+// field and context element, with buttons in brackets.
+func blocksText(blocks []slack.Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		switch block := block.(type) {
+		case *slack.HeaderBlock:
+			fmt.Fprintf(&b, "# %s\n", block.Text.Text)
+		case *slack.SectionBlock:
+			if block.Text != nil {
+				fmt.Fprintln(&b, block.Text.Text)
+			}
+			for _, f := range block.Fields {
+				fmt.Fprintf(&b, "  %s\n", f.Text)
+			}
+			if block.Accessory != nil && block.Accessory.ButtonElement != nil {
+				fmt.Fprintf(&b, "  [%s]\n", block.Accessory.ButtonElement.Text.Text)
+			}
+		case *slack.ContextBlock:
+			var parts []string
+			for _, e := range block.ContextElements.Elements {
+				if text, ok := e.(*slack.TextBlockObject); ok {
+					parts = append(parts, text.Text)
+				}
+			}
+			fmt.Fprintf(&b, "  %s\n", strings.Join(parts, "  "))
+		case *slack.ActionBlock:
+			var buttons []string
+			if block.Elements != nil {
+				for _, e := range block.Elements.ElementSet {
+					if button, ok := e.(*slack.ButtonBlockElement); ok {
+						buttons = append(buttons, "["+button.Text.Text+"]")
+					}
+				}
+			}
+			fmt.Fprintf(&b, "  %s\n", strings.Join(buttons, " "))
+		case *slack.DividerBlock:
+			fmt.Fprintln(&b, "----")
+		case *slack.ImageBlock:
+			fmt.Fprintf(&b, "<image %s>\n", block.ImageURL)
+		}
+	}
+	return b.String()
+}
+
+// dryRunNotifier stands in for a notifier during a dry run, printing the
+// alerts it would have sent.
+type dryRunNotifier struct {
+	name string
+	out  io.Writer
+}
+
+func (n dryRunNotifier) Name() string {
+	return n.name
+}
+
+func (n dryRunNotifier) Notify(ctx context.Context, c Cycle) error {
+	for _, t := range c.Transitions {
+		fmt.Fprintf(n.out, "--- %s: %s is %s", n.name, t.ServiceName, t.Type)
+		if t.Error != "" {
+			fmt.Fprintf(n.out, " (%s)", t.Error)
+		}
+		fmt.Fprintln(n.out)
+	}
+	return nil
+}
+
+// dryRunConfig turns off the integrations that would write somewhere other
+// than Slack and the notifiers: issue trackers, SMS, metrics sinks, backups,
+// the audit log and report files.
+func dryRunConfig(cfg Config) Config {
+	cfg.GitHubIssues.Enabled = false
+	cfg.Jira.Enabled = false
+	cfg.SMS.Enabled = false
+	cfg.StatsD.Enabled = false
+	cfg.Pushgateway.Enabled = false
+	cfg.Backup.Enabled = false
+	cfg.AuditLog.Enabled = false
+
+	reports := make([]ReportConfig, len(cfg.Reports))
+	for i, r := range cfg.Reports {
+		r.OutputDir = ""
+		reports[i] = r
+	}
+	cfg.Reports = reports
+	return cfg
+}
+
+// dryRunScratch copies the state file and board timestamps into a temporary
+// directory and points cfg and boards at the copies, so a dry run starts
+// from the real state without changing it.
+func dryRunScratch(cfg *Config, boards []BoardConfig) (string, error) {
+	dir, err := os.MkdirTemp("", "status-bot-dry-run")
+	if err != nil {
+		return "", fmt.Errorf("create scratch dir: %w", err)
+	}
+
+	scratch := func(path string) (string, error) {
+		copied := filepath.Join(dir, filepath.Base(path))
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return copied, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return copied, os.WriteFile(copied, data, 0600)
+	}
+
+	if cfg.StateFile, err = scratch(cfg.StateFile); err != nil {
+		return dir, fmt.Errorf("copy state: %w", err)
+	}
+	for i := range boards {
+		path, err := scratch(boards[i].ref.Path())
+		if err != nil {
+			return dir, fmt.Errorf("copy board timestamps: %w", err)
+		}
+		boards[i].ref = slackboard.NewRef(path)
+	}
+	slog.Info("dry run: Slack writes are printed, not sent", "scratch", dir)
+	return dir, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	var reached atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true,"messages":[]}`))
+	}))
+	defer srv.Close()
+
+	var out strings.Builder
+	api := slack.New("xoxb-test",
+		slack.OptionAPIURL(srv.URL+"/api/"),
+		slack.OptionHTTPClient(&http.Client{Transport: &dryRunTransport{base: http.DefaultTransport, out: &out}}),
+	)
+
+	_, ts, err := api.PostMessage("C1", slack.MsgOptionBlocks(
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Status", false, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "🔴 *api*", false, false), nil, nil),
+	))
+	if err != nil || ts == "" {
+		t.Fatalf("post: ts %q, err %v", ts, err)
+	}
+	if _, updated, _, err := api.UpdateMessage("C1", ts, slack.MsgOptionText("edited", false)); err != nil || updated != ts {
+		t.Fatalf("update: ts %q, err %v", updated, err)
+	}
+	if _, err := api.GetConversationHistory(&slack.GetConversationHistoryParameters{ChannelID: "C1"}); err != nil {
+		t.Fatalf("history: %v", err)
+	}
+
+	if reached.Load() != 1 {
+		t.Errorf("expected only the read to reach Slack, got %d calls", reached.Load())
+	}
+	for _, want := range []string{"--- chat.postMessage channel=C1", "# Status\n🔴 *api*\n", "--- chat.update channel=C1 ts=" + ts, "edited"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDryRunScratch(t *testing.T) {
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state.json")
+	os.WriteFile(state, []byte(`{"states":{}}`), 0600)
+
+	cfg := Config{StateFile: state}
+	boards := []BoardConfig{{Name: "main", ref: slackboard.NewRef(filepath.Join(dir, ".board_ts"))}}
+	scratch, err := dryRunScratch(&cfg, boards)
+	defer os.RemoveAll(scratch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Dir(cfg.StateFile) != scratch || filepath.Dir(boards[0].ref.Path()) != scratch {
+		t.Fatalf("expected paths in %s, got %s and %s", scratch, cfg.StateFile, boards[0].ref.Path())
+	}
+	if data, _ := os.ReadFile(cfg.StateFile); string(data) != `{"states":{}}` {
+		t.Errorf("expected the state to be copied, got %q", data)
+	}
+	if _, err := os.Stat(boards[0].ref.Path()); !os.IsNotExist(err) {
+		t.Errorf("expected no board file when there was none, got %v", err)
+	}
+}
+
+func TestDryRunNotifier(t *testing.T) {
+	var out strings.Builder
+	b := newTestBot()
+	b.dryRun = &out
+	b.register(newNtfyNotifier(NtfyConfig{}))
+
+	if b.notifiers[0].Name() != "ntfy" {
+		t.Fatalf("expected the notifier to keep its name, got %s", b.notifiers[0].Name())
+	}
+	b.notifiers[0].Notify(context.Background(), Cycle{Transitions: []Transition{{ServiceName: "api (production)", Type: "down", Error: "http_503"}}})
+	if out.String() != "--- ntfy: api (production) is down (http_503)\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
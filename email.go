@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig sends down/up alerts and an optional daily summary over SMTP.
+// Recipients are keyed by service ("name:env"), env or "default"; the most
+// specific entry wins. SMTP_USERNAME and SMTP_PASSWORD enable authentication.
+type EmailConfig struct {
+	Enabled    bool                `json:"enabled"`
+	Host       string              `json:"host"`
+	Port       int                 `json:"port"`
+	From       string              `json:"from"`
+	Recipients map[string][]string `json:"recipients"`
+
+	// DailySummary mails the digest at digest.time to summary_to, or to the
+	// default recipients.
+	DailySummary bool     `json:"daily_summary"`
+	SummaryTo    []string `json:"summary_to"`
+}
+
+const defaultSMTPPort = 587
+
+func (c *EmailConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Host == "" || c.From == "" {
+		return fmt.Errorf("email.host and email.from are required")
+	}
+	if c.Port <= 0 {
+		c.Port = defaultSMTPPort
+	}
+	if len(c.Recipients) == 0 {
+		return fmt.Errorf("email.recipients is empty")
+	}
+	if len(c.SummaryTo) == 0 {
+		c.SummaryTo = c.Recipients["default"]
+	}
+	return nil
+}
+
+// recipientsFor returns who gets mail about svc.
+func (c EmailConfig) recipientsFor(svc Service) []string {
+	if to := c.Recipients[serviceKey(svc)]; len(to) > 0 {
+		return to
+	}
+	if to := c.Recipients[svc.Env]; len(to) > 0 {
+		return to
+	}
+	return c.Recipients["default"]
+}
+
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+type emailNotifier struct {
+	cfg        EmailConfig
+	digestTime string
+	auth       smtp.Auth
+	send       sendMailFunc
+}
+
+func newEmailNotifier(cfg EmailConfig, digestTime string) *emailNotifier {
+	n := &emailNotifier{cfg: cfg, digestTime: digestTime, send: smtp.SendMail}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		n.auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), cfg.Host)
+	}
+	return n
+}
+
+func (n *emailNotifier) Name() string {
+	return "email"
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, c Cycle) error {
+	// Every recipient gets a single mail covering all of their services.
+	byRecipient := make(map[string][]Transition)
+	for _, t := range c.Transitions {
+		if t.Type != "down" && t.Type != "up" {
+			continue
+		}
+		for _, to := range n.cfg.recipientsFor(t.Service) {
+			byRecipient[to] = append(byRecipient[to], t)
+		}
+	}
+
+	recipients := make([]string, 0, len(byRecipient))
+	for to := range byRecipient {
+		recipients = append(recipients, to)
+	}
+	sort.Strings(recipients)
+
+	for _, to := range recipients {
+		subject, body := alertEmail(byRecipient[to])
+		if err := n.mail([]string{to}, subject, body, c.At); err != nil {
+			return fmt.Errorf("mail %s: %w", to, err)
+		}
+	}
+
+	if c.History == nil || len(n.cfg.SummaryTo) == 0 {
+		return nil
+	}
+	due := digestDue(DigestConfig{Enabled: n.cfg.DailySummary, Time: n.digestTime}, c.History.LastEmailSummary, c.At)
+	if !due {
+		return nil
+	}
+	summary := strings.ReplaceAll(renderDigest(c.History, c.At), "*", "")
+	if err := n.mail(n.cfg.SummaryTo, "[status] Daily summary "+c.At.Format(dayLayout), summary, c.At); err != nil {
+		return fmt.Errorf("mail daily summary: %w", err)
+	}
+	c.History.LastEmailSummary = c.At.Format(dayLayout)
+	return nil
+}
+
+func (n *emailNotifier) mail(to []string, subject string, body string, now time.Time) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	return n.send(addr, n.auth, n.cfg.From, to, []byte(msg.String()))
+}
+
+// alertEmail renders the transitions one recipient cares about.
+func alertEmail(transitions []Transition) (string, string) {
+	var down, up []string
+	var lines []string
+	for _, t := range transitions {
+		switch t.Type {
+		case "down":
+			down = append(down, t.ServiceName)
+			lines = append(lines, fmt.Sprintf("DOWN  %s: %s", t.ServiceName, t.Error))
+		case "up":
+			up = append(up, t.ServiceName)
+			line := fmt.Sprintf("UP    %s", t.ServiceName)
+			if t.Downtime != "" {
+				line += " after " + t.Downtime
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	var subject string
+	switch {
+	case len(transitions) == 1 && len(down) == 1:
+		subject = fmt.Sprintf("[status] %s is down", down[0])
+	case len(transitions) == 1:
+		subject = fmt.Sprintf("[status] %s recovered", up[0])
+	default:
+		var parts []string
+		if len(down) > 0 {
+			parts = append(parts, fmt.Sprintf("%d down", len(down)))
+		}
+		if len(up) > 0 {
+			parts = append(parts, fmt.Sprintf("%d recovered", len(up)))
+		}
+		subject = "[status] " + strings.Join(parts, ", ")
+	}
+
+	slices.Sort(lines)
+	return subject, strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	to  []string
+	msg string
+}
+
+func TestEmailNotifier(t *testing.T) {
+	var sent []sentMail
+	n := &emailNotifier{
+		cfg: EmailConfig{
+			Host: "smtp.example.com",
+			Port: 587,
+			From: "status@example.com",
+			Recipients: map[string][]string{
+				"default":        {"ops@example.com"},
+				"api:production": {"api-team@example.com"},
+			},
+			DailySummary: true,
+			SummaryTo:    []string{"ops@example.com"},
+		},
+		digestTime: "09:00",
+		send: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			if addr != "smtp.example.com:587" {
+				t.Errorf("unexpected server %s", addr)
+			}
+			sent = append(sent, sentMail{to, string(msg)})
+			return nil
+		},
+	}
+
+	api := Service{Name: "api", Env: "production"}
+	web := Service{Name: "web", Env: "staging"}
+	history := newHistory()
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	err := n.Notify(context.Background(), Cycle{
+		At: now,
+		Transitions: []Transition{
+			{Service: api, ServiceName: "api (production)", Type: "down", Error: "timeout"},
+			{Service: web, ServiceName: "web (staging)", Type: "up", Downtime: "5m"},
+		},
+		History: history,
+	})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("expected one mail per recipient, got %d", len(sent))
+	}
+	if sent[0].to[0] != "api-team@example.com" || !strings.Contains(sent[0].msg, "Subject: [status] api (production) is down") {
+		t.Errorf("expected the api team to hear about api, got %+v", sent[0])
+	}
+	if sent[1].to[0] != "ops@example.com" || !strings.Contains(sent[1].msg, "Subject: [status] web (staging) recovered") {
+		t.Errorf("expected ops to hear about web, got %+v", sent[1])
+	}
+
+	sent = nil
+	n.Notify(context.Background(), Cycle{At: now.Add(2 * time.Hour), History: history})
+	n.Notify(context.Background(), Cycle{At: now.Add(3 * time.Hour), History: history})
+	if len(sent) != 1 || !strings.Contains(sent[0].msg, "Subject: [status] Daily summary 2024-03-01") {
+		t.Errorf("expected a single daily summary, got %+v", sent)
+	}
+	if strings.Contains(sent[0].msg, "*") {
+		t.Errorf("expected Slack formatting to be stripped from the summary")
+	}
+}
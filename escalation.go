@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+type EscalationConfig struct {
+	Channel      string `json:"channel"`
+	AfterMinutes int    `json:"after_minutes"`
+}
+
+func (e EscalationConfig) enabled() bool {
+	return e.Channel != "" && e.AfterMinutes > 0
+}
+
+// dueEscalations returns the services that have been down longer than the
+// escalation threshold and haven't been escalated yet. Muted services are
+// left alone.
+func dueEscalations(cfg EscalationConfig, services []Service, states map[string]*ServiceState, now time.Time) []Service {
+	if !cfg.enabled() {
+		return nil
+	}
+
+	after := time.Duration(cfg.AfterMinutes) * time.Minute
+	var due []Service
+	for _, svc := range services {
+		state := states[serviceKey(svc)]
+		if state == nil || !state.IsDown || state.Escalated || state.muted(now) || inMaintenance(svc, now) {
+			continue
+		}
+		if now.Sub(state.DownSince) >= after {
+			due = append(due, svc)
+		}
+	}
+	return due
+}
+
+func (b *Bot) sendEscalations(now time.Time) {
+	for _, svc := range dueEscalations(b.cfg.Escalation, b.cfg.Services, b.states, now) {
+		if !b.cfg.alerting(svc, now) {
+			continue
+		}
+		state := b.states[serviceKey(svc)]
+
+		msg := fmt.Sprintf("🚨 *Escalation: %s (%s) has been down for %s*\n`%s`",
+			svc.Name, svc.Env, formatDuration(now.Sub(state.DownSince)), state.LastError)
+
+		if link := b.incidentPermalink(svc); link != "" {
+			msg += fmt.Sprintf("\n<%s|Incident thread>", link)
+		}
+
+		if _, _, err := b.api.PostMessage(b.cfg.Escalation.Channel, slack.MsgOptionText(msg, false)); err != nil {
+			slog.Error("failed to post escalation", "err", err)
+			continue
+		}
+		state.Escalated = true
+	}
+}
+
+// dueReminders returns the services that are still down, unacknowledged and
+// unmuted, and haven't been reminded about for a full interval.
+func dueReminders(interval time.Duration, services []Service, states map[string]*ServiceState, now time.Time) []Service {
+	if interval <= 0 {
+		return nil
+	}
+
+	var due []Service
+	for _, svc := range services {
+		state := states[serviceKey(svc)]
+		if state == nil || !state.IsDown || state.AckedBy != "" || state.muted(now) || inMaintenance(svc, now) {
+			continue
+		}
+
+		last := state.DownSince
+		if state.LastReminder.After(last) {
+			last = state.LastReminder
+		}
+		if now.Sub(last) >= interval {
+			due = append(due, svc)
+		}
+	}
+	return due
+}
+
+func (b *Bot) sendReminders(now time.Time) {
+	interval := time.Duration(b.cfg.ReminderIntervalMinutes) * time.Minute
+
+	for _, svc := range dueReminders(interval, b.cfg.Services, b.states, now) {
+		state := b.states[serviceKey(svc)]
+		if state.IncidentTS == "" || !b.cfg.alerting(svc, now) {
+			continue
+		}
+
+		mention := ownerMentions(svc)
+		if mention == "" {
+			mention = policyMention(b.cfg.Mentions, svc.Env)
+		}
+		if b.cfg.QuietHours.active(svc.Env, now) {
+			mention = ""
+		}
+
+		msg := fmt.Sprintf("⏰ Reminder: *%s (%s)* is still down after %s, not acknowledged yet `%s` %s",
+			svc.Name, svc.Env, formatDuration(now.Sub(state.DownSince)), state.LastError, mention)
+
+		if err := postIncidentReply(b.api, primaryChannel(b.boards, svc), state.IncidentTS, msg); err != nil {
+			slog.Error("failed to post reminder", "err", err)
+			continue
+		}
+		state.LastReminder = now
+	}
+}
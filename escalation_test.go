@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueEscalations(t *testing.T) {
+	now := time.Now()
+	cfg := EscalationConfig{Channel: "CINC", AfterMinutes: 30}
+	services := []Service{
+		{Name: "api", Env: "production"},
+		{Name: "auth", Env: "production"},
+		{Name: "db", Env: "production"},
+		{Name: "web", Env: "production"},
+	}
+	states := map[string]*ServiceState{
+		"api:production":  {IsDown: true, DownSince: now.Add(-31 * time.Minute)},
+		"auth:production": {IsDown: true, DownSince: now.Add(-5 * time.Minute)},
+		"db:production":   {IsDown: true, DownSince: now.Add(-time.Hour), Escalated: true},
+		"web:production":  {IsDown: true, DownSince: now.Add(-time.Hour), MutedUntilFixed: true},
+	}
+
+	due := dueEscalations(cfg, services, states, now)
+	if len(due) != 1 || due[0].Name != "api" {
+		t.Fatalf("expected only api to escalate, got %+v", due)
+	}
+
+	if got := dueEscalations(EscalationConfig{}, services, states, now); len(got) != 0 {
+		t.Errorf("disabled escalation should never be due, got %+v", got)
+	}
+}
+
+func TestDueReminders(t *testing.T) {
+	now := time.Now()
+	services := []Service{
+		{Name: "api", Env: "production"},
+		{Name: "auth", Env: "production"},
+		{Name: "db", Env: "production"},
+		{Name: "web", Env: "production"},
+	}
+	states := map[string]*ServiceState{
+		"api:production":  {IsDown: true, DownSince: now.Add(-31 * time.Minute)},
+		"auth:production": {IsDown: true, DownSince: now.Add(-2 * time.Hour), LastReminder: now.Add(-10 * time.Minute)},
+		"db:production":   {IsDown: true, DownSince: now.Add(-time.Hour), AckedBy: "U1"},
+		"web:production":  {IsDown: false},
+	}
+
+	due := dueReminders(30*time.Minute, services, states, now)
+	if len(due) != 1 || due[0].Name != "api" {
+		t.Fatalf("expected only api to be reminded, got %+v", due)
+	}
+
+	if got := dueReminders(0, services, states, now); len(got) != 0 {
+		t.Errorf("reminders should be off without an interval, got %+v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// eventLog is where the Windows event log output goes. It is implemented by
+// *eventlog.Log from golang.org/x/sys/windows/svc/eventlog.
+type eventLog interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+}
+
+// eventLogID is the event ID of every entry the bot writes. The source is
+// registered with EventCreate.exe's message file, which accepts 1 to 1000.
+const eventLogID = 1
+
+// eventLogHandler writes each record to the event log as its own entry, of
+// the type matching its level. Entries are formatted as text, without time
+// and level since the event log records both.
+type eventLogHandler struct {
+	log  eventLog
+	text slog.Handler
+
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+}
+
+func newEventLogHandler(log eventLog, level slog.Leveler) *eventLogHandler {
+	buf := &bytes.Buffer{}
+	return &eventLogHandler{
+		log: log,
+		text: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+		mu:  &sync.Mutex{},
+		buf: buf,
+	}
+}
+
+func (h *eventLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.text.Enabled(ctx, level)
+}
+
+func (h *eventLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.text.Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(h.buf.String(), "\n")
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.log.Error(eventLogID, msg)
+	case r.Level >= slog.LevelWarn:
+		return h.log.Warning(eventLogID, msg)
+	default:
+		return h.log.Info(eventLogID, msg)
+	}
+}
+
+func (h *eventLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.text = h.text.WithAttrs(attrs)
+	return &clone
+}
+
+func (h *eventLogHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.text = h.text.WithGroup(name)
+	return &clone
+}
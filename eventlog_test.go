@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+)
+
+type testEventLog struct {
+	entries []string
+}
+
+func (l *testEventLog) Info(eid uint32, msg string) error {
+	l.entries = append(l.entries, "info: "+msg)
+	return nil
+}
+
+func (l *testEventLog) Warning(eid uint32, msg string) error {
+	l.entries = append(l.entries, "warning: "+msg)
+	return nil
+}
+
+func (l *testEventLog) Error(eid uint32, msg string) error {
+	l.entries = append(l.entries, "error: "+msg)
+	return nil
+}
+
+func TestEventLogHandler(t *testing.T) {
+	events := &testEventLog{}
+	logger := slog.New(newEventLogHandler(events, slog.LevelInfo)).With("board", "main")
+
+	logger.Debug("hidden")
+	logger.Info("loaded config", "services", 3)
+	logger.Warn("cycle overran the interval")
+	logger.Error("failed to update board", "err", "rate_limited")
+
+	want := []string{
+		"info: msg=\"loaded config\" board=main services=3",
+		"warning: msg=\"cycle overran the interval\" board=main",
+		"error: msg=\"failed to update board\" board=main err=rate_limited",
+	}
+	if len(events.entries) != len(want) {
+		t.Fatalf("expected %d entries, got %q", len(want), events.entries)
+	}
+	for i := range want {
+		if events.entries[i] != want[i] {
+			t.Errorf("entry %d: expected %q, got %q", i, want[i], events.entries[i])
+		}
+	}
+}
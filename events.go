@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseKeepalive is how often an idle event stream gets a comment line, so
+// proxies don't close it.
+const sseKeepalive = 30 * time.Second
+
+// event is one message on the event stream. Type is the SSE event name,
+// "transition" or "board".
+type event struct {
+	Type string
+	Data any
+}
+
+type transitionEvent struct {
+	At        time.Time `json:"at"`
+	Service   string    `json:"service"`
+	Env       string    `json:"env"`
+	Type      string    `json:"type"`
+	Error     string    `json:"error,omitempty"`
+	PrevError string    `json:"prev_error,omitempty"`
+	Downtime  string    `json:"downtime,omitempty"`
+}
+
+type boardEvent struct {
+	At      time.Time `json:"at"`
+	Healthy int       `json:"healthy"`
+	Down    int       `json:"down"`
+}
+
+// eventHub fans events out to the clients of the event stream. The zero
+// value is ready to use.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan event]struct{}
+}
+
+func (h *eventHub) subscribe() chan event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[chan event]struct{})
+	}
+	ch := make(chan event, 16)
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, ch)
+}
+
+// publish sends e to every client. A client that has fallen behind misses
+// the event rather than hold up the cycle.
+func (h *eventHub) publish(e event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// publishCycle streams the transitions of a cycle, then the board refresh.
+func (h *eventHub) publishCycle(at time.Time, results []CheckResult, transitions []Transition) {
+	for _, t := range transitions {
+		h.publish(event{Type: "transition", Data: transitionEvent{
+			At:        at,
+			Service:   t.Service.Name,
+			Env:       t.Service.Env,
+			Type:      t.Type,
+			Error:     t.Error,
+			PrevError: t.PrevError,
+			Downtime:  t.Downtime,
+		}})
+	}
+
+	healthy, down := countStatus(results)
+	h.publish(event{Type: "board", Data: boardEvent{At: at, Healthy: healthy, Down: down}})
+}
+
+// handleEvents streams status changes as Server-Sent Events until the client
+// goes away.
+func (b *Bot) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	ch := b.events.subscribe()
+	defer b.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case e := <-ch:
+			data, err := json.Marshal(e.Data)
+			if err != nil {
+				slog.Error("failed to encode event", "err", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleEvents(t *testing.T) {
+	b := newTestBot()
+	srv := httptest.NewServer(b.routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/events")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	// The handler subscribes before sending the headers, so the client is
+	// registered by now.
+	svc := Service{Name: "api", Env: "production"}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b.events.publishCycle(at,
+		[]CheckResult{{Service: svc, Error: "request failed"}},
+		[]Transition{{Service: svc, Type: "down", Error: "request failed"}},
+	)
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(lines) < 6 && scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	want := []string{
+		"event: transition",
+		`data: {"at":"2024-05-01T12:00:00Z","service":"api","env":"production","type":"down","error":"request failed"}`,
+		"",
+		"event: board",
+		`data: {"at":"2024-05-01T12:00:00Z","healthy":0,"down":1}`,
+		"",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected stream:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestEventHubDropsSlowClients(t *testing.T) {
+	var h eventHub
+	ch := h.subscribe()
+	for range 20 {
+		h.publish(event{Type: "board"})
+	}
+	if len(ch) != cap(ch) {
+		t.Errorf("expected the buffer to fill up, got %d", len(ch))
+	}
+
+	h.unsubscribe(ch)
+	h.publish(event{Type: "board"})
+	if len(h.subs) != 0 {
+		t.Errorf("expected no subscribers left")
+	}
+}
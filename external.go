@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+// Sources a service's status can come from instead of an HTTP check. A
+// service with a source is never probed: it is down while the source reports
+// one of its matching alerts as firing.
+const (
+	sourceAlertmanager = "alertmanager"
+	sourceCloudWatch   = "cloudwatch"
+)
+
+var sources = []string{sourceAlertmanager, sourceCloudWatch}
+
+// externalAlert is an alert a source reported as firing. Until is when it
+// lapses on its own if the source doesn't say otherwise; zero means never.
+type externalAlert struct {
+	Error string    `json:"error"`
+	Until time.Time `json:"until,omitempty"`
+}
+
+func (a externalAlert) lapsed(now time.Time) bool {
+	return !a.Until.IsZero() && !now.Before(a.Until)
+}
+
+// externalAlerts are the alerts firing for a service, by the source's id for
+// the alert.
+type externalAlerts map[string]externalAlert
+
+// externalStatus holds the firing alerts of the services with a source, by
+// service key. The zero value is ready to use.
+type externalStatus struct {
+	mu     sync.Mutex
+	firing map[string]externalAlerts
+}
+
+func (s *externalStatus) fire(key string, id string, a externalAlert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.firing == nil {
+		s.firing = make(map[string]externalAlerts)
+	}
+	if s.firing[key] == nil {
+		s.firing[key] = make(externalAlerts)
+	}
+	s.firing[key][id] = a
+}
+
+func (s *externalStatus) resolve(key string, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.firing[key], id)
+}
+
+// result turns the alerts firing for svc into a check result, dropping the
+// ones that lapsed. Several alerts are reported together, in a stable order.
+func (s *externalStatus) result(svc Service, now time.Time) CheckResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := serviceKey(svc)
+	s.prune(key, now)
+	alerts := s.firing[key]
+	ids := make([]string, 0, len(alerts))
+	for id := range alerts {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var errs []string
+	for _, id := range ids {
+		if a := alerts[id]; !slices.Contains(errs, a.Error) {
+			errs = append(errs, a.Error)
+		}
+	}
+	return CheckResult{Service: svc, Up: len(errs) == 0, Error: strings.Join(errs, "; ")}
+}
+
+// prune drops the lapsed alerts of the service identified by key.
+func (s *externalStatus) prune(key string, now time.Time) {
+	for id, a := range s.firing[key] {
+		if a.lapsed(now) {
+			delete(s.firing[key], id)
+		}
+	}
+	if len(s.firing[key]) == 0 {
+		delete(s.firing, key)
+	}
+}
+
+// save copies the firing alerts into states, so they are kept in the state
+// file and a restart doesn't bring services back up before their source
+// says so. Callers hold b.mu.
+func (s *externalStatus) save(states map[string]*ServiceState, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, state := range states {
+		state.Firing = nil
+	}
+	for key := range s.firing {
+		s.prune(key, now)
+	}
+	for key, alerts := range s.firing {
+		state := states[key]
+		if state == nil {
+			state = &ServiceState{}
+			states[key] = state
+		}
+		state.Firing = maps.Clone(alerts)
+	}
+}
+
+// restore replaces the firing alerts with the ones saved in states.
+func (s *externalStatus) restore(states map[string]*ServiceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.firing = make(map[string]externalAlerts)
+	for key, state := range states {
+		if len(state.Firing) > 0 {
+			s.firing[key] = maps.Clone(state.Firing)
+		}
+	}
+}
+
+// matchesLabels reports whether labels carry every label svc matches on.
+func matchesLabels(svc Service, labels map[string]string) bool {
+	for k, v := range svc.Match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func validateSource(svc Service) error {
+	if svc.Source == "" {
+		return nil
+	}
+	if !slices.Contains(sources, svc.Source) {
+		return fmt.Errorf("unknown source %q, use one of %s", svc.Source, strings.Join(sources, ", "))
+	}
+	if len(svc.Match) == 0 {
+		return fmt.Errorf("a service with a source needs match labels")
+	}
+	return nil
+}
+
+// checkServices probes every enabled service without a source and takes the
+// status of the others from their source, keeping the config order.
+func (b *Bot) checkServices(ctx context.Context, spread time.Duration, jitter time.Duration) []CheckResult {
+	services := b.enabledServices()
+
+	var probed []Service
+	var at []int
+	for i, svc := range services {
+		if svc.Source == "" {
+			probed = append(probed, svc)
+			at = append(at, i)
+		}
+	}
+
+	pool := b.pool
+	if pool == nil {
+		pool = checker.NewPool(b.cfg.Concurrency)
+		defer pool.Close()
+	}
+	results := make([]CheckResult, len(services))
+	for j, r := range checkAll(ctx, pool, b.client, probed, b.cfg, spread, jitter) {
+		results[at[j]] = r
+	}
+
+	now := time.Now()
+	for i, svc := range services {
+		if svc.Source != "" {
+			results[i] = b.external.result(svc, now)
+		}
+	}
+	return results
+}
+
+// checkOne checks a single service right away.
+func (b *Bot) checkOne(ctx context.Context, svc Service) CheckResult {
+	if svc.Source != "" {
+		return b.external.result(svc, time.Now())
+	}
+	return checkService(ctx, b.client, svc, b.cfg)
+}
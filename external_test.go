@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExternalStatusResult(t *testing.T) {
+	var s externalStatus
+	svc := Service{Name: "kafka", Env: "production", Source: sourceAlertmanager}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if r := s.result(svc, now); !r.Up {
+		t.Fatalf("expected a service without alerts to be up, got %+v", r)
+	}
+
+	s.fire(serviceKey(svc), "b", externalAlert{Error: "consumer lag"})
+	s.fire(serviceKey(svc), "a", externalAlert{Error: "broker down"})
+	s.fire(serviceKey(svc), "c", externalAlert{Error: "disk full", Until: now})
+	if r := s.result(svc, now); r.Up || r.Error != "broker down; consumer lag" {
+		t.Errorf("expected the live alerts in order, got %+v", r)
+	}
+	if _, ok := s.firing[serviceKey(svc)]["c"]; ok {
+		t.Errorf("expected the lapsed alert to be dropped")
+	}
+
+	s.resolve(serviceKey(svc), "a")
+	s.resolve(serviceKey(svc), "b")
+	if r := s.result(svc, now); !r.Up {
+		t.Errorf("expected the service to be up once resolved, got %+v", r)
+	}
+}
+
+func TestExternalStatusSurvivesRestart(t *testing.T) {
+	var s externalStatus
+	svc := Service{Name: "kafka", Env: "production", Source: sourceAlertmanager}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.fire(serviceKey(svc), "a", externalAlert{Error: "broker down"})
+	s.fire(serviceKey(svc), "b", externalAlert{Error: "disk full", Until: now.Add(-time.Minute)})
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	states := map[string]*ServiceState{}
+	s.save(states, now)
+	if err := saveState(path, states, newHistory()); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restarted externalStatus
+	restarted.restore(loaded)
+	if r := restarted.result(svc, now); r.Up || r.Error != "broker down" {
+		t.Errorf("expected the firing alert to be restored, got %+v", r)
+	}
+
+	s.resolve(serviceKey(svc), "a")
+	s.save(loaded, now)
+	if state := loaded[serviceKey(svc)]; len(state.Firing) != 0 {
+		t.Errorf("expected resolved alerts not to be saved, got %+v", state.Firing)
+	}
+}
+
+func TestCheckServicesKeepsOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	kafka := Service{Name: "kafka", Env: "production", Source: sourceAlertmanager, Match: map[string]string{"job": "kafka"}}
+	b := newTestBot(kafka, Service{Name: "api", Env: "production", URL: srv.URL})
+	b.client = srv.Client()
+	b.cfg.Concurrency = 1
+	b.external.fire(serviceKey(kafka), "x", externalAlert{Error: "broker down"})
+
+	results := b.checkServices(context.Background(), 0, 0)
+	if len(results) != 2 || results[0].Service.Name != "kafka" || results[0].Up || !results[1].Up {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	if err := validateSource(Service{Source: "nagios", Match: map[string]string{"a": "b"}}); err == nil {
+		t.Errorf("expected an unknown source to be rejected")
+	}
+	if err := validateSource(Service{Source: sourceAlertmanager}); err == nil {
+		t.Errorf("expected match labels to be required")
+	}
+	if err := validateSource(Service{URL: "https://example.com"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
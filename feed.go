@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// feedSize is the number of incidents the Atom feed lists.
+const feedSize = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Content atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// incidentFeed builds an Atom feed of the latest incidents, newest first,
+// optionally limited to one environment. An entry is updated when its
+// incident is resolved, so feed readers show the recovery.
+func incidentFeed(h *History, env string, self string, now time.Time) atomFeed {
+	var incidents []Incident
+	for _, i := range h.Incidents {
+		if env == "" || strings.EqualFold(i.Env, env) {
+			incidents = append(incidents, i)
+		}
+	}
+	slices.SortFunc(incidents, func(a, b Incident) int { return b.StartedAt.Compare(a.StartedAt) })
+	if len(incidents) > feedSize {
+		incidents = incidents[:feedSize]
+	}
+
+	feed := atomFeed{
+		ID:      "urn:status-bot:incidents",
+		Title:   "Service incidents",
+		Updated: now.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: self, Rel: "self"},
+	}
+
+	var latest time.Time
+	for _, i := range incidents {
+		updated := i.StartedAt
+		title := fmt.Sprintf("%s is down", i.ServiceName)
+		if !i.Open() {
+			updated = i.EndedAt
+			title = fmt.Sprintf("%s was down for %s", i.ServiceName, formatDuration(i.Duration(now)))
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      fmt.Sprintf("urn:status-bot:incident:%s:%d", i.ServiceKey, i.StartedAt.Unix()),
+			Title:   title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{Name: "status-bot"},
+			Content: atomContent{Type: "text", Body: feedSummary(i, now)},
+		})
+	}
+	if !latest.IsZero() {
+		feed.Updated = latest.UTC().Format(time.RFC3339)
+	}
+	return feed
+}
+
+func feedSummary(i Incident, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Started: %s\n", i.StartedAt.UTC().Format(time.RFC1123))
+	if i.Open() {
+		fmt.Fprintf(&b, "Ongoing for %s\n", formatDuration(i.Duration(now)))
+	} else {
+		fmt.Fprintf(&b, "Resolved: %s\n", i.EndedAt.UTC().Format(time.RFC1123))
+	}
+	fmt.Fprintf(&b, "Error: %s\n", i.Error)
+	if i.AckedBy != "" {
+		fmt.Fprintf(&b, "Acknowledged by %s\n", i.AckedBy)
+	}
+	for _, n := range i.Notes {
+		fmt.Fprintf(&b, "Note from %s: %s\n", n.Author, n.Text)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// handleFeed serves the incident feed. ?env= limits it to one environment.
+func (b *Bot) handleFeed(w http.ResponseWriter, r *http.Request) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	self := scheme + "://" + r.Host + r.URL.RequestURI()
+
+	b.mu.Lock()
+	feed := incidentFeed(b.history, r.URL.Query().Get("env"), self, time.Now())
+	b.mu.Unlock()
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	fmt.Fprint(w, xml.Header)
+	w.Write(out)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIncidentFeed(t *testing.T) {
+	h := newHistory()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h.Incidents = []Incident{
+		{ServiceKey: "api:production", ServiceName: "api (production)", Env: "production", Error: "http_503",
+			StartedAt: now.Add(-3 * time.Hour), EndedAt: now.Add(-2 * time.Hour), Notes: []Note{{Author: "ops", Text: "rolled back"}}},
+		{ServiceKey: "db:staging", ServiceName: "db (staging)", Env: "staging", Error: "request failed", StartedAt: now.Add(-10 * time.Minute)},
+	}
+
+	feed := incidentFeed(h, "", "http://bot/feed.atom", now)
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed.Entries))
+	}
+	open, resolved := feed.Entries[0], feed.Entries[1]
+	if open.Title != "db (staging) is down" || open.Updated != "2024-05-01T11:50:00Z" {
+		t.Errorf("unexpected open entry: %+v", open)
+	}
+	if resolved.Title != "api (production) was down for 1h" || resolved.Updated != "2024-05-01T10:00:00Z" || !strings.Contains(resolved.Content.Body, "Note from ops: rolled back") {
+		t.Errorf("unexpected resolved entry: %+v", resolved)
+	}
+	if feed.Updated != "2024-05-01T11:50:00Z" {
+		t.Errorf("expected the feed to be as recent as its newest entry, got %s", feed.Updated)
+	}
+
+	if feed := incidentFeed(h, "production", "", now); len(feed.Entries) != 1 || feed.Entries[0].Title != resolved.Title {
+		t.Errorf("expected the env filter to apply, got %+v", feed.Entries)
+	}
+}
+
+func TestHandleFeed(t *testing.T) {
+	b := newTestBot()
+	b.history.Incidents = []Incident{{ServiceKey: "api:production", ServiceName: "api (production)", Env: "production", StartedAt: time.Now()}}
+
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed.atom", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, rec.Body)
+	}
+	if len(feed.Entries) != 1 || feed.Link.Href != "http://example.com/feed.atom" {
+		t.Errorf("unexpected feed: %+v", feed)
+	}
+}
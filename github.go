@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// GitHubIssuesConfig opens an issue in repo ("owner/name") for incidents in
+// envs that last longer than after_minutes, then comments on and closes it on
+// recovery. Title and body templates see the fields of messageData. The token
+// is read from GITHUB_TOKEN.
+type GitHubIssuesConfig struct {
+	Enabled       bool     `json:"enabled"`
+	Repo          string   `json:"repo"`
+	Labels        []string `json:"labels"`
+	AfterMinutes  int      `json:"after_minutes"`
+	Envs          []string `json:"envs"`
+	TitleTemplate string   `json:"title_template"`
+	BodyTemplate  string   `json:"body_template"`
+	APIURL        string   `json:"api_url"`
+
+	title *template.Template
+	body  *template.Template
+}
+
+const defaultIssueAfter = 10
+
+func (c *GitHubIssuesConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if owner, name, ok := strings.Cut(c.Repo, "/"); !ok || owner == "" || name == "" {
+		return fmt.Errorf("github_issues.repo must be owner/name, got %q", c.Repo)
+	}
+	if c.AfterMinutes <= 0 {
+		c.AfterMinutes = defaultIssueAfter
+	}
+	if len(c.Envs) == 0 {
+		c.Envs = []string{"production"}
+	}
+	if c.APIURL == "" {
+		c.APIURL = "https://api.github.com"
+	}
+
+	var err error
+	if c.TitleTemplate != "" {
+		if c.title, err = template.New("title").Option("missingkey=error").Parse(c.TitleTemplate); err != nil {
+			return fmt.Errorf("github_issues.title_template: %w", err)
+		}
+	}
+	if c.BodyTemplate != "" {
+		if c.body, err = template.New("body").Option("missingkey=error").Parse(c.BodyTemplate); err != nil {
+			return fmt.Errorf("github_issues.body_template: %w", err)
+		}
+	}
+	return nil
+}
+
+type githubClient struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+func newGitHubClient(cfg GitHubIssuesConfig) (*githubClient, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	return &githubClient{
+		token:   token,
+		baseURL: strings.TrimRight(cfg.APIURL, "/") + "/repos/" + cfg.Repo,
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}, nil
+}
+
+// syncGitHubIssues opens issues for incidents that crossed the threshold and
+// closes the issues of incidents that ended.
+func (b *Bot) syncGitHubIssues(ctx context.Context, now time.Time) {
+	if b.github == nil {
+		return
+	}
+	cfg := b.cfg.GitHubIssues
+
+	for i := range b.history.Incidents {
+		incident := &b.history.Incidents[i]
+
+		switch {
+		case incident.GitHubIssue != 0 && !incident.Open() && !incident.GitHubClosed:
+			comment := fmt.Sprintf("Recovered at %s after %s.", incident.EndedAt.Format(time.RFC1123), formatDuration(incident.Duration(now)))
+			if err := b.github.closeIssue(ctx, incident.GitHubIssue, comment); err != nil {
+				slog.Error("failed to close GitHub issue", "issue", incident.GitHubIssue, "err", err)
+				continue
+			}
+			incident.GitHubClosed = true
+
+		case incident.GitHubIssue == 0 && incident.Open():
+			if !slices.Contains(cfg.Envs, incident.Env) || incident.Duration(now) < time.Duration(cfg.AfterMinutes)*time.Minute {
+				continue
+			}
+			svc, ok := serviceByKey(b.cfg.Services, incident.ServiceKey)
+			if !ok {
+				continue
+			}
+
+			data := newMessageData(svc)
+			data.Error = incident.Error
+			data.Downtime = formatDuration(incident.Duration(now))
+
+			title := renderTemplate(cfg.title, data, fmt.Sprintf("Incident: %s (%s) is down", svc.Name, svc.Env))
+			body := renderTemplate(cfg.body, data, issueBody(svc, *incident))
+
+			number, err := b.github.createIssue(ctx, title, body, cfg.Labels)
+			if err != nil {
+				slog.Error("failed to open GitHub issue", "service", svc.Name, "env", svc.Env, "err", err)
+				continue
+			}
+			incident.GitHubIssue = number
+		}
+	}
+}
+
+func issueBody(svc Service, incident Incident) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (%s) has been down since %s.\n\n", svc.Name, svc.Env, incident.StartedAt.Format(time.RFC1123))
+	fmt.Fprintf(&sb, "- URL: %s\n", svc.URL)
+	fmt.Fprintf(&sb, "- Error: `%s`\n", incident.Error)
+	if svc.RunbookURL != "" {
+		fmt.Fprintf(&sb, "- Runbook: %s\n", svc.RunbookURL)
+	}
+	if svc.DashboardURL != "" {
+		fmt.Fprintf(&sb, "- Dashboard: %s\n", svc.DashboardURL)
+	}
+	sb.WriteString("\nThis issue is closed automatically when the service recovers.")
+	return sb.String()
+}
+
+func (c *githubClient) createIssue(ctx context.Context, title string, body string, labels []string) (int, error) {
+	payload := map[string]any{"title": title, "body": body}
+	if len(labels) > 0 {
+		payload["labels"] = labels
+	}
+
+	var created struct {
+		Number int `json:"number"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/issues", payload, &created); err != nil {
+		return 0, err
+	}
+	return created.Number, nil
+}
+
+func (c *githubClient) closeIssue(ctx context.Context, number int, comment string) error {
+	path := fmt.Sprintf("/issues/%d", number)
+	if err := c.call(ctx, http.MethodPost, path+"/comments", map[string]string{"body": comment}, nil); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPatch, path, map[string]string{"state": "closed", "state_reason": "completed"}, nil)
+}
+
+func (c *githubClient) call(ctx context.Context, method string, path string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("github %s %s returned %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
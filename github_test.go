@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncGitHubIssues(t *testing.T) {
+	var requests []string
+	var created map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost && r.URL.Path == "/repos/acme/ops/issues" {
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"number":7}`))
+		}
+	}))
+	defer srv.Close()
+
+	svc := Service{Name: "api", Env: "production", URL: "https://api.example.com"}
+	b := newTestBot(svc, Service{Name: "api", Env: "staging"})
+	b.cfg.GitHubIssues = GitHubIssuesConfig{Enabled: true, Repo: "acme/ops", Labels: []string{"incident"}, TitleTemplate: "{{.Name}} down: {{.Error}}"}
+	if err := b.cfg.GitHubIssues.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	b.github = &githubClient{token: "gh", baseURL: srv.URL + "/repos/acme/ops", client: srv.Client()}
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	b.history.record(nil, []Transition{
+		{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"},
+		{Service: Service{Name: "api", Env: "staging"}, ServiceName: "api (staging)", Type: "down", Error: "timeout"},
+	}, start)
+
+	b.syncGitHubIssues(context.Background(), start.Add(5*time.Minute))
+	if len(requests) != 0 {
+		t.Fatalf("expected no issue before the threshold, got %v", requests)
+	}
+
+	b.syncGitHubIssues(context.Background(), start.Add(11*time.Minute))
+	b.syncGitHubIssues(context.Background(), start.Add(12*time.Minute))
+	if len(requests) != 1 || created["title"] != "api down: timeout" {
+		t.Fatalf("expected a single production issue, got %v %v", requests, created)
+	}
+	if labels, _ := created["labels"].([]any); len(labels) != 1 || labels[0] != "incident" {
+		t.Errorf("expected labels to be set, got %v", created["labels"])
+	}
+
+	b.history.record(nil, []Transition{{Service: svc, ServiceName: "api (production)", Type: "up"}}, start.Add(20*time.Minute))
+	b.syncGitHubIssues(context.Background(), start.Add(21*time.Minute))
+	b.syncGitHubIssues(context.Background(), start.Add(22*time.Minute))
+
+	want := "POST /repos/acme/ops/issues\nPOST /repos/acme/ops/issues/7/comments\nPATCH /repos/acme/ops/issues/7"
+	if got := strings.Join(requests, "\n"); got != want {
+		t.Errorf("unexpected requests:\n%s", got)
+	}
+}
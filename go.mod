@@ -3,6 +3,9 @@ module github.com/Gb16702/status-bot
 go 1.22
 
 require (
-	github.com/gorilla/websocket v1.5.3 // indirect
-	github.com/slack-go/slack v0.17.3 // indirect
+	github.com/slack-go/slack v0.17.3
+	golang.org/x/net v0.35.0
+	golang.org/x/sys v0.30.0
 )
+
+require github.com/gorilla/websocket v1.5.3 // indirect
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GrafanaConfig annotates Grafana with every incident: a region from the
+// down transition to the recovery, tagged with the service name, env and any
+// extra tags. dashboard_uid limits the annotations to one dashboard. The
+// service account token is read from GRAFANA_TOKEN.
+type GrafanaConfig struct {
+	Enabled      bool     `json:"enabled"`
+	URL          string   `json:"url"`
+	DashboardUID string   `json:"dashboard_uid"`
+	Tags         []string `json:"tags"`
+}
+
+func (c GrafanaConfig) validate() error {
+	if c.Enabled && c.URL == "" {
+		return fmt.Errorf("grafana.url is required")
+	}
+	return nil
+}
+
+type grafanaNotifier struct {
+	cfg     GrafanaConfig
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+func newGrafanaNotifier(cfg GrafanaConfig) (*grafanaNotifier, error) {
+	token := os.Getenv("GRAFANA_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GRAFANA_TOKEN is not set")
+	}
+	return &grafanaNotifier{
+		cfg:     cfg,
+		token:   token,
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}, nil
+}
+
+func (n *grafanaNotifier) Name() string {
+	return "grafana"
+}
+
+func (n *grafanaNotifier) Notify(ctx context.Context, c Cycle) error {
+	if c.History == nil {
+		return nil
+	}
+
+	for _, t := range c.Transitions {
+		key := serviceKey(t.Service)
+
+		switch t.Type {
+		case "down":
+			incident := c.History.openIncident(key)
+			if incident == nil || incident.GrafanaAnnotation != 0 {
+				continue
+			}
+			id, err := n.annotate(ctx, t, incident.StartedAt)
+			if err != nil {
+				return err
+			}
+			incident.GrafanaAnnotation = id
+		case "up":
+			incident := c.History.lastIncident(key)
+			if incident == nil || incident.Open() || incident.GrafanaAnnotation == 0 {
+				continue
+			}
+			if err := n.closeRegion(ctx, *incident); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *grafanaNotifier) annotate(ctx context.Context, t Transition, at time.Time) (int64, error) {
+	body := map[string]any{
+		"time": at.UnixMilli(),
+		"tags": append([]string{t.Service.Name, t.Service.Env}, n.cfg.Tags...),
+		"text": fmt.Sprintf("%s is down: %s", t.ServiceName, t.Error),
+	}
+	if n.cfg.DashboardUID != "" {
+		body["dashboardUID"] = n.cfg.DashboardUID
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := n.call(ctx, http.MethodPost, "/api/annotations", body, &created); err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// closeRegion turns the incident's annotation into a region ending at the
+// recovery.
+func (n *grafanaNotifier) closeRegion(ctx context.Context, incident Incident) error {
+	body := map[string]any{
+		"timeEnd": incident.EndedAt.UnixMilli(),
+		"text":    fmt.Sprintf("%s was down for %s: %s", incident.ServiceName, formatDuration(incident.Duration(incident.EndedAt)), incident.Error),
+	}
+	return n.call(ctx, http.MethodPatch, "/api/annotations/"+strconv.FormatInt(incident.GrafanaAnnotation, 10), body, nil)
+}
+
+func (n *grafanaNotifier) call(ctx context.Context, method string, path string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("grafana %s %s returned %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
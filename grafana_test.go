@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGrafanaNotifier(t *testing.T) {
+	var requests []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer glsa" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"id":42}`))
+		}
+	}))
+	defer srv.Close()
+
+	n := &grafanaNotifier{
+		cfg:     GrafanaConfig{DashboardUID: "dash", Tags: []string{"status-bot"}},
+		token:   "glsa",
+		baseURL: srv.URL,
+		client:  srv.Client(),
+	}
+
+	svc := Service{Name: "api", Env: "production"}
+	history := newHistory()
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	down := []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}}
+	history.record(nil, down, start)
+	if err := n.Notify(context.Background(), Cycle{At: start, Transitions: down, History: history}); err != nil {
+		t.Fatalf("notify down: %v", err)
+	}
+
+	up := []Transition{{Service: svc, ServiceName: "api (production)", Type: "up"}}
+	history.record(nil, up, start.Add(10*time.Minute))
+	if err := n.Notify(context.Background(), Cycle{At: start.Add(10 * time.Minute), Transitions: up, History: history}); err != nil {
+		t.Fatalf("notify up: %v", err)
+	}
+
+	if len(requests) != 2 || requests[0] != "POST /api/annotations" || requests[1] != "PATCH /api/annotations/42" {
+		t.Fatalf("unexpected requests %v", requests)
+	}
+
+	tags, _ := bodies[0]["tags"].([]any)
+	if len(tags) != 3 || tags[0] != "api" || tags[1] != "production" || tags[2] != "status-bot" {
+		t.Errorf("unexpected tags %v", bodies[0]["tags"])
+	}
+	if bodies[0]["time"] != float64(start.UnixMilli()) || bodies[0]["dashboardUID"] != "dash" {
+		t.Errorf("unexpected annotation %v", bodies[0])
+	}
+	if bodies[1]["timeEnd"] != float64(start.Add(10*time.Minute).UnixMilli()) {
+		t.Errorf("expected the region to end at the recovery, got %v", bodies[1])
+	}
+}
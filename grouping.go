@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+// alertGroup collects the transitions bound for one destination while the
+// grouping window is open.
+type alertGroup struct {
+	since       time.Time
+	transitions []Transition
+}
+
+// groupAlerts holds transitions for dest until alert_group_seconds have
+// passed since the first of them, then returns everything collected so a
+// cascade spread over several cycles goes out as one alert. Without a window
+// transitions are returned right away. Callers hold b.mu.
+func (b *Bot) groupAlerts(dest string, transitions []Transition, now time.Time) []Transition {
+	window := time.Duration(b.cfg.AlertGroupSeconds) * time.Second
+	if window <= 0 {
+		return transitions
+	}
+
+	group := b.alertGroups[dest]
+	if group == nil {
+		if len(transitions) == 0 {
+			return nil
+		}
+		if b.alertGroups == nil {
+			b.alertGroups = make(map[string]*alertGroup)
+		}
+		group = &alertGroup{since: now}
+		b.alertGroups[dest] = group
+	}
+	group.transitions = append(group.transitions, transitions...)
+
+	if now.Sub(group.since) < window {
+		return nil
+	}
+	delete(b.alertGroups, dest)
+	return group.transitions
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupAlerts(t *testing.T) {
+	b := newTestBot()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	api := Transition{Service: Service{Name: "api"}, Type: "down"}
+	db := Transition{Service: Service{Name: "db"}, Type: "down"}
+
+	if got := b.groupAlerts("board main", []Transition{api}, now); len(got) != 1 {
+		t.Fatalf("expected alerts to pass straight through without a window, got %d", len(got))
+	}
+
+	b.cfg.AlertGroupSeconds = 60
+	if got := b.groupAlerts("board main", nil, now); got != nil {
+		t.Errorf("expected nothing without transitions, got %+v", got)
+	}
+	if got := b.groupAlerts("board main", []Transition{api}, now); got != nil {
+		t.Errorf("expected the first alert to be held, got %+v", got)
+	}
+	if got := b.groupAlerts("board main", []Transition{db}, now.Add(30*time.Second)); got != nil {
+		t.Errorf("expected alerts inside the window to be held, got %+v", got)
+	}
+	if got := b.groupAlerts("channel C1", nil, now.Add(60*time.Second)); got != nil {
+		t.Errorf("expected destinations to be grouped separately, got %+v", got)
+	}
+
+	got := b.groupAlerts("board main", nil, now.Add(60*time.Second))
+	if len(got) != 2 || got[0].Service.Name != "api" || got[1].Service.Name != "db" {
+		t.Fatalf("expected both alerts once the window closed, got %+v", got)
+	}
+	if got := b.groupAlerts("board main", nil, now.Add(90*time.Second)); got != nil {
+		t.Errorf("expected the group to be cleared after sending, got %+v", got)
+	}
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Leader election locks.
+const (
+	lockKubernetes  = "kubernetes"
+	lockObjectStore = "object_store"
+)
+
+const (
+	defaultLeaseName    = "status-bot"
+	defaultLeaseSeconds = 15
+	serviceAccountDir   = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// HAConfig runs several replicas of which only the one holding a lease
+// checks services and posts to Slack; the others stand by and take over when
+// the lease expires. The lease is a Kubernetes Lease, or an object next to
+// the backups written with conditional requests. Enable backups too, with a
+// short interval, so the replica taking over starts from recent state.
+type HAConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Lock         string `json:"lock"`
+	LeaseName    string `json:"lease_name"`
+	Namespace    string `json:"namespace"`
+	LeaseSeconds int    `json:"lease_seconds"`
+
+	// Identity names this replica in the lease. It defaults to POD_NAME,
+	// then the host name.
+	Identity string `json:"identity"`
+}
+
+func (c *HAConfig) validate(backup BackupConfig) error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Lock {
+	case lockKubernetes:
+	case lockObjectStore:
+		if !backup.Enabled {
+			return fmt.Errorf("ha.lock %s needs backup to be enabled", lockObjectStore)
+		}
+	default:
+		return fmt.Errorf("ha.lock must be %s or %s, got %q", lockKubernetes, lockObjectStore, c.Lock)
+	}
+
+	if c.LeaseName == "" {
+		c.LeaseName = defaultLeaseName
+	}
+	if c.LeaseSeconds == 0 {
+		c.LeaseSeconds = defaultLeaseSeconds
+	}
+	if c.LeaseSeconds < 3 {
+		return fmt.Errorf("ha.lease_seconds must be at least 3")
+	}
+	if c.Identity == "" {
+		c.Identity = os.Getenv("POD_NAME")
+	}
+	if c.Identity == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("ha.identity is not set and the host name is unknown: %w", err)
+		}
+		c.Identity = host
+	}
+	return nil
+}
+
+// lease is who holds the leadership and until when.
+type lease struct {
+	Holder      string    `json:"holder"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	RenewedAt   time.Time `json:"renewed_at"`
+	Duration    int       `json:"duration_seconds"`
+	Transitions int       `json:"transitions"`
+}
+
+func (l lease) expired(now time.Time) bool {
+	return l.Holder == "" || now.After(l.RenewedAt.Add(time.Duration(l.Duration)*time.Second))
+}
+
+var errLeaseConflict = errors.New("lease written concurrently")
+
+// leaseStore keeps the lease. write only succeeds while the lease is still at
+// the version read returned, empty for a lease that doesn't exist yet, and
+// returns errLeaseConflict otherwise.
+type leaseStore interface {
+	read(ctx context.Context) (lease, string, error)
+	write(ctx context.Context, l lease, version string) error
+}
+
+type elector struct {
+	store leaseStore
+	id    string
+	ttl   time.Duration
+	now   func() time.Time
+
+	// seen, when set, is told who holds the lease each time it is read.
+	seen func(holder string)
+}
+
+func newElector(cfg HAConfig, backup *objectStore) (*elector, error) {
+	e := &elector{id: cfg.Identity, ttl: time.Duration(cfg.LeaseSeconds) * time.Second, now: time.Now}
+	switch cfg.Lock {
+	case lockKubernetes:
+		store, err := newKubernetesLease(cfg)
+		if err != nil {
+			return nil, err
+		}
+		e.store = store
+	case lockObjectStore:
+		e.store = objectStoreLease{store: backup, name: cfg.LeaseName + ".lease"}
+	}
+	return e, nil
+}
+
+// tryAcquire takes the lease when it is free or expired, or renews it when
+// this replica holds it, and reports whether it does.
+func (e *elector) tryAcquire(ctx context.Context) (bool, error) {
+	l, version, err := e.store.read(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := e.now()
+	if l.Holder != e.id && !l.expired(now) {
+		e.observe(l.Holder)
+		return false, nil
+	}
+
+	if l.Holder != e.id {
+		l.Holder = e.id
+		l.AcquiredAt = now
+		l.Transitions++
+	}
+	l.RenewedAt = now
+	l.Duration = int(e.ttl / time.Second)
+	if err := e.store.write(ctx, l, version); err != nil {
+		if errors.Is(err, errLeaseConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	e.observe(e.id)
+	return true, nil
+}
+
+func (e *elector) observe(holder string) {
+	if e.seen != nil {
+		e.seen(holder)
+	}
+}
+
+// release gives the lease up, so another replica takes over without waiting
+// for it to expire.
+func (e *elector) release(ctx context.Context) error {
+	l, version, err := e.store.read(ctx)
+	if err != nil || l.Holder != e.id {
+		return err
+	}
+	l.Holder = ""
+	return e.store.write(ctx, l, version)
+}
+
+// run stands by until this replica gets the lease, then calls lead with a
+// channel closed when the lease is lost. It returns once ctx is done and
+// lead has returned, giving the lease up.
+func (e *elector) run(ctx context.Context, lead func(lost <-chan struct{})) {
+	poll := e.ttl / 3
+	for {
+		if ok, err := e.tryAcquire(ctx); err != nil {
+			slog.Error("failed to acquire leader lease", "err", err)
+		} else if ok {
+			slog.Info("became leader", "identity", e.id)
+			lost := make(chan struct{})
+			stopRenewing := make(chan struct{})
+			renewed := make(chan struct{})
+			go func() {
+				defer close(renewed)
+				e.renew(ctx, lost, stopRenewing)
+			}()
+
+			lead(lost)
+			close(stopRenewing)
+			<-renewed
+
+			if ctx.Err() != nil {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.release(releaseCtx); err != nil {
+					slog.Error("failed to release leader lease", "err", err)
+				}
+				cancel()
+				return
+			}
+			slog.Warn("lost leadership, standing by", "identity", e.id)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+	}
+}
+
+// renew keeps the lease until stop is closed, closing lost when another
+// replica took it or it couldn't be renewed in time. Like client-go, it
+// gives up a poll before the lease runs out rather than at expiry, so this
+// replica has stopped leading by the time another one can take over.
+func (e *elector) renew(ctx context.Context, lost chan<- struct{}, stop <-chan struct{}) {
+	poll := e.ttl / 3
+	deadline := e.ttl - poll
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	last := e.now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Renewing goes on through shutdown, until lead returns, so the
+		// lease isn't taken over while the boards are marked offline. A
+		// renewal can't outlast the deadline though.
+		renewCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadline-e.now().Sub(last))
+		ok, err := e.tryAcquire(renewCtx)
+		cancel()
+		switch {
+		case err != nil && e.now().Sub(last) < deadline:
+			slog.Error("failed to renew leader lease", "err", err)
+			continue
+		case err != nil:
+			slog.Error("leader lease could not be renewed in time", "err", err)
+		case ok:
+			last = e.now()
+			continue
+		}
+		close(lost)
+		<-stop
+		return
+	}
+}
+
+// leaderOnly serves h on the leader only. A standby answers 503 naming the
+// leader it last saw, since what h changes would be lost on it: it isn't
+// running cycles and its state is replaced when it takes over.
+func (b *Bot) leaderOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !b.standby.Load() {
+			h(w, r)
+			return
+		}
+		leader, _ := b.leader.Load().(string)
+		if leader == "" {
+			leader = "unknown"
+		}
+		w.Header().Set("Retry-After", "5")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error":  "this replica is a standby, send this to the leader",
+			"leader": leader,
+		})
+	}
+}
+
+// objectStoreLease keeps the lease as a JSON object next to the backups.
+type objectStoreLease struct {
+	store *objectStore
+	name  string
+}
+
+func (s objectStoreLease) read(ctx context.Context) (lease, string, error) {
+	data, etag, err := s.store.getVersion(ctx, s.name)
+	if errors.Is(err, errObjectNotFound) {
+		return lease{}, "", nil
+	}
+	if err != nil {
+		return lease{}, "", fmt.Errorf("read lease: %w", err)
+	}
+	var l lease
+	if err := json.Unmarshal(data, &l); err != nil {
+		return lease{}, "", fmt.Errorf("decode lease: %w", err)
+	}
+	return l, etag, nil
+}
+
+func (s objectStoreLease) write(ctx context.Context, l lease, version string) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("encode lease: %w", err)
+	}
+	if err := s.store.putIf(ctx, s.name, data, version); err != nil {
+		if errors.Is(err, errObjectChanged) {
+			return errLeaseConflict
+		}
+		return fmt.Errorf("write lease: %w", err)
+	}
+	return nil
+}
+
+// kubernetesLease keeps the lease as a coordination.k8s.io/v1 Lease, using
+// the pod's service account. It needs get, create and update on leases.
+type kubernetesLease struct {
+	client    *http.Client
+	url       string
+	name      string
+	namespace string
+	tokenPath string
+}
+
+// k8sLease is the part of a Lease object we read and write.
+type k8sLease struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string `json:"name"`
+		Namespace       string `json:"namespace"`
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		HolderIdentity       string `json:"holderIdentity"`
+		LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+		AcquireTime          string `json:"acquireTime,omitempty"`
+		RenewTime            string `json:"renewTime,omitempty"`
+		LeaseTransitions     int    `json:"leaseTransitions"`
+	} `json:"spec"`
+}
+
+// k8sMicroTime is the layout of Kubernetes MicroTime fields.
+const k8sMicroTime = "2006-01-02T15:04:05.000000Z07:00"
+
+func newKubernetesLease(cfg HAConfig) (*kubernetesLease, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("ha.lock %s only works inside a cluster", lockKubernetes)
+	}
+
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in the cluster CA")
+	}
+
+	namespace := cfg.Namespace
+	if namespace == "" {
+		data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("ha.namespace is not set and the pod namespace is unknown: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	return &kubernetesLease{
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+		url:       fmt.Sprintf("https://%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", net.JoinHostPort(host, port), namespace),
+		name:      cfg.LeaseName,
+		namespace: namespace,
+		tokenPath: filepath.Join(serviceAccountDir, "token"),
+	}, nil
+}
+
+// do sends a request to the API server. The token is read every time, as
+// projected service account tokens rotate.
+func (k *kubernetesLease) do(ctx context.Context, method, url string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode lease: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	token, err := os.ReadFile(k.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("read service account token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	return k.client.Do(req)
+}
+
+func (k *kubernetesLease) read(ctx context.Context) (lease, string, error) {
+	resp, err := k.do(ctx, http.MethodGet, k.url+"/"+k.name, nil)
+	if err != nil {
+		return lease{}, "", fmt.Errorf("get lease: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return lease{}, "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return lease{}, "", fmt.Errorf("get lease: http_%d", resp.StatusCode)
+	}
+	var obj k8sLease
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return lease{}, "", fmt.Errorf("decode lease: %w", err)
+	}
+
+	l := lease{
+		Holder:      obj.Spec.HolderIdentity,
+		Duration:    obj.Spec.LeaseDurationSeconds,
+		Transitions: obj.Spec.LeaseTransitions,
+	}
+	l.AcquiredAt, _ = time.Parse(k8sMicroTime, obj.Spec.AcquireTime)
+	l.RenewedAt, _ = time.Parse(k8sMicroTime, obj.Spec.RenewTime)
+	return l, obj.Metadata.ResourceVersion, nil
+}
+
+func (k *kubernetesLease) write(ctx context.Context, l lease, version string) error {
+	obj := k8sLease{APIVersion: "coordination.k8s.io/v1", Kind: "Lease"}
+	obj.Metadata.Name = k.name
+	obj.Metadata.Namespace = k.namespace
+	obj.Metadata.ResourceVersion = version
+	obj.Spec.HolderIdentity = l.Holder
+	obj.Spec.LeaseDurationSeconds = l.Duration
+	obj.Spec.LeaseTransitions = l.Transitions
+	if !l.AcquiredAt.IsZero() {
+		obj.Spec.AcquireTime = l.AcquiredAt.UTC().Format(k8sMicroTime)
+	}
+	if !l.RenewedAt.IsZero() {
+		obj.Spec.RenewTime = l.RenewedAt.UTC().Format(k8sMicroTime)
+	}
+
+	method, url := http.MethodPut, k.url+"/"+k.name
+	if version == "" {
+		method, url = http.MethodPost, k.url
+	}
+	resp, err := k.do(ctx, method, url, obj)
+	if err != nil {
+		return fmt.Errorf("write lease: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return errLeaseConflict
+	case resp.StatusCode/100 != 2:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("write lease: http_%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// takeOver loads the state the previous leader backed up, so this replica
+// carries on from where it left off rather than from its own stale copy.
+func (b *Bot) takeOver(ctx context.Context) {
+	if b.backup == nil {
+		return
+	}
+	if err := restoreFiles(ctx, b.backup, persistedFiles(b.cfg, b.boards), true); err != nil {
+		slog.Error("failed to restore the previous leader's state", "err", err)
+		return
+	}
+	for _, bc := range b.boards {
+		bc.ref.Reload()
+	}
+	states, history, err := loadState(b.cfg.StateFile)
+	if err != nil {
+		slog.Error("failed to load the previous leader's state", "err", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states = states
+	b.history = history
+	b.recent = recentFromHistory(history, b.cfg.RecentIncidents)
+	b.external.restore(states)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryLease is a leaseStore with versions, like the real ones.
+type memoryLease struct {
+	mu      sync.Mutex
+	lease   lease
+	version int
+}
+
+func (m *memoryLease) read(ctx context.Context) (lease, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.version == 0 {
+		return lease{}, "", nil
+	}
+	return m.lease, fmt.Sprint(m.version), nil
+}
+
+func (m *memoryLease) write(ctx context.Context, l lease, version string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := ""
+	if m.version > 0 {
+		current = fmt.Sprint(m.version)
+	}
+	if version != current {
+		return errLeaseConflict
+	}
+	m.lease = l
+	m.version++
+	return nil
+}
+
+func TestElectorTryAcquire(t *testing.T) {
+	store := &memoryLease{}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	a := &elector{store: store, id: "a", ttl: 15 * time.Second, now: clock}
+	b := &elector{store: store, id: "b", ttl: 15 * time.Second, now: clock}
+	ctx := context.Background()
+
+	if ok, err := a.tryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected a to take a free lease, got %v %v", ok, err)
+	}
+	if ok, _ := b.tryAcquire(ctx); ok {
+		t.Errorf("expected b to stand by while a holds the lease")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := a.tryAcquire(ctx); !ok {
+		t.Errorf("expected a to renew its lease")
+	}
+	now = now.Add(10 * time.Second)
+	if ok, _ := b.tryAcquire(ctx); ok {
+		t.Errorf("expected the renewed lease to hold")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := b.tryAcquire(ctx); !ok {
+		t.Errorf("expected b to take over the expired lease")
+	}
+	if store.lease.Holder != "b" || store.lease.Transitions != 2 || !store.lease.AcquiredAt.Equal(now) {
+		t.Errorf("unexpected lease %+v", store.lease)
+	}
+
+	if err := b.release(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := a.tryAcquire(ctx); !ok {
+		t.Errorf("expected a released lease to be free")
+	}
+}
+
+// failingLease is a memoryLease whose writes fail once fail is set.
+type failingLease struct {
+	memoryLease
+	fail atomic.Bool
+}
+
+func (f *failingLease) write(ctx context.Context, l lease, version string) error {
+	if f.fail.Load() {
+		return errors.New("store unreachable")
+	}
+	return f.memoryLease.write(ctx, l, version)
+}
+
+func TestElectorRenewDeadline(t *testing.T) {
+	store := &failingLease{}
+	e := &elector{store: store, id: "a", ttl: 900 * time.Millisecond, now: time.Now}
+	if ok, err := e.tryAcquire(context.Background()); !ok || err != nil {
+		t.Fatalf("expected to take the lease, got %v %v", ok, err)
+	}
+	store.fail.Store(true)
+
+	lost := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	start := time.Now()
+	go e.renew(context.Background(), lost, stop)
+
+	select {
+	case <-lost:
+		if elapsed := time.Since(start); elapsed >= e.ttl {
+			t.Errorf("expected to stop leading before the lease expired, took %s", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("never stopped leading")
+	}
+}
+
+func TestElectorRunFailover(t *testing.T) {
+	store := &memoryLease{}
+	// Leases last whole seconds, so this takes a few.
+	a := &elector{store: store, id: "a", ttl: time.Second, now: time.Now}
+	b := &elector{store: store, id: "b", ttl: time.Second, now: time.Now}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	leading := make(chan string, 4)
+	go a.run(ctxA, func(lost <-chan struct{}) {
+		leading <- "a"
+		select {
+		case <-ctxA.Done():
+		case <-lost:
+		}
+	})
+	if got := <-leading; got != "a" {
+		t.Fatalf("expected a to lead, got %s", got)
+	}
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go b.run(ctxB, func(lost <-chan struct{}) {
+		leading <- "b"
+		<-ctxB.Done()
+	})
+
+	select {
+	case got := <-leading:
+		t.Fatalf("expected b to stand by, but %s leads", got)
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	stopA()
+	select {
+	case got := <-leading:
+		if got != "b" {
+			t.Errorf("expected b to take over, got %s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("b never took over")
+	}
+}
+
+func TestObjectStoreLease(t *testing.T) {
+	var mu sync.Mutex
+	var body []byte
+	version := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		etag := fmt.Sprintf(`"v%d"`, version)
+		switch r.Method {
+		case http.MethodGet:
+			if version == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Write(body)
+		case http.MethodPut:
+			if match := r.Header.Get("If-Match"); (version == 0 && r.Header.Get("If-None-Match") != "*") || (version > 0 && match != etag) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			body, _ = io.ReadAll(r.Body)
+			version++
+		}
+	}))
+	defer srv.Close()
+
+	store := objectStoreLease{
+		store: &objectStore{client: srv.Client(), endpoint: srv.URL, region: "eu-west-1", bucket: "bucket", accessKey: "AK", secretKey: "SK", now: time.Now},
+		name:  "status-bot.lease",
+	}
+	ctx := context.Background()
+
+	l, v, err := store.read(ctx)
+	if err != nil || l.Holder != "" || v != "" {
+		t.Fatalf("expected no lease, got %+v %q %v", l, v, err)
+	}
+	if err := store.write(ctx, lease{Holder: "a", Duration: 15}, ""); err != nil {
+		t.Fatal(err)
+	}
+	l, v, err = store.read(ctx)
+	if err != nil || l.Holder != "a" || v != `"v1"` {
+		t.Fatalf("unexpected lease %+v %q %v", l, v, err)
+	}
+	if err := store.write(ctx, lease{Holder: "b"}, ""); err != errLeaseConflict {
+		t.Errorf("expected a conflict creating an existing lease, got %v", err)
+	}
+	if err := store.write(ctx, lease{Holder: "b"}, `"v0"`); err != errLeaseConflict {
+		t.Errorf("expected a conflict on a stale version, got %v", err)
+	}
+	if err := store.write(ctx, lease{Holder: "a"}, v); err != nil {
+		t.Errorf("expected the write at the current version to pass, got %v", err)
+	}
+}
+
+func TestKubernetesLease(t *testing.T) {
+	var mu sync.Mutex
+	var stored *k8sLease
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		const base = "/apis/coordination.k8s.io/v1/namespaces/monitoring/leases"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == base+"/status-bot":
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(stored)
+		case r.Method == http.MethodPost && r.URL.Path == base:
+			if stored != nil {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			stored = &k8sLease{}
+			json.NewDecoder(r.Body).Decode(stored)
+			stored.Metadata.ResourceVersion = "1"
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == base+"/status-bot":
+			var obj k8sLease
+			json.NewDecoder(r.Body).Decode(&obj)
+			if stored == nil || obj.Metadata.ResourceVersion != stored.Metadata.ResourceVersion {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			obj.Metadata.ResourceVersion += "1"
+			stored = &obj
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenPath, []byte("sa-token\n"), 0600)
+	k := &kubernetesLease{
+		client:    srv.Client(),
+		url:       srv.URL + "/apis/coordination.k8s.io/v1/namespaces/monitoring/leases",
+		name:      "status-bot",
+		namespace: "monitoring",
+		tokenPath: tokenPath,
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	e := &elector{store: k, id: "pod-a", ttl: 15 * time.Second, now: func() time.Time { return now }}
+	ctx := context.Background()
+	if ok, err := e.tryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected to create the lease, got %v %v", ok, err)
+	}
+	if ok, err := e.tryAcquire(ctx); !ok || err != nil {
+		t.Fatalf("expected to renew the lease, got %v %v", ok, err)
+	}
+
+	if stored.Spec.HolderIdentity != "pod-a" || stored.Spec.LeaseDurationSeconds != 15 || stored.Spec.RenewTime != "2024-05-01T12:00:00.123456Z" {
+		t.Errorf("unexpected lease %+v", stored.Spec)
+	}
+	l, _, err := k.read(ctx)
+	if err != nil || !l.RenewedAt.Equal(now) || l.Transitions != 1 {
+		t.Errorf("unexpected lease read back %+v %v", l, err)
+	}
+
+	other := &elector{store: k, id: "pod-b", ttl: 15 * time.Second, now: func() time.Time { return now }}
+	if ok, _ := other.tryAcquire(ctx); ok {
+		t.Errorf("expected pod-b to stand by")
+	}
+}
+
+func TestStandbyProbes(t *testing.T) {
+	b := newTestBot()
+	b.cfg.IntervalSeconds = 30
+	b.metrics = metrics{started: time.Now().Add(-time.Hour)}
+	b.standby.Store(true)
+
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "standby") {
+		t.Errorf("expected a standby replica to be live, got %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "standby") {
+		t.Errorf("expected a standby replica not to be ready, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestStandbyRefusesWrites(t *testing.T) {
+	b := newServiceTestBot(t, Service{Name: "API", URL: "http://api"}, Service{Name: "Web", URL: "http://web"})
+	b.cfg.APIAuth.Tokens = []APIToken{{Name: "ops", Scope: scopeAdmin, value: "a"}}
+	b.standby.Store(true)
+	b.leader.Store("status-bot-0")
+
+	for _, req := range []*http.Request{
+		localRequest(http.MethodPost, "/api/v1/blackouts", strings.NewReader(`{"service":"API","minutes":10}`)),
+		localRequest(http.MethodPost, "/api/v1/ingest/alertmanager", strings.NewReader(`{"alerts":[]}`)),
+		localRequest(http.MethodPost, "/api/v1/services", strings.NewReader(`{"name":"Auth","url":"http://auth"}`)),
+		localRequest(http.MethodDelete, "/api/v1/services/API", nil),
+	} {
+		req.Header.Set("Authorization", "Bearer a")
+		rec := httptest.NewRecorder()
+		b.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "status-bot-0") {
+			t.Errorf("%s %s: expected 503 naming the leader, got %d %s", req.Method, req.URL.Path, rec.Code, rec.Body)
+		}
+	}
+
+	b.standby.Store(false)
+	req := localRequest(http.MethodDelete, "/api/v1/services/API", nil)
+	req.Header.Set("Authorization", "Bearer a")
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected the leader to serve, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHAConfigValidate(t *testing.T) {
+	t.Setenv("POD_NAME", "status-bot-7d9f")
+	cfg := HAConfig{Enabled: true, Lock: lockKubernetes}
+	if err := cfg.validate(BackupConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LeaseName != defaultLeaseName || cfg.LeaseSeconds != defaultLeaseSeconds || cfg.Identity != "status-bot-7d9f" {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+
+	for _, bad := range []HAConfig{
+		{Enabled: true, Lock: "etcd"},
+		{Enabled: true, Lock: lockObjectStore},
+		{Enabled: true, Lock: lockKubernetes, LeaseSeconds: 1},
+	} {
+		if err := bad.validate(BackupConfig{}); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// staleIntervals is how many check intervals may pass without a finished
+// cycle before the bot stops reporting itself healthy.
+const staleIntervals = 3
+
+// testSlackAuth verifies the bot token. Until it succeeds, /readyz fails; the
+// cycle retries it so a bot that started during a Slack outage recovers.
+func (b *Bot) testSlackAuth(ctx context.Context) {
+	if b.slackAuthed.Load() {
+		return
+	}
+	if _, err := b.api.AuthTestContext(ctx); err != nil {
+		slog.Error("slack auth test failed", "err", err)
+		return
+	}
+	b.slackAuthed.Store(true)
+}
+
+// cycleAge is how long it has been since the last finished cycle, or since
+// start-up when none has finished yet.
+func (b *Bot) cycleAge(now time.Time) (time.Duration, bool) {
+	started, last := b.metrics.cycleTimes()
+	if last.IsZero() {
+		return now.Sub(started), false
+	}
+	return now.Sub(last), true
+}
+
+func (b *Bot) staleAfter() time.Duration {
+	return staleIntervals * time.Duration(b.cfg.IntervalSeconds) * time.Second
+}
+
+// handleHealthz is the liveness probe: it fails once no cycle has finished
+// for several intervals, so a wedged bot gets restarted instead of leaving a
+// stale board behind.
+func (b *Bot) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if b.standby.Load() {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "standby"})
+		return
+	}
+	if age, _ := b.cycleAge(time.Now()); age > b.staleAfter() {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("no cycle finished in %s", formatDuration(age)))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe: the config is loaded, the Slack token
+// works and a cycle finished recently. A standby replica isn't ready, so a
+// Service only routes writes and ingested alerts to the leader.
+func (b *Bot) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{"config": "ok", "slack": "ok", "cycle": "ok"}
+	ready := true
+
+	if b.cfg.IntervalSeconds <= 0 {
+		checks["config"] = "not loaded"
+		ready = false
+	}
+	if b.standby.Load() {
+		checks["slack"], checks["cycle"] = "standby", "standby"
+		ready = false
+	} else {
+		if !b.slackAuthed.Load() {
+			checks["slack"] = "auth test has not passed"
+			ready = false
+		}
+		if age, finished := b.cycleAge(time.Now()); !finished {
+			checks["cycle"] = "no cycle finished yet"
+			ready = false
+		} else if age > b.staleAfter() {
+			checks["cycle"] = fmt.Sprintf("last cycle finished %s ago", formatDuration(age))
+			ready = false
+		}
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]any{"ready": ready, "checks": checks})
+}
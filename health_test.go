@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthz(t *testing.T) {
+	b := newTestBot()
+	b.cfg.IntervalSeconds = 30
+	b.metrics.started = time.Now()
+
+	get := func() int {
+		rec := httptest.NewRecorder()
+		b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		return rec.Code
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Errorf("expected 200 right after start, got %d", code)
+	}
+
+	b.metrics.started = time.Now().Add(-5 * time.Minute)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when no cycle ever finished, got %d", code)
+	}
+
+	b.metrics.recordCycle(time.Now(), time.Second, nil)
+	if code := get(); code != http.StatusOK {
+		t.Errorf("expected 200 after a cycle, got %d", code)
+	}
+
+	b.metrics.recordCycle(time.Now().Add(-2*time.Minute), time.Second, nil)
+	if code := get(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when the last cycle is stale, got %d", code)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	api, calls := newTestSlack(t)
+	b := newTestBot()
+	b.api = api
+	b.cfg.IntervalSeconds = 30
+	b.metrics.started = time.Now()
+
+	get := func() (int, map[string]string) {
+		rec := httptest.NewRecorder()
+		b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body struct {
+			Checks map[string]string `json:"checks"`
+		}
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec.Code, body.Checks
+	}
+
+	if code, checks := get(); code != http.StatusServiceUnavailable || checks["slack"] == "ok" || checks["cycle"] == "ok" {
+		t.Errorf("expected 503 before auth and the first cycle, got %d %v", code, checks)
+	}
+
+	b.testSlackAuth(context.Background())
+	b.testSlackAuth(context.Background())
+	if n := len(calls()); n != 1 {
+		t.Errorf("expected a single auth.test call, got %d", n)
+	}
+
+	b.metrics.recordCycle(time.Now(), time.Second, nil)
+	if code, checks := get(); code != http.StatusOK {
+		t.Errorf("expected 200, got %d %v", code, checks)
+	}
+}
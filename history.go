@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+// historyRetention bounds how long incidents and daily stats are kept.
+const historyRetention = 90 * 24 * time.Hour
+
+const dayLayout = "2006-01-02"
+
+type Incident struct {
+	ServiceKey   string    `json:"service_key"`
+	ServiceName  string    `json:"service_name"`
+	Env          string    `json:"env"`
+	Error        string    `json:"error"`
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at"`
+	Notes        []Note    `json:"notes,omitempty"`
+	AckedBy      string    `json:"acked_by,omitempty"`
+	AckedAt      time.Time `json:"acked_at"`
+	Errors       []string  `json:"errors,omitempty"`
+	FailedChecks int       `json:"failed_checks"`
+	StatuspageID string    `json:"statuspage_id,omitempty"`
+	GitHubIssue  int       `json:"github_issue,omitempty"`
+	GitHubClosed bool      `json:"github_closed,omitempty"`
+	JiraKey      string    `json:"jira_key,omitempty"`
+	JiraResolved bool      `json:"jira_resolved,omitempty"`
+
+	GrafanaAnnotation int64 `json:"grafana_annotation,omitempty"`
+}
+
+func (i Incident) Open() bool {
+	return i.EndedAt.IsZero()
+}
+
+// Duration returns how long the incident lasted, or has lasted so far if it
+// is still open.
+func (i Incident) Duration(now time.Time) time.Duration {
+	if i.Open() {
+		return now.Sub(i.StartedAt)
+	}
+	return i.EndedAt.Sub(i.StartedAt)
+}
+
+// DayStats aggregates the checks of one service over one calendar day.
+type DayStats struct {
+	Checks    int   `json:"checks"`
+	Failures  int   `json:"failures"`
+	LatencyMs int64 `json:"latency_ms"`
+}
+
+// AvgLatency is the mean latency of the successful checks of the day.
+func (d DayStats) AvgLatency() time.Duration {
+	ok := d.Checks - d.Failures
+	if ok <= 0 {
+		return 0
+	}
+	return time.Duration(d.LatencyMs/int64(ok)) * time.Millisecond
+}
+
+type History struct {
+	Incidents          []Incident                      `json:"incidents"`
+	Days               map[string]map[string]*DayStats `json:"days"`
+	LastDigest         string                          `json:"last_digest"`
+	LastReports        map[string]string               `json:"last_reports"`
+	LastQuietSummary   string                          `json:"last_quiet_summary"`
+	MaintenanceNotices []MaintenanceNotice             `json:"maintenance_notices"`
+	LastEmailSummary   string                          `json:"last_email_summary"`
+}
+
+func newHistory() *History {
+	return &History{
+		Days:        make(map[string]map[string]*DayStats),
+		LastReports: make(map[string]string),
+	}
+}
+
+func (h *History) record(results []CheckResult, transitions []Transition, now time.Time) {
+	day := now.Format(dayLayout)
+	stats, exists := h.Days[day]
+	if !exists {
+		stats = make(map[string]*DayStats)
+		h.Days[day] = stats
+	}
+
+	for _, r := range results {
+		key := serviceKey(r.Service)
+		s, exists := stats[key]
+		if !exists {
+			s = &DayStats{}
+			stats[key] = s
+		}
+
+		s.Checks++
+		if r.Up {
+			s.LatencyMs += r.Latency.Milliseconds()
+		} else {
+			s.Failures++
+			if i := h.openIncident(key); i != nil {
+				i.FailedChecks++
+				if !slices.Contains(i.Errors, r.Error) {
+					i.Errors = append(i.Errors, r.Error)
+				}
+			}
+		}
+	}
+
+	for _, t := range transitions {
+		key := serviceKey(t.Service)
+		switch t.Type {
+		case "down":
+			h.Incidents = append(h.Incidents, Incident{
+				ServiceKey:  key,
+				ServiceName: t.ServiceName,
+				Env:         t.Service.Env,
+				Error:       t.Error,
+				StartedAt:   now,
+
+				// The failures that confirmed the outage count too.
+				Errors:       []string{t.Error},
+				FailedChecks: failThreshold,
+			})
+		case "up":
+			if i := h.openIncident(key); i != nil {
+				i.EndedAt = now
+			}
+		}
+	}
+}
+
+func (h *History) openIncident(key string) *Incident {
+	for i := len(h.Incidents) - 1; i >= 0; i-- {
+		if h.Incidents[i].ServiceKey == key && h.Incidents[i].Open() {
+			return &h.Incidents[i]
+		}
+	}
+	return nil
+}
+
+// incidentsBetween returns the incidents that started in [from, to).
+func (h *History) incidentsBetween(from, to time.Time) []Incident {
+	var incidents []Incident
+	for _, i := range h.Incidents {
+		if !i.StartedAt.Before(from) && i.StartedAt.Before(to) {
+			incidents = append(incidents, i)
+		}
+	}
+	return incidents
+}
+
+// Reliability holds the mean time to recovery and mean time between failures
+// of a service.
+type Reliability struct {
+	Incidents int
+	MTTR      time.Duration
+	MTBF      time.Duration
+}
+
+// reliability computes MTTR and MTBF from the resolved incidents of the
+// service identified by key that started in [from, to). MTBF is the mean
+// healthy time between the end of one incident and the start of the next.
+func (h *History) reliability(key string, from, to time.Time) Reliability {
+	var incidents []Incident
+	for _, i := range h.incidentsBetween(from, to) {
+		if i.ServiceKey == key {
+			incidents = append(incidents, i)
+		}
+	}
+	sort.Slice(incidents, func(a, b int) bool { return incidents[a].StartedAt.Before(incidents[b].StartedAt) })
+
+	rel := Reliability{Incidents: len(incidents)}
+
+	var recovered int
+	var repair time.Duration
+	for _, i := range incidents {
+		if !i.Open() {
+			recovered++
+			repair += i.Duration(i.EndedAt)
+		}
+	}
+	if recovered > 0 {
+		rel.MTTR = repair / time.Duration(recovered)
+	}
+
+	var gaps int
+	var between time.Duration
+	for n := 1; n < len(incidents); n++ {
+		prev := incidents[n-1]
+		if prev.Open() {
+			continue
+		}
+		between += incidents[n].StartedAt.Sub(prev.EndedAt)
+		gaps++
+	}
+	if gaps > 0 {
+		rel.MTBF = between / time.Duration(gaps)
+	}
+
+	return rel
+}
+
+func (h *History) prune(now time.Time) {
+	cutoff := now.Add(-historyRetention)
+
+	kept := h.Incidents[:0]
+	for _, i := range h.Incidents {
+		if i.Open() || i.EndedAt.After(cutoff) {
+			kept = append(kept, i)
+		}
+	}
+	h.Incidents = kept
+
+	for day := range h.Days {
+		t, err := time.ParseInLocation(dayLayout, day, now.Location())
+		if err != nil || t.Before(cutoff) {
+			delete(h.Days, day)
+		}
+	}
+
+	notices := h.MaintenanceNotices[:0]
+	for _, n := range h.MaintenanceNotices {
+		if !n.WrappedUp || n.End.After(now.Add(-24*time.Hour)) {
+			notices = append(notices, n)
+		}
+	}
+	h.MaintenanceNotices = notices
+}
+
+// maintenanceNotice returns the announced occurrence of a maintenance window
+// of the service identified by key starting at start, if any.
+func (h *History) maintenanceNotice(key string, start time.Time) *MaintenanceNotice {
+	for i := range h.MaintenanceNotices {
+		if n := &h.MaintenanceNotices[i]; n.ServiceKey == key && n.Start.Equal(start) {
+			return n
+		}
+	}
+	return nil
+}
+
+// lastIncident returns the most recent incident of the service identified by
+// key.
+func (h *History) lastIncident(key string) *Incident {
+	for i := len(h.Incidents) - 1; i >= 0; i-- {
+		if h.Incidents[i].ServiceKey == key {
+			return &h.Incidents[i]
+		}
+	}
+	return nil
+}
+
+// recentFromHistory seeds the footer ring buffer with the latest resolved
+// incidents so it survives restarts.
+func recentFromHistory(h *History, size int) *RecentIncidents {
+	recent := newRecentIncidents(size)
+
+	var resolved []Incident
+	for _, i := range h.Incidents {
+		if !i.Open() {
+			resolved = append(resolved, i)
+		}
+	}
+	sort.Slice(resolved, func(a, b int) bool { return resolved[a].EndedAt.Before(resolved[b].EndedAt) })
+
+	if len(resolved) > size {
+		resolved = resolved[len(resolved)-size:]
+	}
+	for _, i := range resolved {
+		recent.add(RecentIncident{
+			ServiceName: i.ServiceName,
+			OccurredAt:  i.EndedAt,
+			Duration:    formatDuration(i.Duration(i.EndedAt)),
+		})
+	}
+
+	return recent
+}
+
+type persistedState struct {
+	States  map[string]*ServiceState `json:"states"`
+	History *History                 `json:"history"`
+}
+
+// loadState restores service states and history from path. A missing file is
+// not an error: the bot simply starts with empty state.
+func loadState(path string) (map[string]*ServiceState, *History, error) {
+	states := make(map[string]*ServiceState)
+	history := newHistory()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, history, nil
+	}
+	if err != nil {
+		return states, history, fmt.Errorf("read file: %w", err)
+	}
+
+	var ps persistedState
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return states, history, fmt.Errorf("parse json: %w", err)
+	}
+
+	if ps.States != nil {
+		states = ps.States
+	}
+	if ps.History != nil {
+		history = ps.History
+		if history.Days == nil {
+			history.Days = make(map[string]map[string]*DayStats)
+		}
+		if history.LastReports == nil {
+			history.LastReports = make(map[string]string)
+		}
+	}
+
+	return states, history, nil
+}
+
+// saveState writes the state file atomically so a crash mid-write can't leave
+// a truncated file behind.
+func saveState(path string, states map[string]*ServiceState, history *History) error {
+	data, err := json.Marshal(persistedState{States: states, History: history})
+	if err != nil {
+		return fmt.Errorf("encode json: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces path with data through a temp file and a rename,
+// so a crash never leaves a half-written file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	return nil
+}
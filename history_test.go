@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecord_OpensAndClosesIncidents(t *testing.T) {
+	h := newHistory()
+	svc := Service{Name: "api", Env: "production"}
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	h.record(
+		[]CheckResult{{Service: svc, Up: false, Error: "http_503"}},
+		[]Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "http_503"}},
+		start,
+	)
+
+	if len(h.Incidents) != 1 || !h.Incidents[0].Open() {
+		t.Fatalf("expected 1 open incident, got %+v", h.Incidents)
+	}
+
+	h.record(
+		[]CheckResult{{Service: svc, Up: true, Latency: 40 * time.Millisecond}},
+		[]Transition{{Service: svc, ServiceName: "api (production)", Type: "up"}},
+		start.Add(5*time.Minute),
+	)
+
+	if h.Incidents[0].Open() {
+		t.Fatalf("expected incident to be closed")
+	}
+
+	if got := h.Incidents[0].Duration(time.Time{}); got != 5*time.Minute {
+		t.Errorf("expected duration 5m, got %v", got)
+	}
+
+	stats := h.Days["2024-03-01"]["api:production"]
+	if stats.Checks != 2 || stats.Failures != 1 {
+		t.Errorf("expected 2 checks / 1 failure, got %d / %d", stats.Checks, stats.Failures)
+	}
+
+	if got := stats.AvgLatency(); got != 40*time.Millisecond {
+		t.Errorf("expected avg latency 40ms, got %v", got)
+	}
+}
+
+func TestHistoryRecord_TracksIncidentFailures(t *testing.T) {
+	h := newHistory()
+	svc := Service{Name: "api", Env: "production"}
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	h.record(
+		[]CheckResult{{Service: svc, Error: "http_503"}},
+		[]Transition{{Service: svc, ServiceName: "api", Type: "down", Error: "http_503"}},
+		start,
+	)
+	h.record([]CheckResult{{Service: svc, Error: "timeout"}}, nil, start.Add(time.Minute))
+	h.record([]CheckResult{{Service: svc, Error: "http_503"}}, nil, start.Add(2*time.Minute))
+	h.record(
+		[]CheckResult{{Service: svc, Up: true}},
+		[]Transition{{Service: svc, ServiceName: "api", Type: "up"}},
+		start.Add(3*time.Minute),
+	)
+
+	i := h.lastIncident("api:production")
+	if i == nil || i.Open() {
+		t.Fatalf("expected a closed incident, got %+v", i)
+	}
+	if i.FailedChecks != failThreshold+2 {
+		t.Errorf("expected %d failed checks, got %d", failThreshold+2, i.FailedChecks)
+	}
+	if len(i.Errors) != 2 || i.Errors[0] != "http_503" || i.Errors[1] != "timeout" {
+		t.Errorf("expected distinct errors, got %v", i.Errors)
+	}
+
+	i.AckedBy = "<@U1>"
+	summary := incidentSummary(*i, defaultTheme)
+	for _, want := range []string{"Duration: 3m", "`http_503`, `timeout`", fmt.Sprintf("Failed checks: %d", failThreshold+2), "Acknowledged by: <@U1>"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+}
+
+func TestHistoryPrune_DropsOldData(t *testing.T) {
+	h := newHistory()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-historyRetention - 24*time.Hour)
+
+	h.Incidents = []Incident{{ServiceKey: "a", StartedAt: old, EndedAt: old.Add(time.Minute)}}
+	h.Days[old.Format(dayLayout)] = map[string]*DayStats{"a": {Checks: 1}}
+	h.Days[now.Format(dayLayout)] = map[string]*DayStats{"a": {Checks: 1}}
+
+	h.prune(now)
+
+	if len(h.Incidents) != 0 {
+		t.Errorf("expected old incident to be pruned")
+	}
+
+	if len(h.Days) != 1 {
+		t.Errorf("expected 1 day left, got %d", len(h.Days))
+	}
+}
+
+func TestSaveAndLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	states := map[string]*ServiceState{"api:production": {IsDown: true, FailCount: 5}}
+	h := newHistory()
+	h.LastDigest = "2024-03-01"
+
+	if err := saveState(path, states, h); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	loaded, history, err := loadState(path)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+
+	if !loaded["api:production"].IsDown || loaded["api:production"].FailCount != 5 {
+		t.Errorf("service state was not restored: %+v", loaded["api:production"])
+	}
+
+	if history.LastDigest != "2024-03-01" {
+		t.Errorf("expected last digest to be restored, got '%s'", history.LastDigest)
+	}
+}
+
+func TestHistoryReliability(t *testing.T) {
+	h := newHistory()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	h.Incidents = []Incident{
+		{ServiceKey: "api:production", StartedAt: start, EndedAt: start.Add(10 * time.Minute)},
+		{ServiceKey: "api:production", StartedAt: start.Add(70 * time.Minute), EndedAt: start.Add(100 * time.Minute)},
+		{ServiceKey: "api:production", StartedAt: start.Add(220 * time.Minute)},
+		{ServiceKey: "auth:production", StartedAt: start, EndedAt: start.Add(time.Hour)},
+	}
+
+	rel := h.reliability("api:production", start, start.Add(24*time.Hour))
+
+	if rel.Incidents != 3 {
+		t.Errorf("expected 3 incidents, got %d", rel.Incidents)
+	}
+
+	if rel.MTTR != 20*time.Minute {
+		t.Errorf("expected MTTR 20m, got %v", rel.MTTR)
+	}
+
+	if rel.MTBF != 90*time.Minute {
+		t.Errorf("expected MTBF 90m, got %v", rel.MTBF)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// instanceLock keeps a second instance on the same host from updating the
+// boards in the same channels: two instances would each post a board and
+// alert twice. It locks a file per channel in the temp directory, so it
+// catches instances started from different directories too. The operating
+// system releases the locks when the process dies, so there are no stale
+// locks to clean up after a crash.
+type instanceLock struct {
+	files []*os.File
+}
+
+// lockChannels takes the lock of every channel in dir, or fails naming the
+// process holding one.
+func lockChannels(dir string, channels []string) (*instanceLock, error) {
+	l := &instanceLock{}
+	seen := map[string]bool{}
+	for _, channel := range channels {
+		if seen[channel] {
+			continue
+		}
+		seen[channel] = true
+
+		path := filepath.Join(dir, "status-bot-"+channel+".lock")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			l.release()
+			return nil, fmt.Errorf("open lock file: %w", err)
+		}
+		if err := tryLock(f); err != nil {
+			data, _ := os.ReadFile(path)
+			f.Close()
+			l.release()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("another instance (pid %s) is already updating channel %s; stop it first", strings.TrimSpace(string(data)), channel)
+			}
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+
+		f.Truncate(0)
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+		l.files = append(l.files, f)
+	}
+	return l, nil
+}
+
+// release unlocks every channel. The files stay, so a waiting instance never
+// ends up holding a lock on a file that was removed.
+func (l *instanceLock) release() {
+	for _, f := range l.files {
+		f.Close()
+	}
+	l.files = nil
+}
+
+// boardChannels returns the channels of boards.
+func boardChannels(boards []BoardConfig) []string {
+	channels := make([]string, len(boards))
+	for i, bc := range boards {
+		channels[i] = bc.Channel
+	}
+	return channels
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLockChannels(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := lockChannels(dir, []string{"C1", "C2", "C1"})
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	data, _ := os.ReadFile(dir + "/status-bot-C1.lock")
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("expected the lock file to hold our pid, got %q", data)
+	}
+
+	if _, err := lockChannels(dir, []string{"C3", "C2"}); err == nil || !strings.Contains(err.Error(), "channel C2") {
+		t.Fatalf("expected C2 to be taken, got %v", err)
+	}
+	// The failed attempt must not keep C3 locked.
+	other, err := lockChannels(dir, []string{"C3"})
+	if err != nil {
+		t.Fatalf("expected C3 to be free, got %v", err)
+	}
+	other.release()
+
+	first.release()
+	again, err := lockChannels(dir, []string{"C1", "C2"})
+	if err != nil {
+		t.Fatalf("expected the channels to be free after release, got %v", err)
+	}
+	again.release()
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	actionAcknowledge    = "ack_incident"
+	actionMuteHour       = "mute_1h"
+	actionMuteUntilFixed = "mute_until_fixed"
+	actionUnmute         = "unmute"
+	actionMuteMenu       = "mute_menu"
+	actionRunbook        = "open_runbook"
+)
+
+func plainText(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.PlainTextType, text, false, false)
+}
+
+// muteMenu is the overflow menu shown next to every board line. Option values
+// are "<action>|<service key>".
+func muteMenu(key string) *slack.OverflowBlockElement {
+	return slack.NewOverflowBlockElement(actionMuteMenu,
+		slack.NewOptionBlockObject(actionMuteHour+"|"+key, plainText("Mute 1h"), nil),
+		slack.NewOptionBlockObject(actionMuteUntilFixed+"|"+key, plainText("Mute until fixed"), nil),
+		slack.NewOptionBlockObject(actionUnmute+"|"+key, plainText("Unmute"), nil),
+	)
+}
+
+// incidentBlocks lays out the root message of an incident thread with
+// Acknowledge and mute buttons, each carrying the service key, and a link to
+// the runbook when there is one.
+func incidentBlocks(text string, key string, runbookURL string) []slack.Block {
+	ack := slack.NewButtonBlockElement(actionAcknowledge, key, plainText("Acknowledge"))
+	ack.Style = slack.StylePrimary
+
+	elements := []slack.BlockElement{
+		ack,
+		slack.NewButtonBlockElement(actionMuteHour, key, plainText("Mute 1h")),
+		slack.NewButtonBlockElement(actionMuteUntilFixed, key, plainText("Mute until fixed")),
+	}
+	if runbookURL != "" {
+		elements = append(elements, slack.NewButtonBlockElement(actionRunbook, key, plainText("Runbook")).WithURL(runbookURL))
+	}
+
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewActionBlock("incident_actions", elements...),
+	}
+}
+
+// acknowledge records that user took ownership of the open incident of the
+// service identified by key. It reports false when there is nothing to ack.
+func (b *Bot) acknowledge(key string, user string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.states[key]
+	if state == nil || !state.IsDown || state.AckedBy != "" {
+		return false
+	}
+	state.AckedBy = user
+
+	if incident := b.history.openIncident(key); incident != nil {
+		incident.AckedBy = user
+		incident.AckedAt = now
+	}
+	return true
+}
+
+func (b *Bot) handleInteraction(callback slack.InteractionCallback) {
+	if callback.Type != slack.InteractionTypeBlockActions {
+		return
+	}
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		actionID, key := action.ActionID, action.Value
+		if actionID == actionMuteMenu {
+			actionID, key, _ = strings.Cut(action.SelectedOption.Value, "|")
+		}
+
+		switch actionID {
+		case actionAcknowledge:
+			b.handleAcknowledge(callback, key)
+		case actionMuteHour:
+			until := time.Now().Add(time.Hour)
+			b.muteKey(key, until)
+			b.confirmMute(callback, fmt.Sprintf("🔇 Muted until %s", until.Format("15:04")))
+		case actionMuteUntilFixed:
+			if !b.muteUntilFixed(key) {
+				b.confirmMute(callback, "The service isn't down, so there is nothing to mute until it recovers")
+				continue
+			}
+			b.confirmMute(callback, "🔇 Muted until the service recovers")
+		case actionUnmute:
+			b.unmute(key)
+			b.confirmMute(callback, "🔔 Alerts unmuted")
+		}
+	}
+}
+
+// confirmMute tells the clicking user what happened without touching the
+// shared message.
+func (b *Bot) confirmMute(callback slack.InteractionCallback, text string) {
+	if _, err := b.api.PostEphemeral(callback.Channel.ID, callback.User.ID, slack.MsgOptionText(text, false)); err != nil {
+		slog.Error("failed to confirm mute", "err", err)
+	}
+}
+
+func (b *Bot) handleAcknowledge(callback slack.InteractionCallback, key string) {
+	now := time.Now()
+	if !b.acknowledge(key, callback.User.ID, now) {
+		return
+	}
+
+	// Keep the original alert but drop the Acknowledge button.
+	var blocks []slack.Block
+	for _, block := range callback.Message.Blocks.BlockSet {
+		if actions, ok := block.(*slack.ActionBlock); ok && actions.Elements != nil {
+			var kept []slack.BlockElement
+			for _, el := range actions.Elements.ElementSet {
+				if btn, ok := el.(*slack.ButtonBlockElement); ok && btn.ActionID == actionAcknowledge {
+					continue
+				}
+				kept = append(kept, el)
+			}
+			if len(kept) == 0 {
+				continue
+			}
+			actions.Elements.ElementSet = kept
+		}
+		blocks = append(blocks, block)
+	}
+	ackText := fmt.Sprintf("✅ Acknowledged by <@%s> at %s", callback.User.ID, now.Format("15:04:05"))
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, ackText, false, false),
+	))
+
+	_, _, _, err := b.api.UpdateMessage(callback.Channel.ID, callback.Message.Timestamp,
+		slack.MsgOptionText(callback.Message.Text, false),
+		slack.MsgOptionBlocks(blocks...),
+	)
+	if err != nil {
+		slog.Error("failed to update acknowledged alert", "err", err)
+	}
+}
+
+const serviceModalID = "service_modal"
+
+// serviceModal is the add/edit form for a monitored service. The key of the
+// service being edited travels in the private metadata.
+func serviceModal(svc Service) slack.ModalViewRequest {
+	title := "Add a service"
+	var key string
+	if svc.Name != "" {
+		title, key = "Edit a service", serviceKey(svc)
+	}
+
+	input := func(id string, label string, value string, optional bool) slack.Block {
+		el := slack.NewPlainTextInputBlockElement(nil, id)
+		el.InitialValue = value
+		block := slack.NewInputBlock(id, plainText(label), nil, el)
+		block.Optional = optional
+		return block
+	}
+
+	return slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      serviceModalID,
+		PrivateMetadata: key,
+		Title:           plainText(title),
+		Submit:          plainText("Save"),
+		Close:           plainText("Cancel"),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			input("name", "Name", svc.Name, false),
+			input("url", "Health check URL", svc.URL, false),
+			input("env", "Environment", svc.Env, false),
+			input("owners", "Owners (Slack user or group IDs, comma separated)", strings.Join(svc.Owners, ", "), true),
+			input("runbook", "Runbook URL", svc.RunbookURL, true),
+		}},
+	}
+}
+
+// serviceFromModal reads the submitted form back into a service, keeping the
+// settings the form doesn't show from the edited service.
+func serviceFromModal(view slack.View, base Service) Service {
+	value := func(id string) string {
+		return strings.TrimSpace(view.State.Values[id][id].Value)
+	}
+
+	svc := base
+	svc.Name = value("name")
+	svc.URL = value("url")
+	svc.Env = value("env")
+	svc.RunbookURL = value("runbook")
+	svc.Owners = nil
+	for _, owner := range strings.Split(value("owners"), ",") {
+		if owner = strings.TrimSpace(owner); owner != "" {
+			svc.Owners = append(svc.Owners, owner)
+		}
+	}
+	return svc
+}
+
+// handleViewSubmission validates a submitted service form. It returns the
+// field errors to show in the modal, or nil once the change is accepted; the
+// change itself is saved in the background so the submission is acked in
+// time.
+func (b *Bot) handleViewSubmission(callback slack.InteractionCallback) map[string]any {
+	if callback.View.CallbackID != serviceModalID {
+		return nil
+	}
+
+	oldKey := callback.View.PrivateMetadata
+	var base Service
+	if oldKey != "" {
+		base, _ = serviceByKey(b.services(), oldKey)
+	}
+	svc := serviceFromModal(callback.View, base)
+
+	if problems := b.validateService(&svc, oldKey); len(problems) > 0 {
+		return map[string]any{"response_action": "errors", "errors": problems}
+	}
+
+	go func() {
+		text := fmt.Sprintf("✅ Saved *%s (%s)*, it will be checked from the next cycle", svc.Name, svc.Env)
+		if err := b.saveService(oldKey, svc); err != nil {
+			text = fmt.Sprintf("⚠️ Couldn't save *%s*: %v", svc.Name, err)
+		}
+		if _, _, err := b.api.PostMessage(callback.User.ID, slack.MsgOptionText(text, false)); err != nil {
+			slog.Error("failed to confirm service change", "err", err)
+		}
+	}()
+	return nil
+}
+
+// ackReactions are the reactions that acknowledge the incident of the down
+// alert they are added to.
+var ackReactions = []string{"eyes", "white_check_mark"}
+
+// alertKeys returns the keys of the services whose incident thread or
+// channel alert is the message at ts.
+func alertKeys(states map[string]*ServiceState, channel string, ts string) []string {
+	var keys []string
+	for key, state := range states {
+		if state.IsDown && (state.IncidentTS == ts || state.AlertTS[channel] == ts) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (b *Bot) handleReaction(ev *slackevents.ReactionAddedEvent) {
+	if ev.Item.Type != "message" || !slices.Contains(ackReactions, ev.Reaction) {
+		return
+	}
+
+	b.mu.Lock()
+	keys := alertKeys(b.states, ev.Item.Channel, ev.Item.Timestamp)
+	b.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		if !b.acknowledge(key, ev.User, now) {
+			continue
+		}
+		svc, ok := serviceByKey(b.services(), key)
+		if !ok {
+			continue
+		}
+		note := Note{Author: "<@" + ev.User + ">", Text: fmt.Sprintf("acknowledged with :%s:", ev.Reaction), At: now}
+		if _, err := b.addNote(svc, note); err != nil {
+			slog.Error("failed to note acknowledgement", "err", err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestAcknowledge(t *testing.T) {
+	b := newTestBot(Service{Name: "api", Env: "production"})
+	now := time.Now()
+
+	if b.acknowledge("api:production", "U1", now) {
+		t.Fatalf("acknowledging a healthy service should be a no-op")
+	}
+
+	b.states["api:production"] = &ServiceState{IsDown: true}
+	b.history.Incidents = []Incident{{ServiceKey: "api:production", StartedAt: now}}
+
+	if !b.acknowledge("api:production", "U1", now) {
+		t.Fatalf("expected the incident to be acknowledged")
+	}
+
+	if b.acknowledge("api:production", "U2", now) {
+		t.Errorf("an incident should only be acknowledged once")
+	}
+
+	if b.states["api:production"].AckedBy != "U1" || b.history.Incidents[0].AckedBy != "U1" {
+		t.Errorf("expected U1 to be recorded as acker")
+	}
+}
+
+func TestDetectTransitions_RecoveryClearsAck(t *testing.T) {
+	states := map[string]*ServiceState{"api:production": {IsDown: true, AckedBy: "U1"}}
+	results := []CheckResult{{Service: Service{Name: "api", Env: "production"}, Up: true}}
+
+	detectTransitions(results, states, 1)
+
+	if states["api:production"].AckedBy != "" {
+		t.Errorf("expected ack to be cleared on recovery")
+	}
+}
+
+func TestHandleInteraction_MuteMenu(t *testing.T) {
+	b := newTestBot(Service{Name: "api", Env: "production"}, Service{Name: "web", Env: "production"})
+	b.states["api:production"] = &ServiceState{IsDown: true}
+	b.states["web:production"] = &ServiceState{}
+
+	if b.muteUntilFixed("web:production") || b.states["web:production"].muted(time.Now()) {
+		t.Errorf("expected a healthy service not to be muted until fixed")
+	}
+	if b.muteUntilFixed("db:production") {
+		t.Errorf("expected an unknown service not to be muted")
+	}
+
+	if !b.muteUntilFixed("api:production") || !b.states["api:production"].muted(time.Now()) {
+		t.Fatalf("expected service to be muted until fixed")
+	}
+
+	results := []CheckResult{{Service: Service{Name: "api", Env: "production"}, Up: true}}
+	detectTransitions(results, b.states, 1)
+
+	if b.states["api:production"].muted(time.Now()) {
+		t.Errorf("recovery should lift a mute-until-fixed")
+	}
+
+	b.muteKey("api:production", time.Now().Add(time.Hour))
+	b.unmute("api:production")
+	if b.states["api:production"].muted(time.Now()) {
+		t.Errorf("expected unmute to clear the mute")
+	}
+}
+
+func TestIncidentBlocksRunbook(t *testing.T) {
+	actions := func(blocks []slack.Block) []slack.BlockElement {
+		return blocks[1].(*slack.ActionBlock).Elements.ElementSet
+	}
+
+	if got := actions(incidentBlocks("down", "api:production", "")); len(got) != 3 {
+		t.Fatalf("expected 3 buttons without a runbook, got %d", len(got))
+	}
+
+	got := actions(incidentBlocks("down", "api:production", "https://wiki/runbooks/api"))
+	if len(got) != 4 {
+		t.Fatalf("expected a runbook button, got %d buttons", len(got))
+	}
+	if btn := got[3].(*slack.ButtonBlockElement); btn.URL != "https://wiki/runbooks/api" {
+		t.Errorf("unexpected runbook url %q", btn.URL)
+	}
+}
+
+func TestHandleReactionAcknowledges(t *testing.T) {
+	api, calls := newTestSlack(t)
+	b := newTestBot(Service{Name: "api", Env: "production"}, Service{Name: "auth", Env: "production"})
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.states["api:production"] = &ServiceState{IsDown: true, IncidentTS: "1700000000.000100"}
+	b.states["auth:production"] = &ServiceState{IsDown: true, IncidentTS: "1700000000.000200"}
+	b.history.Incidents = append(b.history.Incidents, Incident{ServiceKey: "api:production", StartedAt: time.Now()})
+
+	reaction := func(name string) *slackevents.ReactionAddedEvent {
+		return &slackevents.ReactionAddedEvent{
+			User:     "U1",
+			Reaction: name,
+			Item:     slackevents.Item{Type: "message", Channel: "C1", Timestamp: "1700000000.000100"},
+		}
+	}
+
+	b.handleReaction(reaction("tada"))
+	if b.states["api:production"].AckedBy != "" {
+		t.Fatalf("an unrelated reaction shouldn't acknowledge")
+	}
+
+	b.handleReaction(reaction("eyes"))
+	if got := b.states["api:production"].AckedBy; got != "U1" {
+		t.Errorf("expected U1 to acknowledge, got %q", got)
+	}
+	if b.states["auth:production"].AckedBy != "" {
+		t.Errorf("only the reacted incident should be acknowledged")
+	}
+	if notes := b.history.Incidents[0].Notes; len(notes) != 1 {
+		t.Errorf("expected the acknowledgement to be noted, got %+v", notes)
+	}
+	if got := calls(); len(got) != 1 || got[0].Get("thread_ts") != "1700000000.000100" {
+		t.Errorf("expected a reply in the incident thread, got %v", got)
+	}
+}
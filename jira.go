@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// JiraConfig files a ticket in project for incidents in envs that last longer
+// than after_minutes, and moves it through done_transition on recovery. Jira
+// Cloud authenticates with JIRA_EMAIL and JIRA_API_TOKEN, Jira Server with a
+// personal access token in JIRA_TOKEN.
+type JiraConfig struct {
+	Enabled        bool     `json:"enabled"`
+	BaseURL        string   `json:"base_url"`
+	Project        string   `json:"project"`
+	IssueType      string   `json:"issue_type"`
+	Labels         []string `json:"labels"`
+	AfterMinutes   int      `json:"after_minutes"`
+	Envs           []string `json:"envs"`
+	DoneTransition string   `json:"done_transition"`
+}
+
+func (c *JiraConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BaseURL == "" || c.Project == "" {
+		return fmt.Errorf("jira.base_url and jira.project are required")
+	}
+	if c.IssueType == "" {
+		c.IssueType = "Task"
+	}
+	if c.AfterMinutes <= 0 {
+		c.AfterMinutes = defaultIssueAfter
+	}
+	if len(c.Envs) == 0 {
+		c.Envs = []string{"production"}
+	}
+	if c.DoneTransition == "" {
+		c.DoneTransition = "Done"
+	}
+	return nil
+}
+
+type jiraClient struct {
+	baseURL string
+	auth    func(*http.Request)
+	client  *http.Client
+}
+
+func newJiraClient(cfg JiraConfig) (*jiraClient, error) {
+	c := &jiraClient{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/") + "/rest/api/2",
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}
+
+	email, apiToken, pat := os.Getenv("JIRA_EMAIL"), os.Getenv("JIRA_API_TOKEN"), os.Getenv("JIRA_TOKEN")
+	switch {
+	case email != "" && apiToken != "":
+		c.auth = func(req *http.Request) { req.SetBasicAuth(email, apiToken) }
+	case pat != "":
+		c.auth = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+pat) }
+	default:
+		return nil, fmt.Errorf("JIRA_EMAIL and JIRA_API_TOKEN, or JIRA_TOKEN, must be set")
+	}
+	return c, nil
+}
+
+// syncJiraTickets files tickets for incidents that crossed the threshold and
+// resolves the tickets of incidents that ended.
+func (b *Bot) syncJiraTickets(ctx context.Context, now time.Time) {
+	if b.jira == nil {
+		return
+	}
+	cfg := b.cfg.Jira
+
+	for i := range b.history.Incidents {
+		incident := &b.history.Incidents[i]
+
+		switch {
+		case incident.JiraKey != "" && !incident.Open() && !incident.JiraResolved:
+			comment := fmt.Sprintf("Recovered at %s after %s.", incident.EndedAt.Format(time.RFC1123), formatDuration(incident.Duration(now)))
+			if err := b.jira.resolve(ctx, incident.JiraKey, cfg.DoneTransition, comment); err != nil {
+				slog.Error("failed to resolve Jira ticket", "ticket", incident.JiraKey, "err", err)
+				continue
+			}
+			incident.JiraResolved = true
+
+		case incident.JiraKey == "" && incident.Open():
+			if !slices.Contains(cfg.Envs, incident.Env) || incident.Duration(now) < time.Duration(cfg.AfterMinutes)*time.Minute {
+				continue
+			}
+			svc, ok := serviceByKey(b.cfg.Services, incident.ServiceKey)
+			if !ok {
+				continue
+			}
+
+			fields := map[string]any{
+				"project":     map[string]string{"key": cfg.Project},
+				"issuetype":   map[string]string{"name": cfg.IssueType},
+				"summary":     fmt.Sprintf("Incident: %s (%s) is down", svc.Name, svc.Env),
+				"description": jiraDescription(svc, *incident, b.incidentPermalink(svc), now),
+			}
+			if len(cfg.Labels) > 0 {
+				fields["labels"] = cfg.Labels
+			}
+
+			key, err := b.jira.createIssue(ctx, fields)
+			if err != nil {
+				slog.Error("failed to file Jira ticket", "service", svc.Name, "env", svc.Env, "err", err)
+				continue
+			}
+			incident.JiraKey = key
+		}
+	}
+}
+
+// incidentPermalink links to the Slack incident thread of svc, or returns ""
+// when there is none.
+func (b *Bot) incidentPermalink(svc Service) string {
+	state := b.states[serviceKey(svc)]
+	if state == nil || state.IncidentTS == "" {
+		return ""
+	}
+	link, err := b.api.GetPermalink(&slack.PermalinkParameters{
+		Channel: primaryChannel(b.boards, svc),
+		Ts:      state.IncidentTS,
+	})
+	if err != nil {
+		slog.Error("failed to get incident thread link", "err", err)
+		return ""
+	}
+	return link
+}
+
+// jiraDescription renders the ticket body in Jira wiki markup.
+func jiraDescription(svc Service, incident Incident, thread string, now time.Time) string {
+	lines := []string{
+		fmt.Sprintf("*Service:* %s (%s)", svc.Name, svc.Env),
+		fmt.Sprintf("*URL:* %s", svc.URL),
+		fmt.Sprintf("*Error:* {{%s}}", incident.Error),
+		fmt.Sprintf("*Down since:* %s (%s)", incident.StartedAt.Format(time.RFC1123), formatDuration(incident.Duration(now))),
+	}
+	if len(svc.Owners) > 0 {
+		lines = append(lines, "*Owners:* "+strings.Join(svc.Owners, ", "))
+	}
+	if svc.RunbookURL != "" {
+		lines = append(lines, fmt.Sprintf("*Runbook:* [%s]", svc.RunbookURL))
+	}
+	if svc.DashboardURL != "" {
+		lines = append(lines, fmt.Sprintf("*Dashboard:* [%s]", svc.DashboardURL))
+	}
+	if thread != "" {
+		lines = append(lines, fmt.Sprintf("*Slack thread:* [Incident thread|%s]", thread))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *jiraClient) createIssue(ctx context.Context, fields map[string]any) (string, error) {
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/issue", map[string]any{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// resolve comments on the ticket and applies the transition called name.
+func (c *jiraClient) resolve(ctx context.Context, key string, name string, comment string) error {
+	if err := c.call(ctx, http.MethodPost, "/issue/"+key+"/comment", map[string]string{"body": comment}, nil); err != nil {
+		return err
+	}
+
+	var available struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/issue/"+key+"/transitions", nil, &available); err != nil {
+		return err
+	}
+
+	for _, t := range available.Transitions {
+		if strings.EqualFold(t.Name, name) {
+			body := map[string]any{"transition": map[string]string{"id": t.ID}}
+			return c.call(ctx, http.MethodPost, "/issue/"+key+"/transitions", body, nil)
+		}
+	}
+	return fmt.Errorf("no %q transition available on %s", name, key)
+}
+
+func (c *jiraClient) call(ctx context.Context, method string, path string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	c.auth(req)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jira %s %s returned %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
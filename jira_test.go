@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncJiraTickets(t *testing.T) {
+	var requests []string
+	var fields map[string]any
+	var transition map[string]map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "ops@example.com" || pass != "tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/issue":
+			var body struct {
+				Fields map[string]any `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			fields = body.Fields
+			w.Write([]byte(`{"key":"OPS-12"}`))
+		case "GET /rest/api/2/issue/OPS-12/transitions":
+			w.Write([]byte(`{"transitions":[{"id":"11","name":"In Progress"},{"id":"31","name":"Done"}]}`))
+		case "POST /rest/api/2/issue/OPS-12/transitions":
+			json.NewDecoder(r.Body).Decode(&transition)
+		}
+	}))
+	defer srv.Close()
+
+	api, _ := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production", URL: "https://api.example.com", RunbookURL: "https://runbooks/api"}
+	b := newTestBot(svc)
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.states["api:production"] = &ServiceState{IsDown: true, IncidentTS: "1700000000.000001"}
+	b.cfg.Jira = JiraConfig{Enabled: true, BaseURL: srv.URL, Project: "OPS"}
+	if err := b.cfg.Jira.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	b.jira = &jiraClient{
+		baseURL: srv.URL + "/rest/api/2",
+		auth:    func(r *http.Request) { r.SetBasicAuth("ops@example.com", "tok") },
+		client:  srv.Client(),
+	}
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	b.history.record(nil, []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}}, start)
+
+	b.syncJiraTickets(context.Background(), start.Add(5*time.Minute))
+	b.syncJiraTickets(context.Background(), start.Add(15*time.Minute))
+	b.syncJiraTickets(context.Background(), start.Add(16*time.Minute))
+
+	if len(requests) != 1 {
+		t.Fatalf("expected a single ticket after the threshold, got %v", requests)
+	}
+	if fields["summary"] != "Incident: api (production) is down" {
+		t.Errorf("unexpected summary %v", fields["summary"])
+	}
+	description, _ := fields["description"].(string)
+	for _, want := range []string{"{{timeout}}", "[https://runbooks/api]", "*Slack thread:* [Incident thread|"} {
+		if !strings.Contains(description, want) {
+			t.Errorf("expected %q in description:\n%s", want, description)
+		}
+	}
+	if b.history.Incidents[0].JiraKey != "OPS-12" {
+		t.Errorf("expected the ticket key to be stored")
+	}
+
+	b.history.record(nil, []Transition{{Service: svc, ServiceName: "api (production)", Type: "up"}}, start.Add(20*time.Minute))
+	b.syncJiraTickets(context.Background(), start.Add(21*time.Minute))
+	b.syncJiraTickets(context.Background(), start.Add(22*time.Minute))
+
+	if len(requests) != 4 || transition["transition"]["id"] != "31" {
+		t.Errorf("expected a comment and the Done transition, got %v %v", requests, transition)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LogSettings come from the environment rather than the config file, so
+// problems loading the config are logged the same way as everything else.
+//
+//	LOG_LEVEL      debug, info (default), warn or error
+//	LOG_FORMAT     text (default) or json
+//	LOG_OUTPUT     stderr (default), file, both or eventlog (Windows; the
+//	               default for the Windows service)
+//	LOG_FILE       path of the log file, default status-bot.log
+//	LOG_MAX_SIZE_MB and LOG_MAX_FILES bound the file and its rotated copies
+type LogSettings struct {
+	Level    slog.Level
+	Format   string
+	Output   string
+	File     string
+	MaxBytes int64
+	MaxFiles int
+}
+
+const (
+	defaultLogFile    = "status-bot.log"
+	defaultLogSizeMB  = 10
+	defaultLogBackups = 5
+)
+
+func logSettingsFromEnv() (LogSettings, error) {
+	s := LogSettings{
+		Format:   os.Getenv("LOG_FORMAT"),
+		Output:   os.Getenv("LOG_OUTPUT"),
+		File:     os.Getenv("LOG_FILE"),
+		MaxBytes: defaultLogSizeMB << 20,
+		MaxFiles: defaultLogBackups,
+	}
+
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		if err := s.Level.UnmarshalText([]byte(level)); err != nil {
+			return LogSettings{}, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+
+	switch s.Output {
+	case "":
+		s.Output = "stderr"
+		if isWindowsService() {
+			s.Output = "eventlog"
+		}
+	case "stderr", "file", "both", "eventlog":
+	default:
+		return LogSettings{}, fmt.Errorf("LOG_OUTPUT must be stderr, file, both or eventlog, got %q", s.Output)
+	}
+
+	if s.File == "" {
+		s.File = defaultLogFile
+	}
+	if v := os.Getenv("LOG_MAX_SIZE_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb <= 0 {
+			return LogSettings{}, fmt.Errorf("LOG_MAX_SIZE_MB must be a positive number, got %q", v)
+		}
+		s.MaxBytes = int64(mb) << 20
+	}
+	if v := os.Getenv("LOG_MAX_FILES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return LogSettings{}, fmt.Errorf("LOG_MAX_FILES must be zero or more, got %q", v)
+		}
+		s.MaxFiles = n
+	}
+	return s, nil
+}
+
+// newLogger builds the process logger writing to w.
+func newLogger(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+func setupLogging() error {
+	s, err := logSettingsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if s.Output == "eventlog" {
+		events, err := openEventLog(serviceName)
+		if err != nil {
+			return fmt.Errorf("open event log: %w", err)
+		}
+		slog.SetDefault(slog.New(newEventLogHandler(events, s.Level)))
+		return nil
+	}
+
+	var out io.Writer = os.Stderr
+	if s.Output != "stderr" {
+		file, err := openRotatingFile(s.File, s.MaxBytes, s.MaxFiles)
+		if err != nil {
+			return fmt.Errorf("open log file: %w", err)
+		}
+		out = file
+		if s.Output == "both" {
+			out = io.MultiWriter(os.Stderr, file)
+		}
+	}
+
+	slog.SetDefault(newLogger(out, s.Format, s.Level))
+	return nil
+}
+
+// logCheck records the outcome of one check. Failures are logged as
+// warnings so they stand out from the steady stream of successful checks.
+func logCheck(r CheckResult) {
+	status, level := "up", slog.LevelInfo
+	if !r.Up {
+		status, level = "down", slog.LevelWarn
+	}
+	attrs := []any{
+		"service", r.Service.Name,
+		"env", r.Service.Env,
+		"status", status,
+		"latency", r.Latency,
+	}
+	if r.StatusCode != 0 {
+		attrs = append(attrs, "status_code", r.StatusCode)
+	}
+	if r.Error != "" {
+		attrs = append(attrs, "error", r.Error)
+	}
+	slog.Log(context.Background(), level, "check", attrs...)
+}
+
+// rotatingFile is an append-only file that is renamed to path.1 (shifting
+// older copies up to path.maxFiles) once it grows past maxBytes.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func openRotatingFile(path string, maxBytes int64, maxFiles int) (*rotatingFile, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	r := &rotatingFile{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if r.maxFiles == 0 {
+		os.Remove(r.path)
+	} else {
+		os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles))
+		for i := r.maxFiles - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// maxLoggedBody caps how much of an API response goes into a debug line.
+const maxLoggedBody = 4096
+
+// debugTransport logs every Slack API response, body included, at debug
+// level. The body is buffered and handed on untouched.
+type debugTransport struct {
+	base http.RoundTripper
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !slog.Default().Enabled(req.Context(), slog.LevelDebug) {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, readErr
+	}
+
+	logged := string(body)
+	if len(logged) > maxLoggedBody {
+		logged = logged[:maxLoggedBody] + "…"
+	}
+	slog.Debug("slack api response",
+		"method", strings.TrimPrefix(req.URL.Path, "/api/"),
+		"status", resp.StatusCode,
+		"body", logged,
+	)
+	return resp, nil
+}
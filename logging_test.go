@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogCheckJSON(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(newLogger(&buf, "json", slog.LevelInfo))
+	defer slog.SetDefault(prev)
+
+	logCheck(CheckResult{
+		Service:    Service{Name: "api", Env: "production"},
+		StatusCode: 502,
+		Latency:    250 * time.Millisecond,
+		Error:      "bad gateway",
+	})
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", buf.String(), err)
+	}
+
+	want := map[string]any{
+		"level":       "WARN",
+		"msg":         "check",
+		"service":     "api",
+		"env":         "production",
+		"status":      "down",
+		"status_code": float64(502),
+		"error":       "bad gateway",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, entry[k])
+		}
+	}
+	if entry["latency"] != float64(250*time.Millisecond) {
+		t.Errorf("expected latency in nanoseconds, got %v", entry["latency"])
+	}
+}
+
+func TestLogSettingsFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_OUTPUT", "both")
+	t.Setenv("LOG_MAX_SIZE_MB", "2")
+
+	s, err := logSettingsFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Level != slog.LevelDebug || s.Output != "both" || s.File != defaultLogFile || s.MaxBytes != 2<<20 || s.MaxFiles != defaultLogBackups {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := logSettingsFromEnv(); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_OUTPUT", "syslog")
+	if _, err := logSettingsFromEnv(); err == nil {
+		t.Errorf("expected an error for an unknown output")
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "bot.log")
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for p, content := range want {
+		got, err := os.ReadFile(p)
+		if err != nil || string(got) != content {
+			t.Errorf("expected %s to hold %q, got %q (%v)", p, content, got, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only two rotated copies to be kept")
+	}
+}
+
+func TestDebugTransportLogsResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"not_in_channel"}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+	client := &http.Client{Transport: debugTransport{base: http.DefaultTransport}}
+
+	slog.SetDefault(newLogger(&buf, "text", slog.LevelInfo))
+	resp, err := client.Get(srv.URL + "/api/chat.update")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing logged above debug level, got %q", buf.String())
+	}
+
+	slog.SetDefault(newLogger(&buf, "text", slog.LevelDebug))
+	resp, err = client.Get(srv.URL + "/api/chat.update")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != `{"ok":false,"error":"not_in_channel"}` {
+		t.Errorf("expected the body to reach the caller, got %q", body)
+	}
+	if out := buf.String(); !strings.Contains(out, "method=chat.update") || !strings.Contains(out, "not_in_channel") {
+		t.Errorf("expected the response to be logged, got %q", out)
+	}
+}
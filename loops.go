@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+// serviceLoops checks every probed service on a timer of its own, instead of
+// all of them at the start of each cycle. A cycle takes what the loops found
+// since the last one, so a service that hangs until its timeout holds up its
+// own checks, not the board.
+type serviceLoops struct {
+	ctx    context.Context
+	pool   *checker.Pool
+	client *http.Client
+
+	mu      sync.Mutex
+	loops   map[string]serviceLoop
+	pending []CheckResult
+	latest  map[string]CheckResult
+	changed chan struct{}
+
+	// running tracks the loop goroutines, so stop can wait for them to be
+	// done with the pool before it is closed.
+	running sync.WaitGroup
+}
+
+type serviceLoop struct {
+	target   checker.Target
+	interval time.Duration
+	stop     context.CancelFunc
+	// now asks for a check right away; the loop closes the channel it gets
+	// once the result is in.
+	now chan chan struct{}
+}
+
+// newServiceLoops returns loops that check on the workers of pool until ctx
+// is done.
+func newServiceLoops(ctx context.Context, pool *checker.Pool, client *http.Client) *serviceLoops {
+	return &serviceLoops{
+		ctx:     ctx,
+		pool:    pool,
+		client:  client,
+		loops:   make(map[string]serviceLoop),
+		latest:  make(map[string]CheckResult),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// serviceInterval is how often svc is checked.
+func serviceInterval(svc Service, cfg Config) time.Duration {
+	if svc.IntervalSeconds > 0 {
+		return time.Duration(svc.IntervalSeconds) * time.Second
+	}
+	return time.Duration(cfg.IntervalSeconds) * time.Second
+}
+
+// sync starts a loop for every probed service that has none, restarts the
+// ones whose check or interval changed and stops the ones that are gone.
+// New loops start staggered over spread, as the checks of a cycle do.
+func (l *serviceLoops) sync(services []Service, cfg Config, spread time.Duration, jitter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	wanted := make(map[string]bool)
+	for i, svc := range services {
+		if svc.Source != "" {
+			continue
+		}
+		key := serviceKey(svc)
+		wanted[key] = true
+
+		t, interval := target(svc, cfg), serviceInterval(svc, cfg)
+		if loop, ok := l.loops[key]; ok {
+			if loop.target == t && loop.interval == interval {
+				continue
+			}
+			loop.stop()
+		}
+
+		ctx, stop := context.WithCancel(l.ctx)
+		now := make(chan chan struct{}, 1)
+		l.loops[key] = serviceLoop{target: t, interval: interval, stop: stop, now: now}
+		offset := checker.Offset(i, len(services), spread, jitter)
+		l.running.Add(1)
+		go func() {
+			defer l.running.Done()
+			l.run(ctx, svc, t, offset, interval, now)
+		}()
+	}
+
+	for key, loop := range l.loops {
+		if !wanted[key] {
+			loop.stop()
+			delete(l.loops, key)
+			delete(l.latest, key)
+		}
+	}
+	l.pending = slices.DeleteFunc(l.pending, func(r CheckResult) bool {
+		return !wanted[serviceKey(r.Service)]
+	})
+}
+
+// run checks svc, as t, every interval, the first time after offset, and
+// whenever a check is asked for on now. Checks still asked for when it
+// returns are let go of, so nobody waits on them.
+func (l *serviceLoops) run(ctx context.Context, svc Service, t checker.Target, offset time.Duration, interval time.Duration, now <-chan chan struct{}) {
+	var requested chan struct{}
+	defer func() {
+		if requested != nil {
+			close(requested)
+		}
+		select {
+		case r := <-now:
+			close(r)
+		default:
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(offset):
+	case requested = <-now:
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		done := make(chan checker.Result, 1)
+		l.pool.Check(ctx, l.client, t, func(r checker.Result) {
+			done <- r
+		})
+		r := <-done
+		// A check cut short because the loop stopped says nothing about
+		// the service.
+		if ctx.Err() != nil {
+			return
+		}
+		l.add(checkResult(svc, r))
+		if requested != nil {
+			close(requested)
+			requested = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case requested = <-now:
+		}
+	}
+}
+
+func (l *serviceLoops) add(r CheckResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.loops[serviceKey(r.Service)]; !ok {
+		return
+	}
+	l.pending = append(l.pending, r)
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+// drain returns the results delivered since the last call, in the order
+// they came in.
+func (l *serviceLoops) drain() []CheckResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pending := l.pending
+	l.pending = nil
+	return pending
+}
+
+// settle records the checked results as the latest of their services and
+// returns the latest result of every service that has one.
+func (l *serviceLoops) settle(checked []CheckResult) map[string]CheckResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, r := range checked {
+		if _, ok := l.loops[serviceKey(r.Service)]; ok {
+			l.latest[serviceKey(r.Service)] = r
+		}
+	}
+	latest := make(map[string]CheckResult, len(l.latest))
+	for key, r := range l.latest {
+		latest[key] = r
+	}
+	return latest
+}
+
+// wait blocks until every loop has delivered a first result, for at most
+// timeout, so the first board shows every service.
+func (l *serviceLoops) wait(ctx context.Context, timeout time.Duration) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		l.mu.Lock()
+		seen := make(map[string]bool, len(l.latest)+len(l.pending))
+		for key := range l.latest {
+			seen[key] = true
+		}
+		for _, r := range l.pending {
+			seen[serviceKey(r.Service)] = true
+		}
+		waiting := false
+		for key := range l.loops {
+			if !seen[key] {
+				waiting = true
+				break
+			}
+		}
+		l.mu.Unlock()
+		if !waiting {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-l.changed:
+		}
+	}
+}
+
+// checkNow has every loop check its service right away, and waits for at
+// most timeout until they all delivered the result. A loop already asked for
+// a check isn't asked again.
+func (l *serviceLoops) checkNow(ctx context.Context, timeout time.Duration) {
+	l.mu.Lock()
+	var pending []chan struct{}
+	for _, loop := range l.loops {
+		done := make(chan struct{})
+		select {
+		case loop.now <- done:
+			pending = append(pending, done)
+		default:
+		}
+	}
+	l.mu.Unlock()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for _, done := range pending {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-done:
+		}
+	}
+}
+
+// stop stops every loop and waits for them to return.
+func (l *serviceLoops) stop() {
+	l.mu.Lock()
+	for key, loop := range l.loops {
+		loop.stop()
+		delete(l.loops, key)
+	}
+	l.mu.Unlock()
+	l.running.Wait()
+}
+
+// startLoops starts checking every service on its own timer until ctx is
+// done, and waits up to an interval for the first checks so the first board
+// shows every service. The returned function stops the loops.
+func (b *Bot) startLoops(ctx context.Context) func() {
+	b.cycleMu.Lock()
+	b.loops = newServiceLoops(ctx, b.pool, b.client)
+	b.cycleMu.Unlock()
+
+	services := b.enabledServices()
+	spread := time.Duration(b.cfg.CheckSpreadSeconds) * time.Second
+	jitter := time.Duration(b.cfg.CheckJitterMs) * time.Millisecond
+	b.loops.sync(services, b.cfg, spread, jitter)
+	b.loops.wait(ctx, time.Duration(b.cfg.IntervalSeconds)*time.Second)
+
+	return func() {
+		b.cycleMu.Lock()
+		defer b.cycleMu.Unlock()
+		b.loops.stop()
+		b.loops = nil
+	}
+}
+
+// loopResults is checkServices for a bot whose services are checked by
+// b.loops. It returns the latest result of every enabled service in config
+// order for the board, and everything checked since the last cycle, in the
+// order it came in, to track state from. Services whose loop hasn't finished
+// a first check yet are left out of both.
+func (b *Bot) loopResults(ctx context.Context, spread time.Duration, jitter time.Duration) (results []CheckResult, checked []CheckResult) {
+	services := b.enabledServices()
+	b.loops.sync(services, b.cfg, spread, jitter)
+
+	// Loops keep the service they were started with; alert settings like
+	// owners may have changed since.
+	current := make(map[string]Service, len(services))
+	for _, svc := range services {
+		current[serviceKey(svc)] = svc
+	}
+	checked = b.loops.drain()
+	for i, r := range checked {
+		checked[i].Service = current[serviceKey(r.Service)]
+	}
+	b.confirmFailures(ctx, checked)
+	latest := b.loops.settle(checked)
+
+	now := time.Now()
+	for _, svc := range services {
+		if svc.Source != "" {
+			r := b.external.result(svc, now)
+			results = append(results, r)
+			checked = append(checked, r)
+			continue
+		}
+		if r, ok := latest[serviceKey(svc)]; ok {
+			r.Service = svc
+			results = append(results, r)
+		}
+	}
+	return results, checked
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+func TestServiceLoops(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	fast := Service{Name: "fast", Env: "production", URL: srv.URL}
+	slow := Service{Name: "slow", Env: "production", URL: srv.URL + "/slow"}
+	b := newTestBot(fast, slow)
+	b.cfg.IntervalSeconds = 60
+	b.client = srv.Client()
+	b.pool = checker.NewPool(2)
+	defer b.pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.loops = newServiceLoops(ctx, b.pool, b.client)
+	b.loops.sync(b.enabledServices(), b.cfg, 0, 0)
+	b.loops.wait(ctx, 200*time.Millisecond)
+
+	started := time.Now()
+	results, checked := b.loopResults(ctx, 0, 0)
+	if took := time.Since(started); took > 100*time.Millisecond {
+		t.Errorf("collecting results shouldn't wait for the slow check, took %v", took)
+	}
+	if len(results) != 1 || results[0].Service.Name != "fast" || !results[0].Up || len(checked) != 1 {
+		t.Fatalf("expected only the fast service so far, got %+v", results)
+	}
+
+	results, checked = b.loopResults(ctx, 0, 0)
+	if len(results) != 1 || len(checked) != 0 {
+		t.Errorf("expected the board to keep the latest result and each check to be tracked once, got %d and %d", len(results), len(checked))
+	}
+
+	b.cfg.Services = []Service{fast, {Name: "slow", Env: "production", URL: slow.URL, Disabled: true}}
+	b.loopResults(ctx, 0, 0)
+	if len(b.loops.loops) != 1 {
+		t.Errorf("expected the loop of the disabled service to stop, got %d loops", len(b.loops.loops))
+	}
+}
+
+func TestServiceLoopsStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	b := newTestBot(Service{Name: "slow", Env: "production", URL: srv.URL})
+	b.cfg.IntervalSeconds = 60
+	pool := checker.NewPool(1)
+	loops := newServiceLoops(context.Background(), pool, srv.Client())
+	loops.sync(b.enabledServices(), b.cfg, time.Hour, 0)
+
+	// Asked for a check while waiting for its first one, then stopped.
+	done := make(chan struct{})
+	for _, loop := range loops.loops {
+		loop.now <- done
+	}
+	time.Sleep(50 * time.Millisecond)
+	loops.stop()
+
+	select {
+	case <-done:
+	default:
+		t.Errorf("expected the pending check to be let go of once stopped")
+	}
+	// With every loop done, closing the pool can't race a check.
+	pool.Close()
+}
+
+func TestServiceInterval(t *testing.T) {
+	cfg := Config{IntervalSeconds: 60}
+	if got := serviceInterval(Service{}, cfg); got != time.Minute {
+		t.Errorf("expected the global interval, got %v", got)
+	}
+	if got := serviceInterval(Service{IntervalSeconds: 10}, cfg); got != 10*time.Second {
+		t.Errorf("expected the service's own interval, got %v", got)
+	}
+}
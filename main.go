@@ -1,24 +1,71 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/Gb16702/status-bot/checker"
+	"github.com/Gb16702/status-bot/config"
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/Gb16702/status-bot/state"
 	"github.com/slack-go/slack"
 )
 
 type Service struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
-	Env  string `json:"env"`
+	Name         string              `json:"name"`
+	URL          string              `json:"url"`
+	Env          string              `json:"env"`
+	Tags         []string            `json:"tags,omitempty"`
+	Owners       []string            `json:"owners,omitempty"`
+	RunbookURL   string              `json:"runbook_url,omitempty"`
+	DashboardURL string              `json:"dashboard_url,omitempty"`
+	Maintenance  []MaintenanceWindow `json:"maintenance,omitempty"`
+
+	// AlertSchedule names an entry of alert_schedules outside of which the
+	// service doesn't alert.
+	AlertSchedule string `json:"alert_schedule,omitempty"`
+
+	// SLO is the monthly uptime target in percent, e.g. 99.9. Services
+	// without one get no SLA alerts.
+	SLO float64 `json:"slo,omitempty"`
+
+	// Source replaces the HTTP check with alerts pushed by another system,
+	// alertmanager or cloudwatch. Match selects the alerts by label.
+	Source string            `json:"source,omitempty"`
+	Match  map[string]string `json:"match,omitempty"`
+
+	// Disabled keeps the service in the config without checking or
+	// showing it.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Retries re-runs a failed check within the same cycle, RetryDelayMs
+	// apart, before it counts toward the fail threshold.
+	Retries      int `json:"retries,omitempty"`
+	RetryDelayMs int `json:"retry_delay_ms,omitempty"`
+
+	// TimeoutMs bounds each check of the service instead of timeout_ms.
+	TimeoutMs int `json:"timeout_ms,omitempty"`
+
+	// IntervalSeconds checks the service on a schedule of its own instead of
+	// every interval_seconds. The board is still updated every
+	// interval_seconds.
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
 }
 
 type Config struct {
@@ -26,6 +73,68 @@ type Config struct {
 	TimeoutMs int `json:"timeout_ms"`
 	Concurrency int `json:"concurrency"`
 	Services []Service `json:"services"`
+	StateFile string `json:"state_file"`
+	BoardTSFile string `json:"board_ts_file"`
+	Digest DigestConfig `json:"digest"`
+	Reports []ReportConfig `json:"reports"`
+	RecentIncidents int `json:"recent_incidents"`
+	ShowReliability bool `json:"show_reliability"`
+	ShowSparkline bool `json:"show_sparkline"`
+	Backup BackupConfig `json:"backup"`
+	HTTPAddr string `json:"http_addr"`
+	Pprof bool `json:"pprof"`
+	Channels map[string]string `json:"channels"`
+	Boards []BoardConfig `json:"boards"`
+	Mentions map[string]string `json:"mentions"`
+	Escalation EscalationConfig `json:"escalation"`
+	ReminderIntervalMinutes int `json:"reminder_interval_minutes"`
+	Theme Theme `json:"theme"`
+	Topic TopicConfig `json:"topic"`
+	Templates TemplateConfig `json:"templates"`
+	QuietHours QuietHoursConfig `json:"quiet_hours"`
+	AlertSchedules map[string]AlertSchedule `json:"alert_schedules"`
+	Announcements AnnouncementConfig `json:"maintenance_announcements"`
+	BoardCheckMinutes int `json:"board_check_minutes"`
+	BoardThreadAlerts bool `json:"board_thread_alerts"`
+	MinUpdateIntervalSeconds int `json:"min_update_interval_seconds"`
+	RecoveryThreshold int `json:"recovery_threshold"`
+	AlertPlacement string `json:"alert_placement"`
+	OverrunPolicy string `json:"overrun_policy"`
+	CheckSpreadSeconds int `json:"check_spread_seconds"`
+	CheckJitterMs int `json:"check_jitter_ms"`
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
+	AlertGroupSeconds int `json:"alert_group_seconds"`
+	AlertRateLimit int `json:"alert_rate_limit"`
+	LatencyAnomaly LatencyAnomalyConfig `json:"latency_anomaly"`
+	SLA SLAConfig `json:"sla"`
+	Confirmation ConfirmationConfig `json:"confirmation"`
+	DNS DNSConfig `json:"dns"`
+	CloudWatch CloudWatchConfig `json:"cloudwatch"`
+	APIAuth APIAuthConfig `json:"api_auth"`
+	HA HAConfig `json:"ha"`
+	Timezone string `json:"timezone"`
+	TimeFormat string `json:"time_format"`
+	ViewerLocalTime bool `json:"viewer_local_time"`
+	OnCall OnCallConfig `json:"on_call"`
+	Webhooks []WebhookConfig `json:"webhooks"`
+	Discord DiscordConfig `json:"discord"`
+	Teams TeamsConfig `json:"teams"`
+	Email EmailConfig `json:"email"`
+	Telegram TelegramConfig `json:"telegram"`
+	Ntfy NtfyConfig `json:"ntfy"`
+	Pushover PushoverConfig `json:"pushover"`
+	SMS SMSConfig `json:"sms"`
+	Routing RoutingConfig `json:"routing"`
+	Statuspage StatuspageConfig `json:"statuspage"`
+	GitHubIssues GitHubIssuesConfig `json:"github_issues"`
+	Jira JiraConfig `json:"jira"`
+	Grafana GrafanaConfig `json:"grafana"`
+	AuditLog AuditLogConfig `json:"audit_log"`
+	StatsD StatsDConfig `json:"statsd"`
+	Pushgateway PushgatewayConfig `json:"pushgateway"`
+	SelfMonitor SelfMonitorConfig `json:"self_monitoring"`
+
+	clock clock
 }
 
 type CheckResult struct {
@@ -37,26 +146,102 @@ type CheckResult struct {
 }
 
 type ServiceState struct {
-    IsDown    bool
-    FailCount int
-    DownSince time.Time
+    IsDown          bool              `json:"is_down"`
+    FailCount       int               `json:"fail_count"`
+    DownSince       time.Time         `json:"down_since"`
+    Latencies       []time.Duration   `json:"latencies"`
+    LastError       string            `json:"last_error"`
+    IncidentTS      string            `json:"incident_ts"`
+    Outcomes        []bool            `json:"outcomes"`
+    MutedUntil      time.Time         `json:"muted_until"`
+    AckedBy         string            `json:"acked_by"`
+    MutedUntilFixed bool              `json:"muted_until_fixed"`
+    Escalated       bool              `json:"escalated"`
+    LastReminder    time.Time         `json:"last_reminder"`
+    AlertTS         map[string]string `json:"alert_ts,omitempty"`
+    SMSSent         bool              `json:"sms_sent"`
+    PassCount       int               `json:"pass_count"`
+    LatencyMean     float64           `json:"latency_mean_ms,omitempty"`
+    LatencyVar      float64           `json:"latency_var,omitempty"`
+    LatencySamples  int               `json:"latency_samples,omitempty"`
+    LatencyAnomaly  bool              `json:"latency_anomaly,omitempty"`
+    AlertDeferred   bool              `json:"alert_deferred,omitempty"`
+    AlertTimes      []time.Time       `json:"alert_times,omitempty"`
+    RateLimited     bool              `json:"rate_limited,omitempty"`
+    SLAAlert        string            `json:"sla_alert,omitempty"`
+    Firing          externalAlerts    `json:"firing,omitempty"`
+}
+
+func (s *ServiceState) muted(now time.Time) bool {
+	return s.MutedUntilFixed || now.Before(s.MutedUntil)
 }
 
 type Transition struct {
+    Service     Service
     ServiceName string
     Type        string
     Error       string
+    PrevError   string
     Downtime    string
+    DownFor     time.Duration
 }
 
-type LastIncident struct {
+type RecentIncident struct {
     ServiceName string
     OccurredAt  time.Time
     Duration    string
 }
 
+// RecentIncidents is a fixed-size ring buffer of the latest resolved incidents.
+type RecentIncidents struct {
+	items []RecentIncident
+	next  int
+	full  bool
+}
+
+func newRecentIncidents(size int) *RecentIncidents {
+	return &RecentIncidents{items: make([]RecentIncident, size)}
+}
+
+func (r *RecentIncidents) add(incident RecentIncident) {
+	if len(r.items) == 0 {
+		return
+	}
+
+	r.items[r.next] = incident
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the buffered incidents, newest first.
+func (r *RecentIncidents) list() []RecentIncident {
+	n := r.next
+	if r.full {
+		n = len(r.items)
+	}
+
+	out := make([]RecentIncident, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, r.items[(r.next-i+len(r.items))%len(r.items)])
+	}
+	return out
+}
+
 const failThreshold = 4
 
+// latencyWindow is the number of recent successful checks kept per service
+// for percentile and trend computation.
+const latencyWindow = 20
+
+// trendRatio is how far the recent median latency has to drift from the
+// older median before a trend arrow is shown.
+const trendRatio = 1.2
+
+// sparklineSlots is the number of recent check outcomes drawn per service.
+const sparklineSlots = 24
+
 func formatDuration(d time.Duration) string {
     if d < time.Minute {
         return fmt.Sprintf("%ds", int(d.Seconds()))
@@ -73,14 +258,9 @@ func formatDuration(d time.Duration) string {
 }
 
 func loadConfig(path string) (Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return Config{}, fmt.Errorf("read file: %w", err)
-	}
-
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("parse json: %w", err)
+	if err := config.Load(path, &cfg); err != nil {
+		return Config{}, err
 	}
 
 	if cfg.IntervalSeconds <= 0 {
@@ -99,377 +279,1762 @@ func loadConfig(path string) (Config, error) {
 		return Config{}, fmt.Errorf("no services defined")
 	}
 
-	return cfg, nil
-}
+	if cfg.RecentIncidents <= 0 {
+		cfg.RecentIncidents = 3
+	}
 
-func checkService(ctx context.Context, client *http.Client, svc Service) CheckResult {
-    start := time.Now()
+	if cfg.StateFile == "" {
+		cfg.StateFile = "state.json"
+	}
 
-    req, err := http.NewRequestWithContext(ctx, http.MethodGet, svc.URL, nil)
-    if err != nil {
-        return CheckResult{
-            Service: svc,
-            Up:      false,
-            Latency: time.Since(start),
-            Error:   "invalid url",
-        }
-    }
+	if cfg.BoardTSFile == "" {
+		cfg.BoardTSFile = ".board_ts"
+	}
 
-    resp, err := client.Do(req)
-    latency := time.Since(start)
+	if cfg.Digest.Time == "" {
+		cfg.Digest.Time = defaultDigestTime
+	}
 
-    if err != nil {
-        return CheckResult{
-            Service: svc,
-            Up:      false,
-            Latency: latency,
-            Error:   "request failed",
-        }
-    }
+	if _, err := time.Parse("15:04", cfg.Digest.Time); err != nil {
+		return Config{}, fmt.Errorf("digest.time must be HH:MM")
+	}
 
-    defer resp.Body.Close()
+	cfg.Theme = cfg.Theme.withDefaults()
 
-    up := resp.StatusCode >= 200 && resp.StatusCode < 300
-    result := CheckResult{
-        Service:    svc,
-        Up:         up,
-        StatusCode: resp.StatusCode,
-        Latency:    latency,
-    }
+	if err := cfg.Templates.compile(); err != nil {
+		return Config{}, err
+	}
 
-    if !up {
-        result.Error = fmt.Sprintf("http_%d", resp.StatusCode)
-    }
+	// Quiet hours follow the configured timezone, or local time when there
+	// is none.
+	if cfg.QuietHours.Timezone == "" {
+		cfg.QuietHours.Timezone = cfg.Timezone
+	}
+	if err := cfg.QuietHours.validate(); err != nil {
+		return Config{}, err
+	}
 
-    return result
-}
+	if err := cfg.OnCall.validate(); err != nil {
+		return Config{}, err
+	}
 
-func checkAll(ctx context.Context, client *http.Client, services []Service, concurrency int) []CheckResult {
-	results := make([]CheckResult, len(services))
-	sem := make(chan struct{}, concurrency)
-	var wg sync.WaitGroup
+	if err := cfg.Email.validate(); err != nil {
+		return Config{}, err
+	}
 
-	for i, svc := range services {
-		wg.Add(1)
-		sem <- struct{}{}
+	if err := cfg.Ntfy.validate(); err != nil {
+		return Config{}, err
+	}
 
-		go func(i int, svc Service) {
-			defer wg.Done()
-			defer func() { <-sem }()
-			results[i] = checkService(ctx, client, svc)
-		}(i, svc)
+	if err := cfg.Pushover.validate(); err != nil {
+		return Config{}, err
 	}
 
-	wg.Wait()
-	return results
-}
+	if err := cfg.SMS.validate(); err != nil {
+		return Config{}, err
+	}
 
-func countStatus(results []CheckResult) (healthy int, down int) {
-    for _, r := range results {
-        if r.Up {
-            healthy++
-        } else {
-            down++
-        }
-    }
+	if err := cfg.Routing.validate(); err != nil {
+		return Config{}, err
+	}
 
-    return
-}
+	if err := cfg.Statuspage.validate(); err != nil {
+		return Config{}, err
+	}
 
-func loadBoardTS(path string) string {
-    data, err := os.ReadFile(path)
-    if err != nil {
-        return ""
-    }
+	if err := cfg.GitHubIssues.validate(); err != nil {
+		return Config{}, err
+	}
 
-    return strings.TrimSpace(string(data))
-}
+	if err := cfg.Jira.validate(); err != nil {
+		return Config{}, err
+	}
 
-func saveBoardTS(path string, ts string) error {
-    return os.WriteFile(path, []byte(ts), 0600)
-}
+	if err := cfg.Grafana.validate(); err != nil {
+		return Config{}, err
+	}
 
-func upsertBoard(api *slack.Client, channelID string, tsPath string, blocks []slack.Block) error {
-    ts := loadBoardTS(tsPath)
+	if err := cfg.AuditLog.validate(); err != nil {
+		return Config{}, err
+	}
 
-    if ts == "" {
-        _, newTS, err := api.PostMessage(channelID, slack.MsgOptionBlocks(blocks...))
-        if err != nil {
-            return fmt.Errorf("post message: %w", err)
-        }
-        return saveBoardTS(tsPath, newTS)
-    }
+	if err := cfg.StatsD.validate(); err != nil {
+		return Config{}, err
+	}
 
-    _, _, _, err := api.UpdateMessage(channelID, ts, slack.MsgOptionBlocks(blocks...))
-    if err != nil {
-        _, newTS, err := api.PostMessage(channelID, slack.MsgOptionBlocks(blocks...))
-        if err != nil {
-            return fmt.Errorf("post message: %w", err)
-        }
-        return saveBoardTS(tsPath, newTS)
-    }
+	if err := cfg.Pushgateway.validate(); err != nil {
+		return Config{}, err
+	}
 
-    return nil
-}
+	if err := cfg.SelfMonitor.validate(); err != nil {
+		return Config{}, err
+	}
 
-func postThreadAlert(api *slack.Client, channelID string, tsPath string, message string) error {
-    ts := loadBoardTS(tsPath)
-    if ts == "" {
-        return fmt.Errorf("no board message to reply to")
-    }
+	if err := cfg.LatencyAnomaly.validate(); err != nil {
+		return Config{}, err
+	}
 
-    _, _, err := api.PostMessage(
-        channelID,
-        slack.MsgOptionText(message, false),
-        slack.MsgOptionTS(ts),
-    )
-    return err
-}
+	if err := cfg.SLA.validate(); err != nil {
+		return Config{}, err
+	}
 
-func serviceKey(svc Service) string {
-    return svc.Name + ":" + svc.Env
-}
+	if err := cfg.Confirmation.validate(); err != nil {
+		return Config{}, err
+	}
 
-func detectTransitions(results []CheckResult, states map[string]*ServiceState) []Transition {
-    var transitions []Transition
+	if err := cfg.DNS.validate(); err != nil {
+		return Config{}, err
+	}
 
-    for _, r := range results {
-        key := serviceKey(r.Service)
-        displayName := fmt.Sprintf("%s (%s)", r.Service.Name, r.Service.Env)
-        state, exists := states[key]
-        if !exists {
-            state = &ServiceState{}
-            states[key] = state
-        }
+	if err := cfg.APIAuth.validate(); err != nil {
+		return Config{}, err
+	}
 
-        if r.Up {
-            if state.IsDown {
-                downtime := ""
-                if !state.DownSince.IsZero() {
-                    downtime = formatDuration(time.Since(state.DownSince))
-                }
-                transitions = append(transitions, Transition{
-                    ServiceName: displayName,
-                    Type:        "up",
-                    Downtime:    downtime,
-                })
-                state.IsDown = false
-                state.DownSince = time.Time{}
-            }
-            state.FailCount = 0
-        } else {
-            state.FailCount++
-            if !state.IsDown && state.FailCount >= failThreshold {
-                transitions = append(transitions, Transition{
-                    ServiceName: displayName,
-                    Type:        "down",
-                    Error:       r.Error,
-                })
-                state.IsDown = true
-                state.DownSince = time.Now()
-            }
-        }
-    }
+	if err := cfg.HA.validate(cfg.Backup); err != nil {
+		return Config{}, err
+	}
 
-    return transitions
-}
+	for i := range cfg.Webhooks {
+		if err := cfg.Webhooks[i].validate(); err != nil {
+			return Config{}, fmt.Errorf("webhook %d: %w", i, err)
+		}
+	}
 
-func sendAlerts(api *slack.Client, channelID string, tsPath string, transitions []Transition) {
-    var downLines, upLines []string
+	clk, err := newClock(cfg.Timezone, cfg.TimeFormat, cfg.ViewerLocalTime)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.clock = clk
 
-    for _, t := range transitions {
-        if t.Type == "down" {
-            downLines = append(downLines, fmt.Sprintf("• *%s*: `%s`", t.ServiceName, t.Error))
-        } else {
-            if t.Downtime != "" {
-                upLines = append(upLines, fmt.Sprintf("• *%s* (was down %s)", t.ServiceName, t.Downtime))
-            } else {
-                upLines = append(upLines, fmt.Sprintf("• *%s*", t.ServiceName))
-            }
-        }
-    }
+	if cfg.RecoveryThreshold <= 0 {
+		cfg.RecoveryThreshold = 1
+	}
 
-    if len(downLines) > 0 {
-        msg := "🔴 *Services DOWN* <!here>\n" + strings.Join(downLines, "\n")
-        if err := postThreadAlert(api, channelID, tsPath, msg); err != nil {
-            fmt.Fprintf(os.Stderr, "failed to post alert: %v\n", err)
-        }
-    }
+	if cfg.BoardCheckMinutes <= 0 {
+		cfg.BoardCheckMinutes = defaultBoardCheckMinutes
+	}
 
-    if len(upLines) > 0 {
-        msg := "🟢 *Services back UP*\n" + strings.Join(upLines, "\n")
-        if err := postThreadAlert(api, channelID, tsPath, msg); err != nil {
-            fmt.Fprintf(os.Stderr, "failed to post alert: %v\n", err)
-        }
-    }
+	if cfg.MinUpdateIntervalSeconds <= 0 {
+		cfg.MinUpdateIntervalSeconds = defaultMinUpdateInterval
+	}
+
+	if cfg.Announcements.LeadMinutes <= 0 {
+		cfg.Announcements.LeadMinutes = defaultAnnouncementLead
+	}
+
+	switch cfg.AlertPlacement {
+	case "":
+		cfg.AlertPlacement = placementBoardThread
+	case placementBoardThread, placementChannel:
+	default:
+		return Config{}, fmt.Errorf("alert_placement must be %s or %s, got %q", placementBoardThread, placementChannel, cfg.AlertPlacement)
+	}
+
+	switch cfg.OverrunPolicy {
+	case "":
+		cfg.OverrunPolicy = overrunDelay
+	case overrunDelay, overrunSkip:
+	default:
+		return Config{}, fmt.Errorf("overrun_policy must be %s or %s, got %q", overrunDelay, overrunSkip, cfg.OverrunPolicy)
+	}
+
+	if cfg.CheckSpreadSeconds < 0 || cfg.CheckJitterMs < 0 {
+		return Config{}, fmt.Errorf("check_spread_seconds and check_jitter_ms can't be negative")
+	}
+
+	if cfg.CheckSpreadSeconds >= cfg.IntervalSeconds {
+		return Config{}, fmt.Errorf("check_spread_seconds must be shorter than interval_seconds")
+	}
+
+	if cfg.ShutdownTimeoutSeconds < 0 {
+		return Config{}, fmt.Errorf("shutdown_timeout_seconds can't be negative")
+	}
+	if cfg.ShutdownTimeoutSeconds == 0 {
+		cfg.ShutdownTimeoutSeconds = int(defaultShutdownGrace / time.Second)
+	}
+
+	if cfg.Topic.MinIntervalSeconds <= 0 {
+		cfg.Topic.MinIntervalSeconds = defaultTopicInterval
+	}
+
+	if cfg.Backup.Enabled {
+		if cfg.Backup.Bucket == "" {
+			return Config{}, fmt.Errorf("backup.bucket is required when backup is enabled")
+		}
+		if cfg.Backup.Region == "" {
+			cfg.Backup.Region = "us-east-1"
+		}
+		if cfg.Backup.Endpoint == "" {
+			cfg.Backup.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Backup.Region)
+		}
+		if cfg.Backup.IntervalSeconds <= 0 {
+			cfg.Backup.IntervalSeconds = 300
+		}
+	}
+
+	for name, s := range cfg.AlertSchedules {
+		if err := s.validate(); err != nil {
+			return Config{}, fmt.Errorf("alert_schedules.%s: %w", name, err)
+		}
+		cfg.AlertSchedules[name] = s
+	}
+
+	for i := range cfg.Services {
+		svc := &cfg.Services[i]
+		if err := svc.validate(cfg.AlertSchedules); err != nil {
+			return Config{}, fmt.Errorf("service %s: %w", svc.Name, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, bc := range cfg.Boards {
+		if bc.Name == "" {
+			return Config{}, fmt.Errorf("every board needs a name")
+		}
+		if names[bc.Name] {
+			return Config{}, fmt.Errorf("duplicate board name %q", bc.Name)
+		}
+		names[bc.Name] = true
+
+		if bc.Layout != "" && bc.Layout != "env" && bc.Layout != "flat" && bc.Layout != "compact" {
+			return Config{}, fmt.Errorf("board %q: layout must be env, flat or compact, got %q", bc.Name, bc.Layout)
+		}
+
+		switch bc.Sort {
+		case "", "config", "down_first", "name", "latency":
+		default:
+			return Config{}, fmt.Errorf("board %q: sort must be config, down_first, name or latency, got %q", bc.Name, bc.Sort)
+		}
+	}
+
+	for _, r := range cfg.Reports {
+		if r.Period != "weekly" && r.Period != "monthly" {
+			return Config{}, fmt.Errorf("report period must be weekly or monthly, got %q", r.Period)
+		}
+		for _, f := range r.Formats {
+			if f != "markdown" && f != "csv" {
+				return Config{}, fmt.Errorf("report format must be markdown or csv, got %q", f)
+			}
+		}
+	}
+
+	return cfg, nil
 }
 
-func renderServiceLine(r CheckResult, states map[string]*ServiceState) string {
-    var emoji, statusText string
-    if r.Up {
-        emoji = "🟢"
-        statusText = fmt.Sprintf("`%dms`", r.Latency.Milliseconds())
-    } else {
-        emoji = "🔴"
-        key := serviceKey(r.Service)
-        state := states[key]
-        if state != nil && !state.DownSince.IsZero() {
-            downtime := formatDuration(time.Since(state.DownSince))
-            statusText = fmt.Sprintf("`%s (%s)`", r.Error, downtime)
-        } else {
-            statusText = fmt.Sprintf("`%s`", r.Error)
-        }
-    }
-    return fmt.Sprintf("%s  *%s:* %s", emoji, r.Service.Name, statusText)
+// target is the checker's view of svc. Its timeout is timeout_ms of the
+// service, or of cfg when it has none.
+func target(svc Service, cfg Config) checker.Target {
+	timeout := cfg.TimeoutMs
+	if svc.TimeoutMs > 0 {
+		timeout = svc.TimeoutMs
+	}
+	return checker.Target{
+		Name:       svc.Name,
+		Env:        svc.Env,
+		URL:        svc.URL,
+		Timeout:    time.Duration(timeout) * time.Millisecond,
+		Retries:    svc.Retries,
+		RetryDelay: time.Duration(svc.RetryDelayMs) * time.Millisecond,
+	}
 }
 
-func renderBoard(results []CheckResult, states map[string]*ServiceState, lastIncident *LastIncident) []slack.Block {
-    var blocks []slack.Block
+func checkResult(svc Service, r checker.Result) CheckResult {
+	return CheckResult{Service: svc, Up: r.Up, StatusCode: r.StatusCode, Latency: r.Latency, Error: r.Error}
+}
 
-    updateText := fmt.Sprintf("Updated: %s", time.Now().Format("2006-01-02 15:04:05"))
-    blocks = append(blocks, slack.NewContextBlock("",
-        slack.NewTextBlockObject(slack.MarkdownType, updateText, false, false),
-    ))
+func checkService(ctx context.Context, client *http.Client, svc Service, cfg Config) CheckResult {
+	return checkResult(svc, checker.Check(ctx, client, target(svc, cfg)))
+}
 
-    blocks = append(blocks, slack.NewContextBlock("",
-        slack.NewTextBlockObject(slack.MarkdownType, "*Development*", false, false),
-    ))
-    for _, r := range results {
-        if r.Service.Env == "development" {
-            text := renderServiceLine(r, states)
-            blocks = append(blocks, slack.NewSectionBlock(
-                slack.NewTextBlockObject(slack.MarkdownType, text, false, false),
-                nil, nil,
-            ))
-        }
-    }
+// checkAll checks every service on the workers of pool. With a spread, the
+// starts are staggered evenly over it, and each is pushed back by up to
+// jitter more, so the checks don't all hit at once.
+func checkAll(ctx context.Context, pool *checker.Pool, client *http.Client, services []Service, cfg Config, spread time.Duration, jitter time.Duration) []CheckResult {
+	targets := make([]checker.Target, len(services))
+	for i, svc := range services {
+		targets[i] = target(svc, cfg)
+	}
 
-    blocks = append(blocks, slack.NewDividerBlock())
+	opts := checker.Options{Spread: spread, Jitter: jitter}
+	results := make([]CheckResult, len(services))
+	for i, r := range pool.CheckAll(ctx, client, targets, opts) {
+		results[i] = checkResult(services[i], r)
+	}
+	return results
+}
 
-    blocks = append(blocks, slack.NewContextBlock("",
-        slack.NewTextBlockObject(slack.MarkdownType, "*Production*", false, false),
-    ))
+func countStatus(results []CheckResult) (healthy int, down int) {
     for _, r := range results {
-        if r.Service.Env == "production" {
-            text := renderServiceLine(r, states)
-            blocks = append(blocks, slack.NewSectionBlock(
-                slack.NewTextBlockObject(slack.MarkdownType, text, false, false),
-                nil, nil,
-            ))
+        if r.Up {
+            healthy++
+        } else {
+            down++
         }
     }
 
-    blocks = append(blocks, slack.NewDividerBlock())
-
-    healthy, down := countStatus(results)
-    footerText := fmt.Sprintf("%d healthy  •  %d down", healthy, down)
+    return
+}
 
-    lastIncidentText := renderLastIncident(lastIncident)
-    if lastIncidentText != "" {
-        footerText += "\n" + lastIncidentText
+func postThreadAlert(api *slack.Client, channelID string, ref *slackboard.Ref, message string) error {
+    ts := ref.TS()
+    if ts == "" {
+        return fmt.Errorf("no board message to reply to")
     }
 
-    blocks = append(blocks, slack.NewContextBlock("",
-        slack.NewTextBlockObject(slack.MarkdownType, footerText, false, false),
-    ))
-
-    return blocks
+    _, _, err := api.PostMessage(
+        channelID,
+        slack.MsgOptionText(message, false),
+        slack.MsgOptionTS(ts),
+    )
+    return err
 }
 
-func renderLastIncident(incident *LastIncident) string {
-    if incident == nil || incident.OccurredAt.IsZero() {
-        return ""
-    }
-    ago := formatDuration(time.Since(incident.OccurredAt))
-    return fmt.Sprintf("Last incident: %s, %s ago (down %s)", incident.ServiceName, ago, incident.Duration)
+func serviceKey(svc Service) string {
+    return svc.Name + ":" + svc.Env
 }
 
-func runCycle(ctx context.Context, api *slack.Client, client *http.Client, cfg Config, channelID string, states map[string]*ServiceState, lastIncident *LastIncident) error {
-	results := checkAll(ctx, client, cfg.Services, cfg.Concurrency)
+func recordLatencies(results []CheckResult, states map[string]*ServiceState) {
 	for _, r := range results {
-		fmt.Printf("%s: up=%v, latency=%v\n", r.Service.Name, r.Up, r.Latency)
-	}
+		if !r.Up {
+			continue
+		}
 
-	transitions := detectTransitions(results, states)
+		key := serviceKey(r.Service)
+		state, exists := states[key]
+		if !exists {
+			state = &ServiceState{}
+			states[key] = state
+		}
 
-	for _, t := range transitions {
-		if t.Type == "up" && t.Downtime != "" {
-			lastIncident.ServiceName = t.ServiceName
-			lastIncident.OccurredAt = time.Now()
-			lastIncident.Duration = t.Downtime
+		state.Latencies = append(state.Latencies, r.Latency)
+		if len(state.Latencies) > latencyWindow {
+			state.Latencies = state.Latencies[len(state.Latencies)-latencyWindow:]
 		}
 	}
+}
 
-	blocks := renderBoard(results, states, lastIncident)
+func recordOutcomes(results []CheckResult, states map[string]*ServiceState) {
+	for _, r := range results {
+		key := serviceKey(r.Service)
+		state, exists := states[key]
+		if !exists {
+			state = &ServiceState{}
+			states[key] = state
+		}
 
-	if err := upsertBoard(api, channelID, ".board_ts", blocks); err != nil {
-		return fmt.Errorf("upsert board: %w", err)
+		state.Outcomes = append(state.Outcomes, r.Up)
+		if len(state.Outcomes) > sparklineSlots {
+			state.Outcomes = state.Outcomes[len(state.Outcomes)-sparklineSlots:]
+		}
 	}
-
-	sendAlerts(api, channelID, ".board_ts", transitions)
-
-	fmt.Println("Board updated successfully")
-	return nil
 }
 
-func run() error {
-	token := os.Getenv("SLACK_BOT_TOKEN")
-	if token == "" {
-		return fmt.Errorf("SLACK_BOT_TOKEN is not set")
+// sparkline draws check outcomes oldest first, a low bar for a pass and a
+// full bar for a failure so outages stand out.
+func sparkline(outcomes []bool) string {
+	var b strings.Builder
+	for _, up := range outcomes {
+		if up {
+			b.WriteString("▁")
+		} else {
+			b.WriteString("█")
+		}
 	}
+	return b.String()
+}
 
-	channelID := os.Getenv("SLACK_CHANNEL_ID")
-	if channelID == "" {
-		return fmt.Errorf("SLACK_CHANNEL_ID is not set")
+// percentile returns the nearest-rank percentile p (0-100) of samples.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
 	}
 
-	cfg, err := loadConfig("services.json")
-	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
 	}
+	return sorted[rank-1]
+}
 
-	fmt.Printf("Loaded %d services, checking every %ds\n", len(cfg.Services), cfg.IntervalSeconds)
+// latencyTrend compares the median of the newer half of samples against the
+// older half and returns an arrow when they differ by more than trendRatio.
+func latencyTrend(samples []time.Duration) string {
+	if len(samples) < 4 {
+		return ""
+	}
 
-	api := slack.New(token)
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: cfg.Concurrency,
-		IdleConnTimeout:     90 * time.Second,
+	half := len(samples) / 2
+	older := percentile(samples[:half], 50)
+	newer := percentile(samples[half:], 50)
+	if older <= 0 {
+		return ""
 	}
 
-	client := &http.Client{
-		Timeout:   time.Duration(cfg.TimeoutMs) * time.Millisecond,
-		Transport: transport,
+	ratio := float64(newer) / float64(older)
+	switch {
+	case ratio >= trendRatio:
+		return "↑"
+	case ratio <= 1/trendRatio:
+		return "↓"
 	}
-	states := make(map[string]*ServiceState)
-	lastIncident := &LastIncident{}
+	return ""
+}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+// detectTransitions updates states with results and returns what changed. A
+// service goes down after failThreshold failed checks in a row and comes
+// back up after recoveryThreshold passed ones, by the same rules as
+// state.Tracker, which the bot doesn't use since ServiceState keeps more.
+func detectTransitions(results []CheckResult, states map[string]*ServiceState, recoveryThreshold int) []Transition {
+	var transitions []Transition
+	now := time.Now()
 
-	if err := runCycle(ctx, api, client, cfg, channelID, states, lastIncident); err != nil {
-		fmt.Fprintf(os.Stderr, "cycle error: %v\n", err)
-	}
+	for _, r := range results {
+		key := serviceKey(r.Service)
+		st, exists := states[key]
+		if !exists {
+			st = &ServiceState{}
+			states[key] = st
+		}
 
-	ticker := time.NewTicker(time.Duration(cfg.IntervalSeconds) * time.Second)
-	defer ticker.Stop()
+		status := state.Status{IsDown: st.IsDown, FailCount: st.FailCount, PassCount: st.PassCount, DownSince: st.DownSince, LastError: st.LastError}
+		downSince := st.DownSince
+		change, changed := status.Observe(r.Up, r.Error, now, failThreshold, recoveryThreshold)
+		st.IsDown, st.FailCount, st.PassCount, st.DownSince, st.LastError = status.IsDown, status.FailCount, status.PassCount, status.DownSince, status.LastError
+		if !changed {
+			continue
+		}
 
-	for {
-		select {
-		case <-ticker.C:
-			if err := runCycle(ctx, api, client, cfg, channelID, states, lastIncident); err != nil {
-				fmt.Fprintf(os.Stderr, "cycle error: %v\n", err)
+		t := Transition{
+			Service:     r.Service,
+			ServiceName: fmt.Sprintf("%s (%s)", r.Service.Name, r.Service.Env),
+			Type:        change.Type,
+			Error:       change.Error,
+			PrevError:   change.PrevError,
+			DownFor:     change.DownFor,
+		}
+		if change.Type == state.Up {
+			if !downSince.IsZero() {
+				t.Downtime = formatDuration(change.DownFor)
 			}
-		case <-ctx.Done():
-			fmt.Println("Shutting down...")
-			return nil
+			st.AckedBy = ""
+			st.MutedUntilFixed = false
+			st.Escalated = false
+			st.LastReminder = time.Time{}
+			st.SMSSent = false
 		}
+		transitions = append(transitions, t)
 	}
+
+	return transitions
 }
 
-func main() {
-	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+// holdRecovering shows services that passed checks again but haven't reached
+// the recovery threshold yet as still down, so the board doesn't flip back
+// and forth while they stabilize.
+func holdRecovering(results []CheckResult, states map[string]*ServiceState, now time.Time) []CheckResult {
+	out := slices.Clone(results)
+	for i, r := range out {
+		state := states[serviceKey(r.Service)]
+		if !r.Up || state == nil || !state.IsDown || inMaintenance(r.Service, now) {
+			continue
+		}
+		out[i].Up = false
+		out[i].Error = state.LastError + " (recovering)"
+	}
+	return out
+}
+
+// formatMention turns an owner entry into Slack mention syntax: user IDs
+// (U…/W…), user group IDs (S…), here/channel, or pre-formatted mentions.
+func formatMention(owner string) string {
+	owner = strings.TrimSpace(owner)
+	switch {
+	case owner == "":
+		return ""
+	case strings.HasPrefix(owner, "<"):
+		return owner
+	case owner == "here" || owner == "@here":
+		return "<!here>"
+	case owner == "channel" || owner == "@channel":
+		return "<!channel>"
+	case strings.HasPrefix(owner, "S"):
+		return "<!subteam^" + owner + ">"
+	case strings.HasPrefix(owner, "U"), strings.HasPrefix(owner, "W"):
+		return "<@" + owner + ">"
+	}
+	return owner
+}
+
+func ownerMentions(svc Service) string {
+	var mentions []string
+	for _, owner := range svc.Owners {
+		if m := formatMention(owner); m != "" {
+			mentions = append(mentions, m)
+		}
+	}
+	return strings.Join(mentions, " ")
+}
+
+// policyMention returns the mention used for a down service without owners,
+// per the mentions config: an entry for the env, else "default", else @here.
+// "none" disables the mention.
+func policyMention(policy map[string]string, env string) string {
+	v := policy[env]
+	if v == "" {
+		v = policy["default"]
+	}
+	if v == "" {
+		v = "here"
+	}
+	if v == "none" {
+		return ""
+	}
+	return formatMention(v)
+}
+
+// alertMention is the header mention for a down service without owners: the
+// current on-call when the env has one, else the mention policy.
+func alertMention(env string, opts alertOptions) string {
+	if opts.onCall != nil {
+		if m := opts.onCall(env); m != "" {
+			return m
+		}
+	}
+	return policyMention(opts.mentions, env)
+}
+
+// Alert placements: grouped alerts either reply under the board message, or
+// go to the channel with recoveries replying under their down alert.
+const (
+	placementBoardThread = "board_thread"
+	placementChannel     = "channel"
+)
+
+// alertOptions carries the settings shared by every alert message.
+type alertOptions struct {
+	mentions  map[string]string
+	theme     Theme
+	placement string
+	templates TemplateConfig
+
+	// quiet reports whether mentions for an env are silenced right now.
+	quiet func(env string) bool
+
+	// onCall returns the on-call mention for an env, "" when there is none.
+	onCall func(env string) string
+
+	// incidentThread, when set, returns the thread of the open incident of
+	// svc, and false when it has none or its alerts go to the board thread.
+	incidentThread func(svc Service) (ts string, ok bool)
+}
+
+func sendAlerts(api *slack.Client, channelID string, ref *slackboard.Ref, transitions []Transition, states map[string]*ServiceState, opts alertOptions) {
+    var downLines, upLines, headerMentions, downMentions []string
+    var down, up []Transition
+
+    for _, t := range transitions {
+        switch t.Type {
+        case "down":
+            line := fmt.Sprintf("• *%s*: `%s`", t.ServiceName, t.Error)
+            if t.Service.RunbookURL != "" {
+                line += fmt.Sprintf(" <%s|Runbook>", t.Service.RunbookURL)
+            }
+            quiet := opts.quiet != nil && opts.quiet(t.Service.Env)
+            mentions := ownerMentions(t.Service)
+            if quiet {
+                mentions = ""
+            }
+            var header string
+            if mentions != "" {
+                line += " " + mentions
+            } else if m := alertMention(t.Service.Env, opts); !quiet && m != "" {
+                header = m
+                if !slices.Contains(headerMentions, m) {
+                    headerMentions = append(headerMentions, m)
+                }
+            }
+            downMentions = append(downMentions, header)
+            data := newMessageData(t.Service)
+            data.Owner = mentions
+            data.Error = t.Error
+            downLines = append(downLines, renderTemplate(opts.templates.downAlert, data, line))
+            down = append(down, t)
+        case "up":
+            upLines = append(upLines, recoveryLine(t, opts.templates))
+            up = append(up, t)
+        }
+    }
+
+    if opts.placement == placementChannel {
+        sendChannelAlerts(api, channelID, down, up, downLines, headerMentions, states, opts)
+        return
+    }
+
+    if opts.incidentThread != nil {
+        downLines, headerMentions = postIncidentAlerts(api, channelID, down, downLines, downMentions, opts)
+        upLines = nil
+        for _, t := range up {
+            if _, ok := opts.incidentThread(t.Service); !ok {
+                upLines = append(upLines, recoveryLine(t, opts.templates))
+            }
+        }
+    }
+
+    if len(downLines) > 0 {
+        header := fmt.Sprintf("%s *%s*", opts.theme.DownEmoji, opts.theme.DownTitle)
+        if len(headerMentions) > 0 {
+            header += " " + strings.Join(headerMentions, " ")
+        }
+        msg := header + "\n" + strings.Join(downLines, "\n")
+        if err := postThreadAlert(api, channelID, ref, msg); err != nil {
+            slog.Error("failed to post alert", "err", err)
+        }
+    }
+
+    if len(upLines) > 0 {
+        msg := fmt.Sprintf("%s *%s*\n", opts.theme.UpEmoji, opts.theme.UpTitle) + strings.Join(upLines, "\n")
+        if err := postThreadAlert(api, channelID, ref, msg); err != nil {
+            slog.Error("failed to post alert", "err", err)
+        }
+    }
+}
+
+// postIncidentAlerts posts the down alert of every service whose incident has
+// a thread of its own into that thread, and returns the lines and header
+// mentions of the others, which still go to the board thread.
+func postIncidentAlerts(api *slack.Client, channelID string, down []Transition, lines []string, mentions []string, opts alertOptions) ([]string, []string) {
+	var rest, restMentions []string
+	for i, t := range down {
+		ts, ok := opts.incidentThread(t.Service)
+		if !ok {
+			rest = append(rest, lines[i])
+			if mentions[i] != "" && !slices.Contains(restMentions, mentions[i]) {
+				restMentions = append(restMentions, mentions[i])
+			}
+			continue
+		}
+
+		msg := fmt.Sprintf("%s *%s*", opts.theme.DownEmoji, opts.theme.DownTitle)
+		if mentions[i] != "" {
+			msg += " " + mentions[i]
+		}
+		if err := postIncidentReply(api, channelID, ts, msg+"\n"+lines[i]); err != nil {
+			slog.Error("failed to post alert", "err", err)
+		}
+	}
+	return rest, restMentions
+}
+
+func recoveryLine(t Transition, templates TemplateConfig) string {
+	line := fmt.Sprintf("• *%s*", t.ServiceName)
+	if t.Downtime != "" {
+		line = fmt.Sprintf("• *%s* (was down %s)", t.ServiceName, t.Downtime)
+	}
+
+	data := newMessageData(t.Service)
+	data.Up = true
+	data.Downtime = t.Downtime
+	return renderTemplate(templates.upAlert, data, line)
+}
+
+// sendChannelAlerts posts down alerts as top-level channel messages and
+// remembers their ts per service, so each recovery can reply under the alert
+// that announced the outage.
+func sendChannelAlerts(api *slack.Client, channelID string, down, up []Transition, downLines, headerMentions []string, states map[string]*ServiceState, opts alertOptions) {
+	theme := opts.theme
+	if len(down) > 0 {
+		header := fmt.Sprintf("%s *%s*", theme.DownEmoji, theme.DownTitle)
+		if len(headerMentions) > 0 {
+			header += " " + strings.Join(headerMentions, " ")
+		}
+		_, ts, err := api.PostMessage(channelID, slack.MsgOptionText(header+"\n"+strings.Join(downLines, "\n"), false))
+		if err != nil {
+			slog.Error("failed to post alert", "err", err)
+		} else {
+			for _, t := range down {
+				if state := states[serviceKey(t.Service)]; state != nil {
+					if state.AlertTS == nil {
+						state.AlertTS = make(map[string]string)
+					}
+					state.AlertTS[channelID] = ts
+				}
+			}
+		}
+	}
+
+	// Recoveries are grouped by the alert they answer; those without one
+	// (e.g. the outage started while muted) go to the channel.
+	var threads []string
+	byThread := make(map[string][]string)
+	for _, t := range up {
+		var ts string
+		if state := states[serviceKey(t.Service)]; state != nil {
+			ts = state.AlertTS[channelID]
+			delete(state.AlertTS, channelID)
+		}
+		if _, seen := byThread[ts]; !seen {
+			threads = append(threads, ts)
+		}
+		byThread[ts] = append(byThread[ts], recoveryLine(t, opts.templates))
+	}
+
+	for _, ts := range threads {
+		msg := fmt.Sprintf("%s *%s*\n", theme.UpEmoji, theme.UpTitle) + strings.Join(byThread[ts], "\n")
+		options := []slack.MsgOption{slack.MsgOptionText(msg, false)}
+		if ts != "" {
+			options = append(options, slack.MsgOptionTS(ts))
+		}
+		if _, _, err := api.PostMessage(channelID, options...); err != nil {
+			slog.Error("failed to post alert", "err", err)
+		}
+	}
+}
+
+// updateIncidentThreads gives every incident its own top-level message in the
+// channel and appends status changes and the recovery to that thread, so each
+// outage reads as one narrative. The recovery reply summarises the incident
+// recorded in history. The thread is left on the state after the recovery,
+// for the alerts of the cycle; endIncidents lets go of it.
+func updateIncidentThreads(api *slack.Client, channelID string, transitions []Transition, states map[string]*ServiceState, history *History, theme Theme, clk clock, at time.Time) {
+	for _, t := range transitions {
+		state := states[serviceKey(t.Service)]
+		if state == nil {
+			continue
+		}
+
+		now := clk.format(at)
+
+		switch t.Type {
+		case "down":
+			// A muted service, e.g. one in a blackout, doesn't open a thread.
+			if state.muted(at) {
+				continue
+			}
+			msg := fmt.Sprintf("%s *Incident: %s is down*\n%s  `%s`", theme.DownEmoji, t.ServiceName, now, t.Error)
+			_, ts, err := api.PostMessage(channelID,
+				slack.MsgOptionText(msg, false),
+				slack.MsgOptionBlocks(incidentBlocks(msg, serviceKey(t.Service), t.Service.RunbookURL)...),
+			)
+			if err != nil {
+				slog.Error("failed to open incident thread", "err", err)
+				continue
+			}
+			state.IncidentTS = ts
+		case "change":
+			if state.IncidentTS == "" {
+				continue
+			}
+			msg := fmt.Sprintf("%s  status changed: `%s` → `%s`", now, t.PrevError, t.Error)
+			if err := postIncidentReply(api, channelID, state.IncidentTS, msg); err != nil {
+				slog.Error("failed to update incident thread", "err", err)
+			}
+		case "up":
+			if state.IncidentTS == "" {
+				continue
+			}
+			msg := fmt.Sprintf("%s %s  recovered", theme.UpEmoji, now)
+			if t.Downtime != "" {
+				msg += fmt.Sprintf(" after %s", t.Downtime)
+			}
+			if i := history.lastIncident(serviceKey(t.Service)); i != nil && !i.Open() {
+				msg = incidentSummary(*i, theme)
+			}
+			if err := postIncidentReply(api, channelID, state.IncidentTS, msg); err != nil {
+				slog.Error("failed to update incident thread", "err", err)
+			}
+		}
+	}
+}
+
+// incidentSummary renders a closed incident as the starting point of a
+// postmortem.
+func incidentSummary(i Incident, theme Theme) string {
+	const layout = "2006-01-02 15:04:05 MST"
+
+	ackedBy := "nobody"
+	if i.AckedBy != "" {
+		ackedBy = fmt.Sprintf("%s at %s", i.AckedBy, i.AckedAt.Format(layout))
+	}
+
+	errs := i.Errors
+	if len(errs) == 0 {
+		errs = []string{i.Error}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s *Incident summary: %s (%s)*\n", theme.UpEmoji, i.ServiceName, i.Env)
+	fmt.Fprintf(&sb, "• Started: %s\n", i.StartedAt.Format(layout))
+	fmt.Fprintf(&sb, "• Ended: %s\n", i.EndedAt.Format(layout))
+	fmt.Fprintf(&sb, "• Duration: %s\n", formatDuration(i.EndedAt.Sub(i.StartedAt)))
+	fmt.Fprintf(&sb, "• Errors: `%s`\n", strings.Join(errs, "`, `"))
+	fmt.Fprintf(&sb, "• Failed checks: %d\n", i.FailedChecks)
+	fmt.Fprintf(&sb, "• Acknowledged by: %s", ackedBy)
+	return sb.String()
+}
+
+func postIncidentReply(api *slack.Client, channelID string, incidentTS string, message string) error {
+	_, _, err := api.PostMessage(
+		channelID,
+		slack.MsgOptionText(message, false),
+		slack.MsgOptionTS(incidentTS),
+	)
+	return err
+}
+
+// boardOptions carries the optional parts of the board layout.
+type boardOptions struct {
+	layout        string
+	sort          string
+	foldHealthy   int
+	clock         clock
+	reliability   map[string]Reliability
+	showSparkline bool
+	interactive   bool
+	theme         Theme
+	templates     TemplateConfig
+	problems      []string
+	version       string
+}
+
+func renderServiceLine(r CheckResult, states map[string]*ServiceState, opts boardOptions) string {
+    var emoji, statusText string
+    data := newMessageData(r.Service)
+    data.Up = r.Up
+    data.Error = r.Error
+    if r.Up && r.Service.Source != "" {
+        emoji = opts.theme.UpEmoji
+        statusText = fmt.Sprintf("_via %s_", r.Service.Source)
+    } else if r.Up {
+        emoji = opts.theme.UpEmoji
+        data.Latency = fmt.Sprintf("%dms", r.Latency.Milliseconds())
+        statusText = fmt.Sprintf("`%dms`", r.Latency.Milliseconds())
+        if state := states[serviceKey(r.Service)]; state != nil && len(state.Latencies) > 1 {
+            p50 := percentile(state.Latencies, 50)
+            p95 := percentile(state.Latencies, 95)
+            statusText += fmt.Sprintf("  p50 `%dms` p95 `%dms`", p50.Milliseconds(), p95.Milliseconds())
+            if trend := latencyTrend(state.Latencies); trend != "" {
+                statusText += " " + trend
+            }
+        }
+        if state := states[serviceKey(r.Service)]; state != nil && state.LatencyAnomaly {
+            statusText += fmt.Sprintf("  %s _slow, usually ~%dms_", opts.theme.SlowEmoji, int64(state.LatencyMean))
+        }
+    } else {
+        emoji = opts.theme.DownEmoji
+        key := serviceKey(r.Service)
+        state := states[key]
+        if state != nil && !state.DownSince.IsZero() {
+            downtime := formatDuration(time.Since(state.DownSince))
+            data.Downtime = downtime
+            statusText = fmt.Sprintf("`%s (%s)`", r.Error, downtime)
+        } else {
+            statusText = fmt.Sprintf("`%s`", r.Error)
+        }
+        if r.Service.RunbookURL != "" {
+            statusText += fmt.Sprintf("  <%s|Runbook>", r.Service.RunbookURL)
+        }
+    }
+    if state := states[serviceKey(r.Service)]; opts.showSparkline && state != nil && len(state.Outcomes) > 0 {
+        statusText += fmt.Sprintf("  `%s`", sparkline(state.Outcomes))
+    }
+    if rel, ok := opts.reliability[serviceKey(r.Service)]; ok && rel.Incidents > 0 {
+        statusText += fmt.Sprintf("  MTTR %s · MTBF %s", formatMeanTime(rel.MTTR), formatMeanTime(rel.MTBF))
+    }
+    marker := ""
+    if state := states[serviceKey(r.Service)]; state != nil && state.muted(time.Now()) {
+        marker = " " + opts.theme.MutedEmoji
+    }
+    if inMaintenance(r.Service, time.Now()) {
+        emoji = opts.theme.MaintenanceEmoji
+        statusText = "_under maintenance_  " + statusText
+    }
+    data.Emoji = emoji
+    data.Status = statusText
+    return renderTemplate(opts.templates.boardLine, data, fmt.Sprintf("%s  *%s:*%s %s", emoji, serviceLink(r.Service), marker, statusText))
+}
+
+func renderBoard(results []CheckResult, states map[string]*ServiceState, recent *RecentIncidents, opts boardOptions) []slack.Block {
+    var blocks []slack.Block
+
+    if opts.theme.BoardTitle != "" {
+        blocks = append(blocks, slack.NewHeaderBlock(plainText(opts.theme.BoardTitle)))
+    }
+
+    updateText := fmt.Sprintf("Updated: %s", opts.clock.format(time.Now()))
+    blocks = append(blocks, slack.NewContextBlock(slackboard.StampBlockID,
+        slack.NewTextBlockObject(slack.MarkdownType, updateText, false, false),
+    ))
+
+    if warning := selfWarningBlock(opts.problems, opts.theme); warning != nil {
+        blocks = append(blocks, warning)
+    }
+
+    shown, folded := foldHealthy(sortResults(results, opts.sort), opts.foldHealthy)
+
+    if opts.layout == "flat" {
+        for _, r := range shown {
+            blocks = append(blocks, renderServiceBlock(r, states, opts))
+        }
+        blocks = append(blocks, slack.NewDividerBlock())
+    } else {
+        for _, env := range boardEnvs(shown) {
+            header := "*" + strings.ToUpper(env[:1]) + env[1:] + "*"
+            blocks = append(blocks, slack.NewContextBlock("",
+                slack.NewTextBlockObject(slack.MarkdownType, header, false, false),
+            ))
+            var envResults []CheckResult
+            for _, r := range shown {
+                if r.Service.Env == env {
+                    envResults = append(envResults, r)
+                }
+            }
+            if opts.layout == "compact" {
+                blocks = append(blocks, renderCompactBlocks(envResults, states, opts)...)
+            } else {
+                for _, r := range envResults {
+                    blocks = append(blocks, renderServiceBlock(r, states, opts))
+                }
+            }
+            blocks = append(blocks, slack.NewDividerBlock())
+        }
+    }
+
+    if folded > 0 {
+        foldText := fmt.Sprintf("…and %d more %s", folded, opts.theme.HealthyLabel)
+        blocks = append(blocks, slack.NewContextBlock("",
+            slack.NewTextBlockObject(slack.MarkdownType, foldText, false, false),
+        ))
+    }
+
+    active := withoutMaintenance(results, time.Now())
+    healthy, down := countStatus(active)
+    footerText := fmt.Sprintf("%d %s  •  %d %s", healthy, opts.theme.HealthyLabel, down, opts.theme.DownLabel)
+    if n := len(results) - len(active); n > 0 {
+        footerText += fmt.Sprintf("  •  %d in maintenance", n)
+    }
+
+    recentText := renderRecentIncidents(recent)
+    if recentText != "" {
+        footerText += "\n" + recentText
+    }
+
+    footer := []slack.MixedElement{slack.NewTextBlockObject(slack.MarkdownType, footerText, false, false)}
+    if opts.version != "" {
+        footer = append(footer, slack.NewTextBlockObject(slack.PlainTextType, opts.version, false, false))
+    }
+    blocks = append(blocks, slack.NewContextBlock("", footer...))
+
+    return blocks
+}
+
+// sortResults returns results ordered for display: down services first,
+// alphabetically, or slowest first. Ties keep the configured order.
+func sortResults(results []CheckResult, order string) []CheckResult {
+	sorted := slices.Clone(results)
+	switch order {
+	case "down_first":
+		slices.SortStableFunc(sorted, func(a, b CheckResult) int {
+			return boolOrder(!a.Up, !b.Up)
+		})
+	case "name":
+		slices.SortStableFunc(sorted, func(a, b CheckResult) int {
+			return strings.Compare(strings.ToLower(a.Service.Name), strings.ToLower(b.Service.Name))
+		})
+	case "latency":
+		slices.SortStableFunc(sorted, func(a, b CheckResult) int {
+			return cmp.Compare(b.Latency, a.Latency)
+		})
+	}
+	return sorted
+}
+
+// boolOrder sorts true before false.
+func boolOrder(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	}
+	return 1
+}
+
+// foldHealthy keeps every failing service but at most limit healthy ones,
+// returning how many were left out. A limit of 0 keeps everything.
+func foldHealthy(results []CheckResult, limit int) ([]CheckResult, int) {
+	if limit <= 0 {
+		return results, 0
+	}
+
+	var shown []CheckResult
+	healthy, folded := 0, 0
+	for _, r := range results {
+		if r.Up {
+			healthy++
+			if healthy > limit {
+				folded++
+				continue
+			}
+		}
+		shown = append(shown, r)
+	}
+	return shown, folded
+}
+
+// boardEnvs lists the environments present in results, development and
+// production first, then the others in order of appearance.
+func boardEnvs(results []CheckResult) []string {
+	seen := make(map[string]bool)
+	for _, r := range results {
+		seen[r.Service.Env] = true
+	}
+
+	var envs []string
+	for _, env := range []string{"development", "production"} {
+		if seen[env] {
+			envs = append(envs, env)
+			delete(seen, env)
+		}
+	}
+	for _, r := range results {
+		if env := r.Service.Env; seen[env] && env != "" {
+			envs = append(envs, env)
+			delete(seen, env)
+		}
+	}
+	return envs
+}
+
+func renderServiceBlock(r CheckResult, states map[string]*ServiceState, opts boardOptions) slack.Block {
+	text := renderServiceLine(r, states, opts)
+
+	var accessory *slack.Accessory
+	if opts.interactive {
+		accessory = slack.NewAccessory(muteMenu(serviceKey(r.Service)))
+	}
+
+	return slack.NewSectionBlock(
+		slack.NewTextBlockObject(slack.MarkdownType, text, false, false),
+		nil, accessory,
+	)
+}
+
+// maxSectionFields is Slack's limit on fields per section block.
+const maxSectionFields = 10
+
+// renderCompactBlocks lays services out as two-column section fields, up to
+// ten per block, with only their status and latency or error.
+func renderCompactBlocks(results []CheckResult, states map[string]*ServiceState, opts boardOptions) []slack.Block {
+	var blocks []slack.Block
+	for i := 0; i < len(results); i += maxSectionFields {
+		var fields []*slack.TextBlockObject
+		for _, r := range results[i:min(i+maxSectionFields, len(results))] {
+			fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, compactServiceLine(r, states, opts), false, false))
+		}
+		blocks = append(blocks, slack.NewSectionBlock(nil, fields, nil))
+	}
+	return blocks
+}
+
+// serviceLink renders the service name as a link to its dashboard, or to the
+// checked URL when it has none.
+func serviceLink(svc Service) string {
+	target := svc.DashboardURL
+	if target == "" {
+		target = svc.URL
+	}
+	if target == "" {
+		return svc.Name
+	}
+	return fmt.Sprintf("<%s|%s>", target, svc.Name)
+}
+
+func compactServiceLine(r CheckResult, states map[string]*ServiceState, opts boardOptions) string {
+	now := time.Now()
+	emoji, status := opts.theme.UpEmoji, fmt.Sprintf("`%dms`", r.Latency.Milliseconds())
+	if !r.Up {
+		emoji, status = opts.theme.DownEmoji, fmt.Sprintf("`%s`", r.Error)
+	}
+	if inMaintenance(r.Service, now) {
+		emoji = opts.theme.MaintenanceEmoji
+	}
+
+	line := fmt.Sprintf("%s *%s* %s", emoji, serviceLink(r.Service), status)
+	if state := states[serviceKey(r.Service)]; state != nil && state.muted(now) {
+		line += " " + opts.theme.MutedEmoji
+	}
+	return line
+}
+
+func renderRecentIncidents(recent *RecentIncidents) string {
+	incidents := recent.list()
+	if len(incidents) == 0 {
+		return ""
+	}
+
+	if len(incidents) == 1 {
+		i := incidents[0]
+		ago := formatDuration(time.Since(i.OccurredAt))
+		return fmt.Sprintf("Last incident: %s, %s ago (down %s)", i.ServiceName, ago, i.Duration)
+	}
+
+	lines := []string{"Recent incidents:"}
+	for _, i := range incidents {
+		ago := formatDuration(time.Since(i.OccurredAt))
+		lines = append(lines, fmt.Sprintf("• %s, %s ago (down %s)", i.ServiceName, ago, i.Duration))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type Bot struct {
+	api       *slack.Client
+	client    *http.Client
+	cfg       Config
+	channelID string
+	boards    []BoardConfig
+
+	// pool runs the checks of every cycle. Without one, a cycle starts a
+	// pool of its own.
+	pool *checker.Pool
+
+	// loops checks each service on its own timer while the bot runs as a
+	// daemon; nil when cycles check every service themselves, as once does.
+	loops *serviceLoops
+
+	// confirm runs the confirmation probes; nil when they are disabled.
+	confirm *http.Client
+
+	// dryRun receives what notifiers would have sent during a dry run; nil
+	// otherwise.
+	dryRun io.Writer
+
+	// interactive is set when Socket Mode is running, so buttons and menus
+	// on our messages have someone to answer them.
+	interactive bool
+
+	// cycleMu serializes cycles, so a refresh requested from Slack doesn't
+	// overlap with a scheduled one.
+	cycleMu sync.Mutex
+
+	// configPath is the config file that service changes made from Slack
+	// are written back to.
+	configPath string
+
+	// servicesMu guards cfg.Services for readers outside a cycle. Writers
+	// hold mu as well, so code running under mu may read it directly.
+	servicesMu sync.RWMutex
+
+	// mu guards the fields below, which are shared with the HTTP API.
+	mu         sync.Mutex
+	results    []CheckResult
+	states     map[string]*ServiceState
+	recent     *RecentIncidents
+	history    *History
+	backup     *objectStore
+	lastBackup time.Time
+	topics     map[string]postedTopic
+
+	// boardChecks and unavailable track, per board name, when its message
+	// was last verified and whether its channel is currently unusable.
+	boardChecks map[string]time.Time
+	unavailable map[string]bool
+
+	// alertGroups holds alerts per destination during the grouping window.
+	alertGroups map[string]*alertGroup
+
+	// profileAPI acts as the user whose status mirrors overall health.
+	profileAPI    *slack.Client
+	profileStatus string
+
+	onCall *onCallResolver
+
+	// notifiers receive every cycle after Slack.
+	notifiers []Notifier
+
+	sms    *twilioClient
+	github *githubClient
+	jira   *jiraClient
+
+	metrics metrics
+	audit   *auditLog
+	statsd  *statsdClient
+	push    *pushgatewayClient
+	self    selfMonitor
+	events  eventHub
+
+	// external holds the status pushed for services with a source.
+	external externalStatus
+
+	// slackAuthed is set once the bot token passed an auth test.
+	slackAuthed atomic.Bool
+
+	// standby is set while another replica holds the leader lease.
+	standby atomic.Bool
+	// leader is the identity of the lease holder last seen, for pointing
+	// writes sent to a standby at it.
+	leader atomic.Value
+}
+
+// persistedFiles lists the local files that carry state across restarts.
+func persistedFiles(cfg Config, boards []BoardConfig) []string {
+	files := []string{cfg.StateFile}
+	for _, bc := range boards {
+		files = append(files, bc.ref.Path())
+	}
+	if cfg.Discord.Enabled {
+		files = append(files, discordBoardPath)
+	}
+	if cfg.Telegram.Enabled {
+		files = append(files, telegramBoardPath)
+	}
+	return files
+}
+
+// persist saves the state file and, when enabled and due, mirrors it to
+// object storage.
+func (b *Bot) persist(ctx context.Context, now time.Time, force bool) {
+	b.external.save(b.states, now)
+	if err := saveState(b.cfg.StateFile, b.states, b.history); err != nil {
+		slog.Error("failed to save state", "err", err)
+	}
+
+	if b.backup == nil {
+		return
+	}
+
+	interval := time.Duration(b.cfg.Backup.IntervalSeconds) * time.Second
+	if !force && now.Sub(b.lastBackup) < interval {
+		return
+	}
+
+	if err := backupFiles(ctx, b.backup, persistedFiles(b.cfg, b.boards)); err != nil {
+		slog.Error("failed to back up state", "err", err)
+		return
+	}
+	b.lastBackup = now
+}
+
+// boardBlocks renders the board for results with the configured options.
+func (b *Bot) boardBlocks(results []CheckResult, bc BoardConfig, now time.Time) []slack.Block {
+	opts := boardOptions{
+		layout:        bc.Layout,
+		sort:          bc.Sort,
+		foldHealthy:   bc.FoldHealthy,
+		clock:         b.cfg.clock,
+		showSparkline: b.cfg.ShowSparkline,
+		interactive:   b.interactive,
+		theme:         b.cfg.Theme,
+		templates:     b.cfg.Templates,
+		problems:      b.self.current(),
+		version:       currentBuild().footer(),
+	}
+
+	if b.cfg.ShowReliability {
+		opts.reliability = make(map[string]Reliability)
+		for _, svc := range b.cfg.Services {
+			key := serviceKey(svc)
+			opts.reliability[key] = b.history.reliability(key, now.Add(-historyRetention), now)
+		}
+	}
+
+	return renderBoard(results, b.states, b.recent, opts)
+}
+
+// unmuted drops the transitions of services whose alerts are muted.
+func unmuted(transitions []Transition, states map[string]*ServiceState, now time.Time) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		if state := states[serviceKey(t.Service)]; state != nil && state.muted(now) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (b *Bot) runCycle(ctx context.Context) error {
+	b.cycleMu.Lock()
+	defer b.cycleMu.Unlock()
+
+	started := time.Now()
+	b.testSlackAuth(ctx)
+	spread := time.Duration(b.cfg.CheckSpreadSeconds) * time.Second
+	jitter := time.Duration(b.cfg.CheckJitterMs) * time.Millisecond
+	var results, checked []CheckResult
+	if b.loops != nil {
+		results, checked = b.loopResults(ctx, spread, jitter)
+	} else {
+		results = b.checkServices(ctx, spread, jitter)
+		b.confirmFailures(ctx, results)
+		checked = results
+	}
+	for _, r := range checked {
+		logCheck(r)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	// Services under maintenance are still shown on the board, but their
+	// results don't drive alerts, sparklines or uptime.
+	tracked := withoutMaintenance(checked, now)
+	transitions := detectTransitions(tracked, b.states, b.cfg.RecoveryThreshold)
+	recordLatencies(tracked, b.states)
+	recordOutcomes(tracked, b.states)
+	anomalies := detectAnomalies(tracked, b.states, b.cfg.LatencyAnomaly)
+	b.metrics.recordChecks(results, transitions, b.states)
+	b.audit.record(now, results, transitions)
+	b.statsd.record(tracked, transitions)
+
+	// Everything below reports status, so services still short of the
+	// recovery threshold keep showing as down.
+	results = holdRecovering(results, b.states, now)
+	b.results = results
+
+	b.history.record(tracked, transitions, now)
+	b.history.prune(now)
+	defer b.persist(ctx, now, false)
+
+	for _, t := range transitions {
+		if t.Type == "up" && t.Downtime != "" {
+			b.recent.add(RecentIncident{
+				ServiceName: t.ServiceName,
+				OccurredAt:  now,
+				Duration:    t.Downtime,
+			})
+		}
+	}
+
+	// Incident threads are opened first, so the alerts of the cycle can go
+	// to them.
+	alerts := b.rateLimited(unmuted(b.scheduledAlerts(transitions, now), b.states, now), now)
+	b.threadIncidents(alerts, now)
+	err := b.notify(ctx, Cycle{
+		At:          now,
+		Results:     results,
+		Transitions: alerts,
+		History:     b.history,
+	})
+	b.endIncidents(transitions)
+
+	b.events.publishCycle(now, results, transitions)
+	b.sendAnomalyWarnings(anomalies, now)
+	b.sendSLAAlerts(now)
+	b.updateTopics(results, now)
+	b.updateProfileStatus(results, now)
+
+	b.sendEscalations(now)
+	b.sendSMS(ctx, now)
+	b.syncGitHubIssues(ctx, now)
+	b.syncJiraTickets(ctx, now)
+	b.sendReminders(now)
+	b.sendQuietSummary(now)
+	b.sendAnnouncements(now)
+
+	b.sendDigestIfDue(now)
+
+	sendReports(b.api, b.cfg.Reports, b.defaultChannel(), b.history, b.cfg.Services, now)
+
+	took := time.Since(started)
+	b.metrics.recordCycle(now, took, err)
+	b.push.push(ctx, &b.metrics)
+	b.self.recordCycle(took, time.Duration(b.cfg.IntervalSeconds)*time.Second)
+	b.alertSelf(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("board updated")
+	return nil
+}
+
+// defaultChannel is where bot-wide messages like digests and reports go when
+// they don't name a channel of their own.
+func (b *Bot) defaultChannel() string {
+	if b.channelID != "" {
+		return b.channelID
+	}
+	return b.boards[0].Channel
+}
+
+// threadIncidents updates the incident threads of every channel with alerts,
+// the transitions that made it through the alert filters, so muted, out of
+// hours and rate limited services don't post there either. Callers hold
+// b.mu.
+func (b *Bot) threadIncidents(alerts []Transition, now time.Time) {
+	for _, ch := range b.incidentChannels() {
+		var channelAlerts []Transition
+		for _, t := range alerts {
+			if primaryChannel(b.boards, t.Service) == ch {
+				channelAlerts = append(channelAlerts, t)
+			}
+		}
+		updateIncidentThreads(b.api, ch, channelAlerts, b.states, b.history, b.cfg.Theme, b.cfg.clock, now)
+	}
+}
+
+// endIncidents lets go of the threads of the services that recovered, once
+// the alerts of the cycle are out. A recovery held back by the filters still
+// ends its incident, so the next outage gets a thread of its own. Callers
+// hold b.mu.
+func (b *Bot) endIncidents(transitions []Transition) {
+	for _, t := range transitions {
+		if state := b.states[serviceKey(t.Service)]; t.Type == "up" && state != nil {
+			state.IncidentTS = ""
+		}
+	}
+}
+
+// incidentChannels lists the distinct channels that host incident threads.
+func (b *Bot) incidentChannels() []string {
+	var channels []string
+	for _, bc := range b.boards {
+		if !slices.Contains(channels, bc.Channel) {
+			channels = append(channels, bc.Channel)
+		}
+	}
+	return channels
+}
+
+// run starts the bot with the options given on the command line.
+func run(ctx context.Context, opts runOptions) error {
+	token, err := opts.slackToken()
+	if err != nil {
+		return err
+	}
+
+	channelID := opts.slackChannel()
+
+	configPath := "services.json"
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := opts.overrideConfig(&cfg); err != nil {
+		return err
+	}
+
+	boards := resolveBoards(cfg, channelID)
+	if err := checkBoards(cfg, boards); err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		cfg = dryRunConfig(cfg)
+		dir, err := dryRunScratch(&cfg, boards)
+		defer os.RemoveAll(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	// With HA the leader lease keeps replicas apart, and a dry run doesn't
+	// touch the boards.
+	if !opts.dryRun && !cfg.HA.Enabled {
+		lock, err := lockChannels(os.TempDir(), boardChannels(boards))
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+	}
+
+	slog.Info("loaded config", "services", len(cfg.Services), "interval", time.Duration(cfg.IntervalSeconds)*time.Second, "version", currentBuild().String())
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var backup *objectStore
+	if cfg.Backup.Enabled {
+		backup, err = newObjectStore(cfg.Backup)
+		if err != nil {
+			return fmt.Errorf("init backup: %w", err)
+		}
+		if err := restoreFiles(ctx, backup, persistedFiles(cfg, boards), false); err != nil {
+			slog.Error("failed to restore backup", "err", err)
+		}
+	}
+
+	states, history, err := loadState(cfg.StateFile)
+	if err != nil {
+		return fmt.Errorf("load state: %w", err)
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: cfg.Concurrency,
+		IdleConnTimeout:     90 * time.Second,
+		DialContext:         checkDialer(cfg.DNS),
+	}
+
+	var slackTransport http.RoundTripper = debugTransport{base: http.DefaultTransport}
+	if opts.dryRun {
+		slackTransport = &dryRunTransport{base: slackTransport, out: os.Stdout}
+	}
+	slackHTTP := slack.OptionHTTPClient(&http.Client{
+		Timeout:   time.Minute,
+		Transport: newRetryTransport(slackTransport),
+	})
+	apiOptions := []slack.Option{slackHTTP}
+	appToken := os.Getenv("SLACK_APP_TOKEN")
+	if appToken != "" {
+		apiOptions = append(apiOptions, slack.OptionAppLevelToken(appToken))
+	}
+
+	bot := &Bot{
+		metrics: metrics{started: time.Now()},
+		api: slack.New(token, apiOptions...),
+		client: &http.Client{
+			Transport: transport,
+		},
+		cfg:         cfg,
+		configPath:  configPath,
+		channelID:   channelID,
+		boards:      boards,
+		interactive: appToken != "",
+		states:     states,
+		recent:     recentFromHistory(history, cfg.RecentIncidents),
+		history:    history,
+		backup:     backup,
+		lastBackup: time.Now(),
+		pool:       checker.NewPool(cfg.Concurrency),
+	}
+	defer bot.pool.Close()
+	bot.external.restore(states)
+
+	if opts.dryRun {
+		bot.dryRun = os.Stdout
+	}
+
+	if cfg.Confirmation.Enabled {
+		bot.confirm = newConfirmationClient(cfg.Confirmation, time.Duration(cfg.TimeoutMs)*time.Millisecond)
+	}
+
+	if cfg.OnCall.enabled() {
+		bot.onCall, err = newOnCallResolver(cfg.OnCall, bot.api)
+		if err != nil {
+			return fmt.Errorf("init on-call lookup: %w", err)
+		}
+	}
+
+	for _, wh := range cfg.Webhooks {
+		bot.register(newWebhookNotifier(wh))
+	}
+
+	if cfg.Discord.Enabled {
+		discord, err := newDiscordNotifier(cfg.Discord, cfg.Theme)
+		if err != nil {
+			return fmt.Errorf("init discord: %w", err)
+		}
+		bot.register(discord)
+	}
+
+	if cfg.Teams.Enabled {
+		teams, err := newTeamsNotifier(cfg.Theme)
+		if err != nil {
+			return fmt.Errorf("init teams: %w", err)
+		}
+		bot.register(teams)
+	}
+
+	if cfg.Email.Enabled {
+		bot.register(newEmailNotifier(cfg.Email, cfg.Digest.Time))
+	}
+
+	if cfg.Telegram.Enabled {
+		telegram, err := newTelegramNotifier(cfg.Telegram, cfg.Theme)
+		if err != nil {
+			return fmt.Errorf("init telegram: %w", err)
+		}
+		bot.register(telegram)
+	}
+
+	if cfg.Ntfy.Enabled {
+		bot.register(newNtfyNotifier(cfg.Ntfy))
+	}
+
+	if cfg.Pushover.Enabled {
+		pushover, err := newPushoverNotifier(cfg.Pushover)
+		if err != nil {
+			return fmt.Errorf("init pushover: %w", err)
+		}
+		bot.register(pushover)
+	}
+
+	if cfg.Statuspage.Enabled {
+		statuspage, err := newStatuspageNotifier(cfg.Statuspage)
+		if err != nil {
+			return fmt.Errorf("init statuspage: %w", err)
+		}
+		bot.register(statuspage)
+	}
+
+	if cfg.Grafana.Enabled {
+		grafana, err := newGrafanaNotifier(cfg.Grafana)
+		if err != nil {
+			return fmt.Errorf("init grafana: %w", err)
+		}
+		bot.register(grafana)
+	}
+
+	if cfg.GitHubIssues.Enabled {
+		bot.github, err = newGitHubClient(cfg.GitHubIssues)
+		if err != nil {
+			return fmt.Errorf("init github issues: %w", err)
+		}
+	}
+
+	if cfg.Jira.Enabled {
+		bot.jira, err = newJiraClient(cfg.Jira)
+		if err != nil {
+			return fmt.Errorf("init jira: %w", err)
+		}
+	}
+
+	if cfg.AuditLog.Enabled {
+		bot.audit, err = openAuditLog(cfg.AuditLog)
+		if err != nil {
+			return err
+		}
+		defer bot.audit.Close()
+	}
+
+	if cfg.StatsD.Enabled {
+		bot.statsd, err = newStatsDClient(cfg.StatsD)
+		if err != nil {
+			return err
+		}
+		defer bot.statsd.Close()
+	}
+
+	if cfg.Pushgateway.Enabled {
+		bot.push = newPushgatewayClient(cfg.Pushgateway)
+	}
+
+	if cfg.SMS.Enabled {
+		bot.sms, err = newTwilioClient()
+		if err != nil {
+			return fmt.Errorf("init sms: %w", err)
+		}
+	}
+
+	if userToken := os.Getenv("SLACK_USER_TOKEN"); userToken != "" {
+		bot.profileAPI = slack.New(userToken, slackHTTP)
+	}
+
+	if opts.once {
+		return bot.runOnce(ctx, os.Stdout)
+	}
+
+	if cfg.HTTPAddr != "" {
+		go func() {
+			if err := bot.serveHTTP(ctx, cfg.HTTPAddr); err != nil {
+				slog.Error("http server error", "err", err)
+			}
+		}()
+	}
+
+	if err := sdNotify("READY=1"); err != nil {
+		slog.Error("failed to notify systemd", "err", err)
+	}
+	if timeout, ok := watchdogInterval(); ok {
+		go bot.runWatchdog(ctx, timeout)
+	}
+
+	if cfg.HA.Enabled {
+		elector, err := newElector(cfg.HA, backup)
+		if err != nil {
+			return fmt.Errorf("init leader election: %w", err)
+		}
+		elector.seen = func(holder string) { bot.leader.Store(holder) }
+		bot.standby.Store(true)
+		elector.run(ctx, func(lost <-chan struct{}) {
+			bot.takeOver(ctx)
+			bot.standby.Store(false)
+			bot.runCycles(ctx, lost)
+			bot.standby.Store(true)
+		})
+		return nil
+	}
+
+	bot.runCycles(ctx, nil)
+	return nil
+}
+
+func main() {
+	if err := setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	reporter, err := setupSentry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer reporter.recoverPanic()
+
+	opts, args, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		// flag has printed the problem and the usage. Exit code 2 is
+		// taken by once for services down.
+		os.Exit(1)
+	}
+
+	if isWindowsService() {
+		if err := runService(opts); err != nil {
+			slog.Error("fatal error", "err", err)
+			reporter.Close()
+			os.Exit(1)
+		}
+		reporter.Close()
+		return
+	}
+
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "":
+	case "once":
+		opts.once = true
+	case "version":
+		fmt.Println(currentBuild())
+		return
+	case "preview":
+		if err := runPreview(context.Background(), opts, args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "service":
+		if err := controlService(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", command)
+		os.Exit(1)
+	}
+
+	if err := run(context.Background(), opts); err != nil {
+		if errors.Is(err, errServicesDown) {
+			slog.Warn("check run finished", "result", err)
+			reporter.Close()
+			os.Exit(exitDown)
+		}
+		slog.Error("fatal error", "err", err)
+		reporter.Close()
 		os.Exit(1)
 	}
+	reporter.Close()
 }
@@ -1,7 +1,16 @@
 package main
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
 )
 
 func TestDetectTransitions_NoAlertBefore4Failures(t *testing.T) {
@@ -12,7 +21,7 @@ func TestDetectTransitions_NoAlertBefore4Failures(t *testing.T) {
 	}
 
 	for i := range 3 {
-		transitions := detectTransitions(results, states)
+		transitions := detectTransitions(results, states, 1)
 		if len(transitions) != 0 {
 			t.Errorf("cycle %d: expected 0 transitions, got %d", i+1, len(transitions))
 		}
@@ -28,7 +37,7 @@ func TestDetectTransitions_AlertAfter4Failures(t *testing.T) {
 
 	var transitions []Transition
 	for range failThreshold {
-		transitions = detectTransitions(results, states)
+		transitions = detectTransitions(results, states, 1)
 	}
 
 	if len(transitions) != 1 {
@@ -56,10 +65,10 @@ func TestDetectTransitions_NoDoubleAlert(t *testing.T) {
 	}
 
 	for range failThreshold {
-		detectTransitions(results, states)
+		detectTransitions(results, states, 1)
 	}
 
-	transitions := detectTransitions(results, states)
+	transitions := detectTransitions(results, states, 1)
 	if len(transitions) != 0 {
 		t.Errorf("expected 0 transitions after already alerting, got %d", len(transitions))
 	}
@@ -73,14 +82,14 @@ func TestDetectTransitions_RecoveryAlert(t *testing.T) {
 	}
 
 	for range failThreshold {
-		detectTransitions(downResults, states)
+		detectTransitions(downResults, states, 1)
 	}
 
 	upResults := []CheckResult{
 		{Service: Service{Name: "api", Env: "production"}, Up: true},
 	}
 
-	transitions := detectTransitions(upResults, states)
+	transitions := detectTransitions(upResults, states, 1)
 
 	if len(transitions) != 1 {
 		t.Fatalf("expected 1 transition, got %d", len(transitions))
@@ -101,13 +110,13 @@ func TestDetectTransitions_ResetCounterOnSuccess(t *testing.T) {
 		{Service: Service{Name: "api", Env: "production"}, Up: true},
 	}
 
-	detectTransitions(downResults, states)
-	detectTransitions(downResults, states)
+	detectTransitions(downResults, states, 1)
+	detectTransitions(downResults, states, 1)
 
-	detectTransitions(upResults, states)
+	detectTransitions(upResults, states, 1)
 
-	detectTransitions(downResults, states)
-	transitions := detectTransitions(downResults, states)
+	detectTransitions(downResults, states, 1)
+	transitions := detectTransitions(downResults, states, 1)
 
 	if len(transitions) != 0 {
 		t.Errorf("expected 0 transitions (counter was reset), got %d", len(transitions))
@@ -124,7 +133,7 @@ func TestDetectTransitions_MultipleServices(t *testing.T) {
 
 	var transitions []Transition
 	for range failThreshold {
-		transitions = detectTransitions(results, states)
+		transitions = detectTransitions(results, states, 1)
 	}
 
 	if len(transitions) != 1 {
@@ -146,7 +155,7 @@ func TestDetectTransitions_SameNameDifferentEnv(t *testing.T) {
 
 	var transitions []Transition
 	for range failThreshold {
-		transitions = detectTransitions(results, states)
+		transitions = detectTransitions(results, states, 1)
 	}
 
 	if len(transitions) != 1 {
@@ -157,3 +166,371 @@ func TestDetectTransitions_SameNameDifferentEnv(t *testing.T) {
 		t.Errorf("expected service 'api (production)', got '%s'", transitions[0].ServiceName)
 	}
 }
+
+func TestPercentile(t *testing.T) {
+	samples := []time.Duration{
+		50 * time.Millisecond, 10 * time.Millisecond, 40 * time.Millisecond,
+		20 * time.Millisecond, 30 * time.Millisecond,
+	}
+
+	if got := percentile(samples, 50); got != 30*time.Millisecond {
+		t.Errorf("expected p50 30ms, got %v", got)
+	}
+
+	if got := percentile(samples, 95); got != 50*time.Millisecond {
+		t.Errorf("expected p95 50ms, got %v", got)
+	}
+
+	if samples[0] != 50*time.Millisecond {
+		t.Errorf("percentile must not reorder its input")
+	}
+}
+
+func TestLatencyTrend(t *testing.T) {
+	flat := []time.Duration{100, 100, 100, 100}
+	if got := latencyTrend(flat); got != "" {
+		t.Errorf("expected no trend, got '%s'", got)
+	}
+
+	rising := []time.Duration{100, 100, 200, 200}
+	if got := latencyTrend(rising); got != "↑" {
+		t.Errorf("expected '↑', got '%s'", got)
+	}
+
+	falling := []time.Duration{200, 200, 100, 100}
+	if got := latencyTrend(falling); got != "↓" {
+		t.Errorf("expected '↓', got '%s'", got)
+	}
+}
+
+func TestRecordLatencies_KeepsWindow(t *testing.T) {
+	states := make(map[string]*ServiceState)
+	results := []CheckResult{
+		{Service: Service{Name: "api", Env: "production"}, Up: true, Latency: time.Millisecond},
+	}
+
+	for range latencyWindow + 5 {
+		recordLatencies(results, states)
+	}
+
+	if got := len(states["api:production"].Latencies); got != latencyWindow {
+		t.Errorf("expected %d samples, got %d", latencyWindow, got)
+	}
+}
+
+func TestDetectTransitions_ErrorChangeWhileDown(t *testing.T) {
+	states := make(map[string]*ServiceState)
+
+	results := []CheckResult{
+		{Service: Service{Name: "api", Env: "production"}, Up: false, Error: "http_503"},
+	}
+
+	for range failThreshold {
+		detectTransitions(results, states, 1)
+	}
+
+	results[0].Error = "request failed"
+	transitions := detectTransitions(results, states, 1)
+
+	if len(transitions) != 1 {
+		t.Fatalf("expected 1 transition, got %d", len(transitions))
+	}
+
+	if transitions[0].Type != "change" {
+		t.Errorf("expected transition type 'change', got '%s'", transitions[0].Type)
+	}
+
+	if transitions[0].PrevError != "http_503" || transitions[0].Error != "request failed" {
+		t.Errorf("expected http_503 -> request failed, got %s -> %s", transitions[0].PrevError, transitions[0].Error)
+	}
+
+	transitions = detectTransitions(results, states, 1)
+	if len(transitions) != 0 {
+		t.Errorf("expected 0 transitions for an unchanged error, got %d", len(transitions))
+	}
+}
+
+func TestRecentIncidents_RingBuffer(t *testing.T) {
+	recent := newRecentIncidents(2)
+
+	if len(recent.list()) != 0 {
+		t.Fatalf("expected an empty buffer")
+	}
+
+	recent.add(RecentIncident{ServiceName: "a"})
+	recent.add(RecentIncident{ServiceName: "b"})
+	recent.add(RecentIncident{ServiceName: "c"})
+
+	list := recent.list()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 incidents, got %d", len(list))
+	}
+
+	if list[0].ServiceName != "c" || list[1].ServiceName != "b" {
+		t.Errorf("expected newest first [c b], got [%s %s]", list[0].ServiceName, list[1].ServiceName)
+	}
+}
+
+func TestRecordOutcomes_Sparkline(t *testing.T) {
+	states := make(map[string]*ServiceState)
+	svc := Service{Name: "api", Env: "production"}
+
+	for i := range sparklineSlots + 2 {
+		recordOutcomes([]CheckResult{{Service: svc, Up: i%3 != 0}}, states)
+	}
+
+	outcomes := states["api:production"].Outcomes
+	if len(outcomes) != sparklineSlots {
+		t.Fatalf("expected %d outcomes, got %d", sparklineSlots, len(outcomes))
+	}
+
+	if got := sparkline(outcomes[:4]); got != "▁█▁▁" {
+		t.Errorf("expected '▁█▁▁', got '%s'", got)
+	}
+}
+
+func TestFormatMention(t *testing.T) {
+	cases := map[string]string{
+		"U123":          "<@U123>",
+		"W123":          "<@W123>",
+		"S456":          "<!subteam^S456>",
+		"here":          "<!here>",
+		"@channel":      "<!channel>",
+		"<!subteam^S1>": "<!subteam^S1>",
+		"":              "",
+	}
+
+	for in, want := range cases {
+		if got := formatMention(in); got != want {
+			t.Errorf("formatMention(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOwnerMentions(t *testing.T) {
+	svc := Service{Name: "api", Owners: []string{"U1", "S2"}}
+	if got := ownerMentions(svc); got != "<@U1> <!subteam^S2>" {
+		t.Errorf("unexpected mentions %q", got)
+	}
+}
+
+func TestPolicyMention(t *testing.T) {
+	policy := map[string]string{"default": "channel", "development": "none", "staging": "S123"}
+
+	cases := map[string]string{
+		"production":  "<!channel>",
+		"development": "",
+		"staging":     "<!subteam^S123>",
+	}
+	for env, want := range cases {
+		if got := policyMention(policy, env); got != want {
+			t.Errorf("policyMention(%q) = %q, want %q", env, got, want)
+		}
+	}
+
+	if got := policyMention(nil, "production"); got != "<!here>" {
+		t.Errorf("expected @here by default, got %q", got)
+	}
+}
+
+// newTestSlack starts a fake Slack Web API that answers every method with a
+// fresh message ts and records the form of each request.
+func newTestSlack(t *testing.T) (*slack.Client, func() []url.Values) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []url.Values
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		form := r.Form
+		form.Set("method", r.URL.Path[1:])
+		calls = append(calls, form)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":"1700000000.%06d","permalink":"https://example.slack.com/archives/%s/p%d"}`,
+			form.Get("channel"), len(calls), form.Get("channel"), len(calls))
+	}))
+	t.Cleanup(srv.Close)
+
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+	return api, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]url.Values(nil), calls...)
+	}
+}
+
+func TestChannelAlertsThreadRecoveries(t *testing.T) {
+	api, calls := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production"}
+	states := map[string]*ServiceState{serviceKey(svc): {}}
+	opts := alertOptions{theme: defaultTheme, placement: placementChannel, mentions: map[string]string{"default": "none"}}
+
+	sendAlerts(api, "C1", nil, []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "http_503"}}, states, opts)
+
+	got := calls()
+	if len(got) != 1 || got[0].Get("thread_ts") != "" {
+		t.Fatalf("expected one top-level down alert, got %v", got)
+	}
+	alertTS := states[serviceKey(svc)].AlertTS["C1"]
+	if alertTS == "" {
+		t.Fatalf("expected the alert ts to be remembered")
+	}
+
+	sendAlerts(api, "C1", nil, []Transition{{Service: svc, ServiceName: "api (production)", Type: "up", Downtime: "5m"}}, states, opts)
+
+	got = calls()
+	if len(got) != 2 || got[1].Get("thread_ts") != alertTS {
+		t.Fatalf("expected the recovery to reply under %s, got %v", alertTS, got)
+	}
+	if _, ok := states[serviceKey(svc)].AlertTS["C1"]; ok {
+		t.Errorf("alert ts should be forgotten after the recovery")
+	}
+}
+
+func TestRenderCompactBlocks(t *testing.T) {
+	var results []CheckResult
+	for i := range 12 {
+		results = append(results, CheckResult{Service: Service{Name: fmt.Sprintf("svc%d", i), Env: "production"}, Up: i != 3, Error: "timeout"})
+	}
+
+	blocks := renderCompactBlocks(results, nil, boardOptions{theme: defaultTheme})
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 section blocks for 12 services, got %d", len(blocks))
+	}
+	first := blocks[0].(*slack.SectionBlock)
+	if len(first.Fields) != maxSectionFields {
+		t.Errorf("expected a full first block, got %d fields", len(first.Fields))
+	}
+	if got := first.Fields[3].Text; got != "🔴 *svc3* `timeout`" {
+		t.Errorf("unexpected compact line %q", got)
+	}
+}
+
+func TestServiceLink(t *testing.T) {
+	tests := []struct {
+		svc  Service
+		want string
+	}{
+		{Service{Name: "api"}, "api"},
+		{Service{Name: "api", URL: "https://api.example.com/health"}, "<https://api.example.com/health|api>"},
+		{Service{Name: "api", URL: "https://api.example.com/health", DashboardURL: "https://grafana/d/api"}, "<https://grafana/d/api|api>"},
+	}
+	for _, tt := range tests {
+		if got := serviceLink(tt.svc); got != tt.want {
+			t.Errorf("serviceLink(%+v) = %q, want %q", tt.svc, got, tt.want)
+		}
+	}
+}
+
+func TestSortAndFoldResults(t *testing.T) {
+	results := []CheckResult{
+		{Service: Service{Name: "web"}, Up: true, Latency: 20 * time.Millisecond},
+		{Service: Service{Name: "auth"}, Up: false},
+		{Service: Service{Name: "api"}, Up: true, Latency: 90 * time.Millisecond},
+		{Service: Service{Name: "db"}, Up: true, Latency: 40 * time.Millisecond},
+	}
+
+	names := func(results []CheckResult) string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Service.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		order string
+		want  string
+	}{
+		{"", "web,auth,api,db"},
+		{"down_first", "auth,web,api,db"},
+		{"name", "api,auth,db,web"},
+		{"latency", "api,db,web,auth"},
+	}
+	for _, tt := range tests {
+		if got := names(sortResults(results, tt.order)); got != tt.want {
+			t.Errorf("sort %q: got %s, want %s", tt.order, got, tt.want)
+		}
+	}
+
+	shown, folded := foldHealthy(sortResults(results, "down_first"), 1)
+	if names(shown) != "auth,web" || folded != 2 {
+		t.Errorf("expected auth,web with 2 folded, got %s with %d", names(shown), folded)
+	}
+}
+
+func TestDetectTransitions_RecoveryThreshold(t *testing.T) {
+	states := make(map[string]*ServiceState)
+	svc := Service{Name: "api", Env: "production"}
+	down := []CheckResult{{Service: svc, Up: false, Error: "http_503"}}
+	up := []CheckResult{{Service: svc, Up: true}}
+
+	for range failThreshold {
+		detectTransitions(down, states, 3)
+	}
+
+	detectTransitions(up, states, 3)
+	if transitions := detectTransitions(up, states, 3); len(transitions) != 0 {
+		t.Fatalf("expected no recovery after 2 of 3 passes, got %+v", transitions)
+	}
+
+	shown := holdRecovering(up, states, time.Now())
+	if shown[0].Up || shown[0].Error != "http_503 (recovering)" {
+		t.Errorf("expected the board to keep showing the service as down, got %+v", shown[0])
+	}
+	if !up[0].Up {
+		t.Errorf("holdRecovering must not modify its input")
+	}
+
+	// A failure in between starts the count over.
+	detectTransitions(down, states, 3)
+	detectTransitions(up, states, 3)
+	detectTransitions(up, states, 3)
+	transitions := detectTransitions(up, states, 3)
+	if len(transitions) != 1 || transitions[0].Type != "up" {
+		t.Fatalf("expected a recovery after 3 passes in a row, got %+v", transitions)
+	}
+
+	if shown := holdRecovering(up, states, time.Now()); !shown[0].Up {
+		t.Errorf("expected a recovered service to show as up")
+	}
+}
+
+func TestTarget(t *testing.T) {
+	cfg := Config{TimeoutMs: 5000}
+	svc := Service{Name: "api", Env: "production", URL: "https://api", Retries: 2, RetryDelayMs: 100}
+
+	got := target(svc, cfg)
+	if got.Timeout != 5*time.Second || got.Retries != 2 || got.RetryDelay != 100*time.Millisecond || got.Key() != serviceKey(svc) {
+		t.Errorf("unexpected target %+v", got)
+	}
+	svc.TimeoutMs = 10000
+	if got := target(svc, cfg); got.Timeout != 10*time.Second {
+		t.Errorf("expected the service's own timeout, got %v", got.Timeout)
+	}
+}
+
+func TestUpdateIncidentThreadsClock(t *testing.T) {
+	api, calls := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production"}
+	states := map[string]*ServiceState{serviceKey(svc): {IsDown: true}}
+	clk, err := newClock("Asia/Tokyo", "15:04 MST", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	down := []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}}
+	updateIncidentThreads(api, "C1", down, states, newHistory(), defaultTheme, clk, at)
+
+	got := calls()
+	if len(got) != 1 || !strings.Contains(got[0].Get("text"), "21:30 JST") || states[serviceKey(svc)].IncidentTS == "" {
+		t.Errorf("expected the incident to open with the configured clock, got %v", got)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// MaintenanceWindow is either a one-off window between start and end, or a
+// recurring one opening on a cron schedule and lasting duration_minutes.
+type MaintenanceWindow struct {
+	Name            string    `json:"name"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Cron            string    `json:"cron"`
+	DurationMinutes int       `json:"duration_minutes"`
+
+	schedule *cronSchedule
+}
+
+func (w *MaintenanceWindow) validate() error {
+	if w.Cron != "" {
+		schedule, err := parseCron(w.Cron)
+		if err != nil {
+			return err
+		}
+		if w.DurationMinutes <= 0 {
+			return fmt.Errorf("recurring window needs duration_minutes")
+		}
+		w.schedule = schedule
+		return nil
+	}
+
+	if w.Start.IsZero() || !w.End.After(w.Start) {
+		return fmt.Errorf("window needs a start before its end, or a cron schedule")
+	}
+	return nil
+}
+
+// active reports whether the window covers now. Recurring windows look back
+// over their duration for the minute they last opened.
+func (w MaintenanceWindow) active(now time.Time) bool {
+	if w.schedule == nil {
+		return !now.Before(w.Start) && now.Before(w.End)
+	}
+
+	minute := now.Truncate(time.Minute)
+	for i := range w.DurationMinutes {
+		if w.schedule.matches(minute.Add(-time.Duration(i) * time.Minute)) {
+			return true
+		}
+	}
+	return false
+}
+
+// inMaintenance reports whether any maintenance window of svc covers now.
+func inMaintenance(svc Service, now time.Time) bool {
+	for _, w := range svc.Maintenance {
+		if w.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutMaintenance drops the results of services under maintenance, so
+// they neither change state nor count against uptime.
+func withoutMaintenance(results []CheckResult, now time.Time) []CheckResult {
+	var out []CheckResult
+	for _, r := range results {
+		if !inMaintenance(r.Service, now) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+type AnnouncementConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Channel     string `json:"channel"`
+	LeadMinutes int    `json:"lead_minutes"`
+
+	// Schedule hands the wrap-up to chat.scheduleMessage when the
+	// announcement goes out, instead of posting it after the window ends.
+	Schedule bool `json:"schedule"`
+}
+
+const defaultAnnouncementLead = 60
+
+// MaintenanceNotice records an announced window occurrence and whether its
+// wrap-up has been taken care of.
+type MaintenanceNotice struct {
+	ServiceKey string    `json:"service_key"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	WrappedUp  bool      `json:"wrapped_up"`
+}
+
+// next returns the first occurrence of the window that hasn't ended by now
+// and starts within horizon.
+func (w MaintenanceWindow) next(now time.Time, horizon time.Duration) (start, end time.Time, ok bool) {
+	if w.schedule == nil {
+		if w.End.After(now) && !w.Start.After(now.Add(horizon)) {
+			return w.Start, w.End, true
+		}
+		return time.Time{}, time.Time{}, false
+	}
+
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+	limit := now.Add(horizon)
+	for m := now.Truncate(time.Minute).Add(-duration + time.Minute); !m.After(limit); m = m.Add(time.Minute) {
+		if w.schedule.matches(m) {
+			return m, m.Add(duration), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func (b *Bot) announcementChannel(svc Service) string {
+	if b.cfg.Announcements.Channel != "" {
+		return b.cfg.Announcements.Channel
+	}
+	if ch := primaryChannel(b.boards, svc); ch != "" {
+		return ch
+	}
+	return b.defaultChannel()
+}
+
+func announcementText(svc Service, w MaintenanceWindow, start, end time.Time, now time.Time) string {
+	name := ""
+	if w.Name != "" {
+		name = fmt.Sprintf(" — %s", w.Name)
+	}
+	if now.Before(start) {
+		return fmt.Sprintf("🔧 *Scheduled maintenance: %s (%s)* from %s to %s%s. Alerts are paused meanwhile.",
+			svc.Name, svc.Env, start.Format("Jan 2 15:04"), end.Format("15:04"), name)
+	}
+	return fmt.Sprintf("🔧 *Maintenance in progress: %s (%s)* until %s%s. Alerts are paused meanwhile.",
+		svc.Name, svc.Env, end.Format("Jan 2 15:04"), name)
+}
+
+func wrapUpText(svc Service, result *CheckResult) string {
+	msg := fmt.Sprintf("✅ *Maintenance finished: %s (%s)*. Alerts are back on.", svc.Name, svc.Env)
+	if result != nil && !result.Up {
+		msg += fmt.Sprintf(" The service is currently failing: `%s`", result.Error)
+	}
+	return msg
+}
+
+// sendAnnouncements posts a heads-up lead_minutes before each maintenance
+// window and a wrap-up once it is over. A wrap-up that fails to post is
+// retried on the next cycle.
+func (b *Bot) sendAnnouncements(now time.Time) {
+	cfg := b.cfg.Announcements
+	if !cfg.Enabled {
+		return
+	}
+	lead := time.Duration(cfg.LeadMinutes) * time.Minute
+
+	for _, svc := range b.cfg.Services {
+		key := serviceKey(svc)
+		for _, w := range svc.Maintenance {
+			start, end, ok := w.next(now, lead)
+			if !ok || b.history.maintenanceNotice(key, start) != nil {
+				continue
+			}
+
+			channel := b.announcementChannel(svc)
+			if _, _, err := b.api.PostMessage(channel, slack.MsgOptionText(announcementText(svc, w, start, end, now), false)); err != nil {
+				slog.Error("failed to announce maintenance", "err", err)
+				continue
+			}
+
+			notice := MaintenanceNotice{ServiceKey: key, Start: start, End: end}
+			if cfg.Schedule {
+				postAt := strconv.FormatInt(end.Unix(), 10)
+				if _, _, err := b.api.ScheduleMessage(channel, postAt, slack.MsgOptionText(wrapUpText(svc, nil), false)); err != nil {
+					slog.Error("failed to schedule maintenance wrap-up", "err", err)
+				} else {
+					notice.WrappedUp = true
+				}
+			}
+			b.history.MaintenanceNotices = append(b.history.MaintenanceNotices, notice)
+		}
+	}
+
+	for i := range b.history.MaintenanceNotices {
+		notice := &b.history.MaintenanceNotices[i]
+		if notice.WrappedUp || now.Before(notice.End) {
+			continue
+		}
+
+		svc, ok := serviceByKey(b.cfg.Services, notice.ServiceKey)
+		if !ok {
+			// The service is gone, there is nobody to tell.
+			notice.WrappedUp = true
+			continue
+		}
+		var result *CheckResult
+		for j := range b.results {
+			if serviceKey(b.results[j].Service) == notice.ServiceKey {
+				result = &b.results[j]
+			}
+		}
+		if _, _, err := b.api.PostMessage(b.announcementChannel(svc), slack.MsgOptionText(wrapUpText(svc, result), false)); err != nil {
+			slog.Error("failed to post maintenance wrap-up, retrying next cycle", "err", err)
+			continue
+		}
+		notice.WrappedUp = true
+	}
+}
+
+func serviceByKey(services []Service, key string) (Service, bool) {
+	for _, svc := range services {
+		if serviceKey(svc) == key {
+			return svc, true
+		}
+	}
+	return Service{}, false
+}
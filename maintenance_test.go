@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	base := time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC)
+
+	oneOff := MaintenanceWindow{Start: base, End: base.Add(time.Hour)}
+	recurring := MaintenanceWindow{Cron: "0 2 * * *", DurationMinutes: 30}
+	for _, w := range []*MaintenanceWindow{&oneOff, &recurring} {
+		if err := w.validate(); err != nil {
+			t.Fatalf("validate: %v", err)
+		}
+	}
+
+	if !oneOff.active(base.Add(59*time.Minute)) || oneOff.active(base.Add(time.Hour)) {
+		t.Errorf("one-off window should cover [start, end)")
+	}
+	if !recurring.active(base.Add(29*time.Minute)) || recurring.active(base.Add(30*time.Minute)) {
+		t.Errorf("recurring window should last duration_minutes")
+	}
+	if !recurring.active(base.AddDate(0, 0, 1).Add(10 * time.Minute)) {
+		t.Errorf("recurring window should open again the next day")
+	}
+
+	if err := (&MaintenanceWindow{Cron: "0 2 * * *"}).validate(); err == nil {
+		t.Errorf("expected an error without duration_minutes")
+	}
+}
+
+func TestWithoutMaintenance(t *testing.T) {
+	now := time.Now()
+	svc := Service{Name: "api", Maintenance: []MaintenanceWindow{{Start: now.Add(-time.Minute), End: now.Add(time.Hour)}}}
+	results := []CheckResult{{Service: svc}, {Service: Service{Name: "auth"}}}
+
+	got := withoutMaintenance(results, now)
+	if len(got) != 1 || got[0].Service.Name != "auth" {
+		t.Errorf("expected only auth to be tracked, got %+v", got)
+	}
+}
+
+func TestSendAnnouncements(t *testing.T) {
+	api, calls := newTestSlack(t)
+	now := time.Date(2024, 3, 4, 1, 30, 0, 0, time.UTC)
+	svc := Service{Name: "api", Env: "production", Maintenance: []MaintenanceWindow{
+		{Start: now.Add(30 * time.Minute), End: now.Add(90 * time.Minute)},
+	}}
+
+	b := newTestBot(svc)
+	b.api = api
+	b.channelID = "C1"
+	b.cfg.Announcements = AnnouncementConfig{Enabled: true, LeadMinutes: 60}
+
+	b.sendAnnouncements(now)
+	b.sendAnnouncements(now.Add(time.Minute))
+	if got := calls(); len(got) != 1 || !strings.Contains(got[0].Get("text"), "Scheduled maintenance") {
+		t.Fatalf("expected a single announcement, got %v", got)
+	}
+
+	b.sendAnnouncements(now.Add(2 * time.Hour))
+	b.sendAnnouncements(now.Add(2*time.Hour + time.Minute))
+	if got := calls(); len(got) != 2 || !strings.Contains(got[1].Get("text"), "Maintenance finished") {
+		t.Fatalf("expected a single wrap-up, got %v", got)
+	}
+}
+
+func TestSendAnnouncementsRetriesWrapUp(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			fmt.Fprint(w, `{"ok":false,"error":"internal_error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1700000000.000001"}`)
+	}))
+	defer srv.Close()
+
+	now := time.Date(2024, 3, 4, 1, 30, 0, 0, time.UTC)
+	svc := Service{Name: "api", Env: "production"}
+	b := newTestBot(svc)
+	b.api = slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+	b.channelID = "C1"
+	b.cfg.Announcements = AnnouncementConfig{Enabled: true, LeadMinutes: 60}
+	b.history.MaintenanceNotices = []MaintenanceNotice{{ServiceKey: serviceKey(svc), Start: now.Add(-time.Hour), End: now.Add(-time.Minute)}}
+
+	b.sendAnnouncements(now)
+	if b.history.MaintenanceNotices[0].WrappedUp {
+		t.Fatalf("expected a failed wrap-up not to be recorded as posted")
+	}
+
+	failing.Store(false)
+	b.sendAnnouncements(now.Add(time.Minute))
+	if !b.history.MaintenanceNotices[0].WrappedUp {
+		t.Errorf("expected the wrap-up to be posted on the next cycle")
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metrics keeps what /metrics exposes. Gauges are a snapshot taken at the end
+// of each cycle, so scrapes never wait for a cycle in progress. The zero
+// value is ready to use.
+type metrics struct {
+	mu sync.Mutex
+
+	services    []serviceGauges
+	checks      map[serviceSeries]float64
+	failures    map[serviceSeries]float64
+	transitions map[transitionKey]float64
+	notifyErrs  map[string]float64
+
+	cycles        float64
+	cycleErrors   float64
+	cycleDuration time.Duration
+	lastCycle     time.Time
+	started       time.Time
+}
+
+type serviceGauges struct {
+	name, env           string
+	up                  bool
+	latency             time.Duration
+	consecutiveFailures int
+}
+
+type serviceSeries struct {
+	name, env string
+}
+
+type transitionKey struct {
+	serviceSeries
+	typ string
+}
+
+// recordChecks updates the per-service gauges and counters from a cycle.
+func (m *metrics) recordChecks(results []CheckResult, transitions []Transition, states map[string]*ServiceState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.checks == nil {
+		m.checks = make(map[serviceSeries]float64)
+		m.failures = make(map[serviceSeries]float64)
+		m.transitions = make(map[transitionKey]float64)
+	}
+
+	m.services = m.services[:0]
+	for _, r := range results {
+		g := serviceGauges{name: r.Service.Name, env: r.Service.Env, up: r.Up, latency: r.Latency}
+		if state := states[serviceKey(r.Service)]; state != nil {
+			g.consecutiveFailures = state.FailCount
+		}
+		m.services = append(m.services, g)
+
+		series := serviceSeries{r.Service.Name, r.Service.Env}
+		m.checks[series]++
+		if !r.Up {
+			m.failures[series]++
+		}
+	}
+
+	for _, t := range transitions {
+		m.transitions[transitionKey{serviceSeries{t.Service.Name, t.Service.Env}, t.Type}]++
+	}
+}
+
+func (m *metrics) recordNotifyError(notifier string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.notifyErrs == nil {
+		m.notifyErrs = make(map[string]float64)
+	}
+	m.notifyErrs[notifier]++
+}
+
+func (m *metrics) recordCycle(at time.Time, took time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cycles++
+	if err != nil {
+		m.cycleErrors++
+	}
+	m.cycleDuration = took
+	m.lastCycle = at
+}
+
+// cycleTimes returns when the bot started and when the last cycle ran.
+func (m *metrics) cycleTimes() (started time.Time, last time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.started, m.lastCycle
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	family(w, "status_bot_service_up", "gauge", "Whether the last check of the service succeeded.")
+	for _, g := range m.services {
+		fmt.Fprintf(w, "status_bot_service_up{%s} %s\n", serviceLabels(g.name, g.env), boolValue(g.up))
+	}
+
+	family(w, "status_bot_service_latency_seconds", "gauge", "Latency of the last check of the service.")
+	for _, g := range m.services {
+		fmt.Fprintf(w, "status_bot_service_latency_seconds{%s} %g\n", serviceLabels(g.name, g.env), g.latency.Seconds())
+	}
+
+	family(w, "status_bot_service_consecutive_failures", "gauge", "Failed checks in a row for the service.")
+	for _, g := range m.services {
+		fmt.Fprintf(w, "status_bot_service_consecutive_failures{%s} %d\n", serviceLabels(g.name, g.env), g.consecutiveFailures)
+	}
+
+	family(w, "status_bot_checks_total", "counter", "Checks run per service.")
+	writeCounters(w, "status_bot_checks_total", m.checks)
+
+	family(w, "status_bot_check_failures_total", "counter", "Failed checks per service.")
+	writeCounters(w, "status_bot_check_failures_total", m.failures)
+
+	family(w, "status_bot_transitions_total", "counter", "Status transitions per service and type.")
+	keys := make([]transitionKey, 0, len(m.transitions))
+	for k := range m.transitions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].serviceSeries != keys[j].serviceSeries {
+			return seriesLess(keys[i].serviceSeries, keys[j].serviceSeries)
+		}
+		return keys[i].typ < keys[j].typ
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "status_bot_transitions_total{%s,type=\"%s\"} %g\n", serviceLabels(k.name, k.env), escapeLabel(k.typ), m.transitions[k])
+	}
+
+	family(w, "status_bot_notify_errors_total", "counter", "Failed deliveries per notifier.")
+	notifiers := make([]string, 0, len(m.notifyErrs))
+	for n := range m.notifyErrs {
+		notifiers = append(notifiers, n)
+	}
+	sort.Strings(notifiers)
+	for _, n := range notifiers {
+		fmt.Fprintf(w, "status_bot_notify_errors_total{notifier=\"%s\"} %g\n", escapeLabel(n), m.notifyErrs[n])
+	}
+
+	family(w, "status_bot_cycles_total", "counter", "Check cycles run.")
+	fmt.Fprintf(w, "status_bot_cycles_total %g\n", m.cycles)
+
+	family(w, "status_bot_cycle_errors_total", "counter", "Check cycles that finished with errors.")
+	fmt.Fprintf(w, "status_bot_cycle_errors_total %g\n", m.cycleErrors)
+
+	family(w, "status_bot_cycle_duration_seconds", "gauge", "Duration of the last check cycle.")
+	fmt.Fprintf(w, "status_bot_cycle_duration_seconds %g\n", m.cycleDuration.Seconds())
+
+	family(w, "status_bot_last_cycle_timestamp_seconds", "gauge", "Unix time the last check cycle ran.")
+	fmt.Fprintf(w, "status_bot_last_cycle_timestamp_seconds %d\n", unixOrZero(m.lastCycle))
+
+	family(w, "status_bot_start_time_seconds", "gauge", "Unix time the bot started.")
+	fmt.Fprintf(w, "status_bot_start_time_seconds %d\n", unixOrZero(m.started))
+
+	family(w, "status_bot_goroutines", "gauge", "Goroutines currently running.")
+	fmt.Fprintf(w, "status_bot_goroutines %d\n", runtime.NumGoroutine())
+}
+
+func family(w io.Writer, name string, typ string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeCounters(w io.Writer, name string, counters map[serviceSeries]float64) {
+	keys := make([]serviceSeries, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return seriesLess(keys[i], keys[j]) })
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{%s} %g\n", name, serviceLabels(k.name, k.env), counters[k])
+	}
+}
+
+func seriesLess(a, b serviceSeries) bool {
+	if a.name != b.name {
+		return a.name < b.name
+	}
+	return a.env < b.env
+}
+
+func serviceLabels(name string, env string) string {
+	return fmt.Sprintf(`service="%s",env="%s"`, escapeLabel(name), escapeLabel(env))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (b *Bot) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	b.metrics.write(w)
+}
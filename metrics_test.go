@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	api := Service{Name: "api", Env: "production"}
+	web := Service{Name: `we"b`, Env: "staging"}
+
+	var m metrics
+	states := map[string]*ServiceState{serviceKey(api): {FailCount: 2}}
+	m.recordChecks(
+		[]CheckResult{{Service: api, Up: false, Latency: 1500 * time.Millisecond}, {Service: web, Up: true}},
+		[]Transition{{Service: api, Type: "down"}},
+		states,
+	)
+	m.recordChecks([]CheckResult{{Service: api, Up: false}}, nil, states)
+	m.recordNotifyError("discord")
+	m.recordCycle(time.Unix(1700000000, 0), 2*time.Second, errors.New("boom"))
+
+	var sb strings.Builder
+	m.write(&sb)
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE status_bot_service_up gauge",
+		`status_bot_service_up{service="api",env="production"} 0`,
+		`status_bot_service_consecutive_failures{service="api",env="production"} 2`,
+		`status_bot_checks_total{service="api",env="production"} 2`,
+		`status_bot_checks_total{service="we\"b",env="staging"} 1`,
+		`status_bot_check_failures_total{service="api",env="production"} 2`,
+		`status_bot_transitions_total{service="api",env="production",type="down"} 1`,
+		`status_bot_notify_errors_total{notifier="discord"} 1`,
+		"status_bot_cycles_total 1",
+		"status_bot_cycle_errors_total 1",
+		"status_bot_cycle_duration_seconds 2",
+		"status_bot_last_cycle_timestamp_seconds 1700000000",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// Gauges describe the last cycle only.
+	if strings.Contains(out, `status_bot_service_up{service="we\"b"`) {
+		t.Errorf("expected gauges of services missing from the last cycle to be dropped")
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	b := newTestBot()
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "status_bot_cycles_total 0") {
+		t.Errorf("expected cycle counter, got:\n%s", rec.Body.String())
+	}
+}
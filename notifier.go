@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+// Notifier is a sink for the outcome of a check cycle. Slack is always
+// notified first; other sinks are registered with Bot.register and receive
+// the same cycle.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, c Cycle) error
+}
+
+// Cycle is what notifiers receive after every check. Transitions only holds
+// alerting transitions: services that are muted or under maintenance are left
+// out. History may only be used for the duration of Notify.
+type Cycle struct {
+	At          time.Time
+	Results     []CheckResult
+	Transitions []Transition
+	History     *History
+}
+
+// register adds a notifier that is called after Slack on every cycle. During
+// a dry run it only prints what it would send.
+func (b *Bot) register(n Notifier) {
+	if b.dryRun != nil {
+		n = dryRunNotifier{name: n.Name(), out: b.dryRun}
+	}
+	b.notifiers = append(b.notifiers, n)
+}
+
+// notify hands the cycle to Slack and then to every registered notifier, each
+// getting the transitions routed to it, and posts alerts to the channels
+// routing rules add. A failing notifier doesn't stop the others.
+func (b *Bot) notify(ctx context.Context, c Cycle) error {
+	var errs []error
+	for _, n := range append([]Notifier{slackNotifier{b}}, b.notifiers...) {
+		routed := c
+		routed.Transitions = b.cfg.Routing.forNotifier(c.Transitions, n.Name())
+		if err := n.Notify(ctx, routed); err != nil {
+			b.metrics.recordNotifyError(n.Name())
+			errs = append(errs, fmt.Errorf("notify %s: %w", n.Name(), err))
+		}
+	}
+
+	channels, byChannel := b.cfg.Routing.forChannels(c.Transitions)
+	// Channels with grouped alerts still pending need a look even when
+	// nothing new was routed to them.
+	for dest := range b.alertGroups {
+		if ch, ok := strings.CutPrefix(dest, "channel "); ok && !slices.Contains(channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+	sort.Strings(channels)
+
+	opts := b.alertOptions(c.At)
+	opts.placement = placementChannel
+	for _, ch := range channels {
+		if grouped := b.groupAlerts("channel "+ch, byChannel[ch], c.At); len(grouped) > 0 {
+			sendAlerts(b.api, ch, nil, grouped, b.states, opts)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// slackNotifier updates every board and posts its alerts. It runs with b.mu
+// held, like the rest of the cycle.
+type slackNotifier struct {
+	b *Bot
+}
+
+func (n slackNotifier) Name() string {
+	return "slack"
+}
+
+func (n slackNotifier) Notify(ctx context.Context, c Cycle) error {
+	b := n.b
+
+	var errs []error
+	for _, bc := range b.boards {
+		if !b.checkBoard(bc, c.At) {
+			continue
+		}
+
+		blocks := b.boardBlocks(bc.filter(c.Results), bc, c.At)
+
+		minInterval := time.Duration(b.cfg.MinUpdateIntervalSeconds) * time.Second
+		if err := slackboard.Upsert(b.api, bc.Channel, bc.ref, blocks, minInterval); err != nil {
+			if channelUnavailable(err) {
+				b.markUnavailable(bc, err)
+				continue
+			}
+			errs = append(errs, fmt.Errorf("upsert board %s: %w", bc.Name, err))
+			continue
+		}
+
+		if grouped := b.groupAlerts("board "+bc.Name, bc.transitions(c.Transitions), c.At); len(grouped) > 0 {
+			sendAlerts(b.api, bc.Channel, bc.ref, grouped, b.states, b.boardAlertOptions(bc, c.At))
+		}
+	}
+
+	err := errors.Join(errs...)
+	b.self.recordSlack(err, b.cfg.SelfMonitor.SlackFailures)
+	return err
+}
+
+// boardAlertOptions are the alert settings for the board thread of bc. Unless
+// board_thread_alerts is set, the alerts of services whose incidents are
+// threaded in bc's channel go to those threads instead.
+func (b *Bot) boardAlertOptions(bc BoardConfig, now time.Time) alertOptions {
+	opts := b.alertOptions(now)
+	if b.cfg.BoardThreadAlerts {
+		return opts
+	}
+	opts.incidentThread = func(svc Service) (string, bool) {
+		if primaryChannel(b.boards, svc) != bc.Channel {
+			return "", false
+		}
+		state := b.states[serviceKey(svc)]
+		if state == nil || state.IncidentTS == "" {
+			return "", false
+		}
+		return state.IncidentTS, true
+	}
+	return opts
+}
+
+// alertOptions gathers the alert settings in effect at now.
+func (b *Bot) alertOptions(now time.Time) alertOptions {
+	return alertOptions{
+		mentions:  b.cfg.Mentions,
+		theme:     b.cfg.Theme,
+		placement: b.cfg.AlertPlacement,
+		templates: b.cfg.Templates,
+		quiet: func(env string) bool {
+			return b.cfg.QuietHours.active(env, now)
+		},
+		onCall: func(env string) string {
+			if b.onCall == nil {
+				return ""
+			}
+			return b.onCall.mention(env, now)
+		},
+	}
+}
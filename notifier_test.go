@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+type recordingNotifier struct {
+	cycles []Cycle
+	err    error
+}
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, c Cycle) error {
+	n.cycles = append(n.cycles, c)
+	return n.err
+}
+
+func TestNotifyRegisteredNotifiers(t *testing.T) {
+	api, calls := newTestSlack(t)
+
+	dir := t.TempDir()
+	b := newTestBot(Service{Name: "api", Env: "production"})
+	b.api = api
+	b.cfg.Theme = defaultTheme
+	b.recent = newRecentIncidents(3)
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(dir, ".board_ts"))}}
+
+	first := &recordingNotifier{err: errors.New("unreachable")}
+	second := &recordingNotifier{}
+	b.register(first)
+	b.register(second)
+
+	now := time.Now()
+	c := Cycle{
+		At:          now,
+		Results:     []CheckResult{{Service: b.cfg.Services[0], Error: "timeout"}},
+		Transitions: []Transition{{Service: b.cfg.Services[0], ServiceName: "api", Type: "down", Error: "timeout"}},
+	}
+
+	err := b.notify(context.Background(), c)
+	if err == nil || !strings.Contains(err.Error(), "notify recording: unreachable") {
+		t.Errorf("expected the notifier error to be reported, got %v", err)
+	}
+
+	if len(second.cycles) != 1 || len(second.cycles[0].Transitions) != 1 {
+		t.Errorf("expected every notifier to receive the cycle despite earlier failures, got %+v", second.cycles)
+	}
+
+	if b.boards[0].ref.TS() == "" || len(calls()) == 0 {
+		t.Errorf("expected Slack to be notified first")
+	}
+}
+
+func TestSlackNotifierIncidentThreads(t *testing.T) {
+	for _, boardThread := range []bool{false, true} {
+		api, calls := newTestSlack(t)
+
+		svc := Service{Name: "api", Env: "production"}
+		web := Service{Name: "web", Env: "production"}
+		b := newTestBot(svc, web)
+		b.api = api
+		b.cfg.Theme = defaultTheme
+		b.cfg.AlertPlacement = placementBoardThread
+		b.cfg.BoardThreadAlerts = boardThread
+		b.recent = newRecentIncidents(3)
+		b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(t.TempDir(), ".board_ts"))}}
+		b.states["api:production"] = &ServiceState{IsDown: true, IncidentTS: "1600000000.000001"}
+		// The thread of web couldn't be opened.
+		b.states["web:production"] = &ServiceState{IsDown: true}
+
+		c := Cycle{
+			At:      time.Now(),
+			Results: []CheckResult{{Service: svc, Error: "timeout"}, {Service: web, Error: "http_502"}},
+			Transitions: []Transition{
+				{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"},
+				{Service: web, ServiceName: "web (production)", Type: "down", Error: "http_502"},
+			},
+		}
+		if err := (slackNotifier{b}).Notify(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+
+		threads := make(map[string][]string)
+		for _, call := range calls() {
+			if call.Get("method") == "chat.postMessage" && call.Get("thread_ts") != "" {
+				threads[call.Get("thread_ts")] = append(threads[call.Get("thread_ts")], call.Get("text"))
+			}
+		}
+		board := b.boards[0].ref.TS()
+		boardAlerts := strings.Join(threads[board], "\n")
+		switch {
+		case !boardThread && (len(threads["1600000000.000001"]) != 1 || strings.Contains(boardAlerts, "api") || !strings.Contains(boardAlerts, "web")):
+			t.Errorf("expected the alert of api only in its incident thread and web's in the board thread, got %v (board %s)", threads, board)
+		case boardThread && (!strings.Contains(boardAlerts, "api") || !strings.Contains(boardAlerts, "web")):
+			t.Errorf("expected board_thread_alerts to keep the alerts in the board thread, got %v (board %s)", threads, board)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// OnCallConfig resolves the person to mention on down alerts from an
+// on-call schedule instead of pinging the whole channel.
+type OnCallConfig struct {
+	Provider   string `json:"provider"`
+	ScheduleID string `json:"schedule_id"`
+
+	// Envs defaults to production. Users maps the email the provider
+	// reports to a Slack user ID; unmapped emails are looked up in Slack.
+	Envs         []string          `json:"envs"`
+	Users        map[string]string `json:"users"`
+	CacheMinutes int               `json:"cache_minutes"`
+}
+
+const (
+	providerPagerDuty = "pagerduty"
+	providerOpsgenie  = "opsgenie"
+
+	defaultOnCallCache = 5
+)
+
+func (c OnCallConfig) enabled() bool {
+	return c.Provider != ""
+}
+
+func (c *OnCallConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if c.Provider != providerPagerDuty && c.Provider != providerOpsgenie {
+		return fmt.Errorf("on_call.provider must be %s or %s, got %q", providerPagerDuty, providerOpsgenie, c.Provider)
+	}
+	if c.ScheduleID == "" {
+		return fmt.Errorf("on_call.schedule_id is required")
+	}
+	if len(c.Envs) == 0 {
+		c.Envs = []string{"production"}
+	}
+	if c.CacheMinutes <= 0 {
+		c.CacheMinutes = defaultOnCallCache
+	}
+	return nil
+}
+
+// onCallResolver looks up and caches the current on-call mention.
+type onCallResolver struct {
+	cfg     OnCallConfig
+	token   string
+	baseURL string
+	client  *http.Client
+	api     *slack.Client
+
+	mentionText string
+	fetchedAt   time.Time
+}
+
+func newOnCallResolver(cfg OnCallConfig, api *slack.Client) (*onCallResolver, error) {
+	r := &onCallResolver{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		api:    api,
+	}
+
+	switch cfg.Provider {
+	case providerPagerDuty:
+		r.token = os.Getenv("PAGERDUTY_TOKEN")
+		r.baseURL = "https://api.pagerduty.com"
+	case providerOpsgenie:
+		r.token = os.Getenv("OPSGENIE_API_KEY")
+		r.baseURL = "https://api.opsgenie.com"
+	}
+	if r.token == "" {
+		return nil, fmt.Errorf("%s credentials are not set", cfg.Provider)
+	}
+	return r, nil
+}
+
+// mention returns the Slack mention of whoever is on call for env, or "" when
+// env isn't covered or the lookup fails, so callers fall back to the mention
+// policy.
+func (r *onCallResolver) mention(env string, now time.Time) string {
+	if !slices.Contains(r.cfg.Envs, env) {
+		return ""
+	}
+	if !r.fetchedAt.IsZero() && now.Sub(r.fetchedAt) < time.Duration(r.cfg.CacheMinutes)*time.Minute {
+		return r.mentionText
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	emails, err := r.onCallEmails(ctx)
+	if err != nil {
+		slog.Error("failed to look up on-call", "err", err)
+		return ""
+	}
+
+	var mentions []string
+	for _, email := range emails {
+		if m := r.slackMention(ctx, email); m != "" && !slices.Contains(mentions, m) {
+			mentions = append(mentions, m)
+		}
+	}
+
+	r.mentionText = strings.Join(mentions, " ")
+	r.fetchedAt = now
+	return r.mentionText
+}
+
+func (r *onCallResolver) slackMention(ctx context.Context, email string) string {
+	if id := r.cfg.Users[email]; id != "" {
+		return formatMention(id)
+	}
+	if r.api == nil {
+		return ""
+	}
+	user, err := r.api.GetUserByEmailContext(ctx, email)
+	if err != nil {
+		slog.Error("failed to find Slack user", "email", email, "err", err)
+		return ""
+	}
+	return formatMention(user.ID)
+}
+
+func (r *onCallResolver) onCallEmails(ctx context.Context) ([]string, error) {
+	if r.cfg.Provider == providerOpsgenie {
+		return r.opsgenieOnCall(ctx)
+	}
+	return r.pagerDutyOnCall(ctx)
+}
+
+func (r *onCallResolver) pagerDutyOnCall(ctx context.Context) ([]string, error) {
+	q := url.Values{}
+	q.Set("schedule_ids[]", r.cfg.ScheduleID)
+	q.Set("include[]", "users")
+	q.Set("earliest", "true")
+
+	var body struct {
+		OnCalls []struct {
+			User struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"oncalls"`
+	}
+	if err := r.get(ctx, r.baseURL+"/oncalls?"+q.Encode(), "Token token="+r.token, &body); err != nil {
+		return nil, err
+	}
+
+	var emails []string
+	for _, oc := range body.OnCalls {
+		if oc.User.Email != "" {
+			emails = append(emails, oc.User.Email)
+		}
+	}
+	return emails, nil
+}
+
+func (r *onCallResolver) opsgenieOnCall(ctx context.Context) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/v2/schedules/%s/on-calls?scheduleIdentifierType=id&flat=true", r.baseURL, url.PathEscape(r.cfg.ScheduleID))
+
+	var body struct {
+		Data struct {
+			OnCallRecipients []string `json:"onCallRecipients"`
+		} `json:"data"`
+	}
+	if err := r.get(ctx, endpoint, "GenieKey "+r.token, &body); err != nil {
+		return nil, err
+	}
+	return body.Data.OnCallRecipients, nil
+}
+
+func (r *onCallResolver) get(ctx context.Context, endpoint string, auth string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request: %w", r.cfg.Provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", r.cfg.Provider, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode %s response: %w", r.cfg.Provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOnCallMention(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/oncalls":
+			if r.Header.Get("Authorization") != "Token token=pd" || r.URL.Query().Get("schedule_ids[]") != "P1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"oncalls":[{"user":{"email":"ada@example.com"}},{"user":{"email":"ada@example.com"}}]}`))
+		case "/v2/schedules/S1/on-calls":
+			if r.Header.Get("Authorization") != "GenieKey og" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"data":{"onCallRecipients":["bob@example.com"]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	users := map[string]string{"ada@example.com": "U1", "bob@example.com": "U2"}
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	pd := &onCallResolver{
+		cfg:     OnCallConfig{Provider: providerPagerDuty, ScheduleID: "P1", Envs: []string{"production"}, Users: users, CacheMinutes: 5},
+		token:   "pd",
+		baseURL: srv.URL,
+		client:  srv.Client(),
+	}
+	if got := pd.mention("production", now); got != "<@U1>" {
+		t.Errorf("expected <@U1> from PagerDuty, got %q", got)
+	}
+	if got := pd.mention("staging", now); got != "" {
+		t.Errorf("expected no on-call outside the configured envs, got %q", got)
+	}
+	pd.mention("production", now.Add(time.Minute))
+	if calls != 1 {
+		t.Errorf("expected the lookup to be cached, got %d calls", calls)
+	}
+
+	og := &onCallResolver{
+		cfg:     OnCallConfig{Provider: providerOpsgenie, ScheduleID: "S1", Envs: []string{"production"}, Users: users, CacheMinutes: 5},
+		token:   "og",
+		baseURL: srv.URL,
+		client:  srv.Client(),
+	}
+	if got := og.mention("production", now); got != "<@U2>" {
+		t.Errorf("expected <@U2> from Opsgenie, got %q", got)
+	}
+
+	og.token = "wrong"
+	og.fetchedAt = time.Time{}
+	if got := og.mention("production", now); got != "" {
+		t.Errorf("expected a failed lookup to return no mention, got %q", got)
+	}
+}
+
+func TestAlertMentionPrefersOnCall(t *testing.T) {
+	opts := alertOptions{
+		mentions: map[string]string{"default": "here"},
+		onCall: func(env string) string {
+			if env == "production" {
+				return "<@U1>"
+			}
+			return ""
+		},
+	}
+
+	if got := alertMention("production", opts); got != "<@U1>" {
+		t.Errorf("expected the on-call mention, got %q", got)
+	}
+	if got := alertMention("staging", opts); got != "<!here>" {
+		t.Errorf("expected the policy mention, got %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// errServicesDown is returned by a one-shot run when a check failed, so cron
+// and CI jobs can tell an outage from the bot failing to run.
+var errServicesDown = errors.New("services down")
+
+// exitDown is the exit code of a one-shot run that found services down.
+const exitDown = 2
+
+// runOnce runs a single cycle, updating the board and notifying as the
+// daemon would, then prints the results to w.
+func (b *Bot) runOnce(ctx context.Context, w io.Writer) error {
+	if err := b.runCycle(ctx); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.backup != nil {
+		b.persist(ctx, time.Now(), true)
+	}
+	if down := printResults(w, b.results, time.Now()); down > 0 {
+		return fmt.Errorf("%d of %d %w", down, len(b.results), errServicesDown)
+	}
+	return nil
+}
+
+// printResults writes one line per result and returns how many services are
+// down. Services under maintenance don't count.
+func printResults(w io.Writer, results []CheckResult, now time.Time) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tSERVICE\tENV\tLATENCY\tERROR")
+
+	down := 0
+	for _, r := range results {
+		status := "up"
+		switch {
+		case inMaintenance(r.Service, now):
+			status = "maintenance"
+		case !r.Up:
+			status = "down"
+			down++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", status, r.Service.Name, r.Service.Env, r.Latency.Round(time.Millisecond), r.Error)
+	}
+	tw.Flush()
+	return down
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintResults(t *testing.T) {
+	now := time.Now()
+	results := []CheckResult{
+		{Service: Service{Name: "api", Env: "production"}, Up: true, Latency: 120 * time.Millisecond},
+		{Service: Service{Name: "db", Env: "production"}, Error: "connection refused"},
+		{Service: Service{Name: "search", Env: "staging", Maintenance: []MaintenanceWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}}}, Error: "http_503"},
+	}
+
+	var out strings.Builder
+	if down := printResults(&out, results, now); down != 1 {
+		t.Errorf("expected 1 service down, got %d", down)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "STATUS") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	for i, want := range []string{"up", "down", "maintenance"} {
+		if fields := strings.Fields(lines[i+1]); fields[0] != want {
+			t.Errorf("line %d: expected %s, got %q", i+1, want, lines[i+1])
+		}
+	}
+	if !strings.Contains(lines[2], "connection refused") || !strings.Contains(lines[1], "120ms") {
+		t.Errorf("expected latency and error in the output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+// runPreview implements the preview command: it checks every service once
+// and renders the boards from the results and the saved state, without
+// posting anything or saving state. -json prints Block Kit payloads to paste
+// into the Block Kit Builder instead of text.
+func runPreview(ctx context.Context, opts runOptions, args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print Block Kit JSON instead of text")
+	board := fs.String("board", "", "only render the board with this name")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig("services.json")
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	boards := resolveBoards(cfg, opts.slackChannel())
+	if *board != "" {
+		var named []BoardConfig
+		for _, bc := range boards {
+			if bc.Name == *board {
+				named = append(named, bc)
+			}
+		}
+		if len(named) == 0 {
+			return fmt.Errorf("no board named %q", *board)
+		}
+		boards = named
+	}
+
+	states, history, err := loadState(cfg.StateFile)
+	if err != nil {
+		return fmt.Errorf("load state: %w", err)
+	}
+
+	b := &Bot{
+		client:      &http.Client{},
+		cfg:         cfg,
+		boards:      boards,
+		interactive: os.Getenv("SLACK_APP_TOKEN") != "",
+		states:      states,
+		recent:      recentFromHistory(history, cfg.RecentIncidents),
+		history:     history,
+	}
+	now := time.Now()
+	results := holdRecovering(b.checkServices(ctx, 0, 0), states, now)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	return writePreview(w, b, results, now, *asJSON)
+}
+
+// writePreview renders each board of b for results, as text or as one
+// Block Kit payload per message.
+func writePreview(w io.Writer, b *Bot, results []CheckResult, now time.Time, asJSON bool) error {
+	for _, bc := range b.boards {
+		blocks := b.boardBlocks(bc.filter(results), bc, now)
+		if !asJSON {
+			fmt.Fprintf(w, "=== %s (%s)\n%s\n", bc.Name, bc.Channel, blocksText(blocks))
+			continue
+		}
+
+		for _, page := range slackboard.Paginate(blocks) {
+			payload, err := json.MarshalIndent(map[string][]slack.Block{"blocks": page}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("encode board %s: %w", bc.Name, err)
+			}
+			fmt.Fprintf(w, "%s\n", payload)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePreview(t *testing.T) {
+	b := newTestBot(Service{Name: "api", Env: "production"}, Service{Name: "db", Env: "production"})
+	b.recent = newRecentIncidents(5)
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	results := []CheckResult{
+		{Service: b.cfg.Services[0], Up: true, StatusCode: 200},
+		{Service: b.cfg.Services[1], Error: "connection refused"},
+	}
+
+	var text strings.Builder
+	if err := writePreview(&text, b, results, time.Now(), false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text.String(), "=== main (C1)\n") || !strings.Contains(text.String(), "api") || !strings.Contains(text.String(), "connection refused") {
+		t.Errorf("unexpected text preview:\n%s", text.String())
+	}
+
+	var out strings.Builder
+	if err := writePreview(&out, b, results, time.Now(), true); err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Blocks []map[string]any `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &payload); err != nil {
+		t.Fatalf("expected a Block Kit payload: %v\n%s", err, out.String())
+	}
+	if len(payload.Blocks) == 0 || payload.Blocks[0]["type"] == nil {
+		t.Errorf("unexpected payload %s", out.String())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// profileStatus summarises overall health as a Slack custom status. Status
+// emoji must be shortcodes.
+func profileStatus(results []CheckResult) (text string, emoji string) {
+	down := 0
+	for _, r := range results {
+		if !r.Up {
+			down++
+		}
+	}
+
+	switch down {
+	case 0:
+		return fmt.Sprintf("All %d services healthy", len(results)), ":large_green_circle:"
+	case 1:
+		return "1 service down", ":red_circle:"
+	}
+	return fmt.Sprintf("%d services down", down), ":red_circle:"
+}
+
+// updateProfileStatus mirrors overall health in the status of the user behind
+// SLACK_USER_TOKEN, since bot users can't set a status of their own. It only
+// calls Slack when the status changes.
+func (b *Bot) updateProfileStatus(results []CheckResult, now time.Time) {
+	if b.profileAPI == nil {
+		return
+	}
+
+	text, emoji := profileStatus(withoutMaintenance(results, now))
+	if text == b.profileStatus {
+		return
+	}
+
+	if err := b.profileAPI.SetUserCustomStatus(text, emoji, 0); err != nil {
+		slog.Error("failed to update profile status", "err", err)
+		return
+	}
+	b.profileStatus = text
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileStatus(t *testing.T) {
+	results := []CheckResult{{Up: true}, {Up: true}}
+	if text, emoji := profileStatus(results); text != "All 2 services healthy" || emoji != ":large_green_circle:" {
+		t.Errorf("unexpected healthy status %q %q", text, emoji)
+	}
+
+	results = append(results, CheckResult{}, CheckResult{})
+	if text, emoji := profileStatus(results); text != "2 services down" || emoji != ":red_circle:" {
+		t.Errorf("unexpected down status %q %q", text, emoji)
+	}
+}
+
+func TestUpdateProfileStatusOnlyOnChange(t *testing.T) {
+	api, calls := newTestSlack(t)
+	b := newTestBot()
+	b.profileAPI = api
+
+	results := []CheckResult{{Up: true}}
+	b.updateProfileStatus(results, time.Now())
+	b.updateProfileStatus(results, time.Now())
+	if got := calls(); len(got) != 1 || got[0].Get("method") != "users.profile.set" {
+		t.Fatalf("expected a single profile update, got %v", got)
+	}
+}
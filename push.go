@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Push priorities, mapped from the env of a down service. Production is high
+// unless configured otherwise; recoveries always go out at the default level.
+const (
+	priorityLow     = "low"
+	priorityDefault = "default"
+	priorityHigh    = "high"
+	priorityUrgent  = "urgent"
+)
+
+// NtfyConfig publishes alerts to an ntfy topic. NTFY_TOKEN is sent as a
+// bearer token when set.
+type NtfyConfig struct {
+	Enabled    bool              `json:"enabled"`
+	Server     string            `json:"server"`
+	Topic      string            `json:"topic"`
+	Priorities map[string]string `json:"priorities"`
+}
+
+// PushoverConfig sends alerts through Pushover using PUSHOVER_TOKEN and
+// PUSHOVER_USER.
+type PushoverConfig struct {
+	Enabled    bool              `json:"enabled"`
+	Priorities map[string]string `json:"priorities"`
+}
+
+func validatePriorities(priorities map[string]string) error {
+	for env, p := range priorities {
+		switch p {
+		case priorityLow, priorityDefault, priorityHigh, priorityUrgent:
+		default:
+			return fmt.Errorf("priority for %s must be low, default, high or urgent, got %q", env, p)
+		}
+	}
+	return nil
+}
+
+func (c *NtfyConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Topic == "" {
+		return fmt.Errorf("ntfy.topic is required")
+	}
+	if c.Server == "" {
+		c.Server = "https://ntfy.sh"
+	}
+	if err := validatePriorities(c.Priorities); err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	return nil
+}
+
+func (c *PushoverConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := validatePriorities(c.Priorities); err != nil {
+		return fmt.Errorf("pushover: %w", err)
+	}
+	return nil
+}
+
+// pushPriority returns the priority of a transition.
+func pushPriority(t Transition, priorities map[string]string) string {
+	if t.Type != "down" {
+		return priorityDefault
+	}
+	if p := priorities[t.Service.Env]; p != "" {
+		return p
+	}
+	if t.Service.Env == "production" {
+		return priorityHigh
+	}
+	return priorityDefault
+}
+
+// pushMessage renders a transition as a notification title and body.
+func pushMessage(t Transition) (string, string) {
+	if t.Type == "down" {
+		return t.ServiceName + " is down", t.Error
+	}
+	body := "Back up"
+	if t.Downtime != "" {
+		body += " after " + t.Downtime
+	}
+	return t.ServiceName + " recovered", body
+}
+
+func pushTransitions(transitions []Transition) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		if t.Type == "down" || t.Type == "up" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+type ntfyNotifier struct {
+	cfg    NtfyConfig
+	token  string
+	client *http.Client
+}
+
+func newNtfyNotifier(cfg NtfyConfig) *ntfyNotifier {
+	return &ntfyNotifier{
+		cfg:    cfg,
+		token:  os.Getenv("NTFY_TOKEN"),
+		client: &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}
+}
+
+func (n *ntfyNotifier) Name() string {
+	return "ntfy"
+}
+
+func (n *ntfyNotifier) Notify(ctx context.Context, c Cycle) error {
+	for _, t := range pushTransitions(c.Transitions) {
+		title, body := pushMessage(t)
+
+		tags := "white_check_mark"
+		if t.Type == "down" {
+			tags = "rotating_light"
+		}
+
+		header := http.Header{}
+		header.Set("Title", title)
+		header.Set("Priority", pushPriority(t, n.cfg.Priorities))
+		header.Set("Tags", tags)
+		if n.token != "" {
+			header.Set("Authorization", "Bearer "+n.token)
+		}
+
+		endpoint := strings.TrimRight(n.cfg.Server, "/") + "/" + url.PathEscape(n.cfg.Topic)
+		if err := postBody(ctx, n.client, endpoint, "text/plain", strings.NewReader(body), header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type pushoverNotifier struct {
+	cfg      PushoverConfig
+	token    string
+	user     string
+	endpoint string
+	client   *http.Client
+}
+
+func newPushoverNotifier(cfg PushoverConfig) (*pushoverNotifier, error) {
+	token, user := os.Getenv("PUSHOVER_TOKEN"), os.Getenv("PUSHOVER_USER")
+	if token == "" || user == "" {
+		return nil, fmt.Errorf("PUSHOVER_TOKEN and PUSHOVER_USER must be set")
+	}
+	return &pushoverNotifier{
+		cfg:      cfg,
+		token:    token,
+		user:     user,
+		endpoint: "https://api.pushover.net/1/messages.json",
+		client:   &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}, nil
+}
+
+func (n *pushoverNotifier) Name() string {
+	return "pushover"
+}
+
+// pushoverPriorities maps our levels onto Pushover's -2..2 scale. Urgent
+// (emergency) messages repeat until acknowledged in the app.
+var pushoverPriorities = map[string]int{
+	priorityLow:     -1,
+	priorityDefault: 0,
+	priorityHigh:    1,
+	priorityUrgent:  2,
+}
+
+func (n *pushoverNotifier) Notify(ctx context.Context, c Cycle) error {
+	for _, t := range pushTransitions(c.Transitions) {
+		title, body := pushMessage(t)
+		priority := pushoverPriorities[pushPriority(t, n.cfg.Priorities)]
+
+		form := url.Values{}
+		form.Set("token", n.token)
+		form.Set("user", n.user)
+		form.Set("title", title)
+		form.Set("message", body)
+		form.Set("priority", strconv.Itoa(priority))
+		if priority == 2 {
+			form.Set("retry", "60")
+			form.Set("expire", "3600")
+		}
+
+		if err := postBody(ctx, n.client, n.endpoint, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPushPriority(t *testing.T) {
+	prod := Service{Name: "api", Env: "production"}
+	staging := Service{Name: "api", Env: "staging"}
+
+	tests := []struct {
+		t          Transition
+		priorities map[string]string
+		want       string
+	}{
+		{Transition{Service: prod, Type: "down"}, nil, priorityHigh},
+		{Transition{Service: staging, Type: "down"}, nil, priorityDefault},
+		{Transition{Service: prod, Type: "down"}, map[string]string{"production": priorityUrgent}, priorityUrgent},
+		{Transition{Service: prod, Type: "up"}, map[string]string{"production": priorityUrgent}, priorityDefault},
+	}
+
+	for _, tt := range tests {
+		if got := pushPriority(tt.t, tt.priorities); got != tt.want {
+			t.Errorf("pushPriority(%s %s) = %s, want %s", tt.t.Service.Env, tt.t.Type, got, tt.want)
+		}
+	}
+}
+
+func TestPushNotifiers(t *testing.T) {
+	var got []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r)
+		bodies = append(bodies, string(body))
+	}))
+	defer srv.Close()
+
+	c := Cycle{
+		At: time.Now(),
+		Transitions: []Transition{
+			{Service: Service{Name: "api", Env: "production"}, ServiceName: "api (production)", Type: "down", Error: "timeout"},
+			{Service: Service{Name: "api", Env: "production"}, ServiceName: "api (production)", Type: "change", Error: "http_503"},
+		},
+	}
+
+	ntfy := &ntfyNotifier{cfg: NtfyConfig{Server: srv.URL, Topic: "alerts"}, token: "tk", client: srv.Client()}
+	if err := ntfy.Notify(context.Background(), c); err != nil {
+		t.Fatalf("ntfy: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected one ntfy message, got %d", len(got))
+	}
+	r := got[0]
+	if r.URL.Path != "/alerts" || r.Header.Get("Priority") != priorityHigh || r.Header.Get("Title") != "api (production) is down" || r.Header.Get("Authorization") != "Bearer tk" || bodies[0] != "timeout" {
+		t.Errorf("unexpected ntfy request %s %v %q", r.URL.Path, r.Header, bodies[0])
+	}
+
+	got = nil
+	pushover := &pushoverNotifier{
+		cfg:      PushoverConfig{Priorities: map[string]string{"production": priorityUrgent}},
+		token:    "app",
+		user:     "me",
+		endpoint: srv.URL,
+		client:   srv.Client(),
+	}
+	if err := pushover.Notify(context.Background(), c); err != nil {
+		t.Fatalf("pushover: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected one pushover message, got %d", len(got))
+	}
+	form, _ := url.ParseQuery(bodies[len(bodies)-1])
+	if form.Get("priority") != "2" || form.Get("retry") == "" || form.Get("user") != "me" || form.Get("message") != "timeout" {
+		t.Errorf("unexpected pushover form %v", form)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// PushgatewayConfig pushes the /metrics exposition to a Prometheus
+// Pushgateway after every cycle, for bots that can't be scraped. Each push
+// replaces the group identified by job and instance. Basic auth credentials
+// are read from PUSHGATEWAY_USERNAME and PUSHGATEWAY_PASSWORD when set.
+type PushgatewayConfig struct {
+	Enabled  bool   `json:"enabled"`
+	URL      string `json:"url"`
+	Job      string `json:"job"`
+	Instance string `json:"instance"`
+}
+
+func (c *PushgatewayConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" {
+		return fmt.Errorf("pushgateway.url is required")
+	}
+	if c.Job == "" {
+		c.Job = "status_bot"
+	}
+	return nil
+}
+
+type pushgatewayClient struct {
+	endpoint string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newPushgatewayClient(cfg PushgatewayConfig) *pushgatewayClient {
+	endpoint := strings.TrimRight(cfg.URL, "/") + "/metrics/job/" + url.PathEscape(cfg.Job)
+	if cfg.Instance != "" {
+		endpoint += "/instance/" + url.PathEscape(cfg.Instance)
+	}
+	return &pushgatewayClient{
+		endpoint: endpoint,
+		username: os.Getenv("PUSHGATEWAY_USERNAME"),
+		password: os.Getenv("PUSHGATEWAY_PASSWORD"),
+		client:   &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}
+}
+
+// push sends the current metrics. A nil client is a no-op.
+func (c *pushgatewayClient) push(ctx context.Context, m *metrics) {
+	if c == nil {
+		return
+	}
+
+	var body bytes.Buffer
+	m.write(&body)
+
+	if err := c.put(ctx, &body); err != nil {
+		slog.Error("failed to push metrics", "err", err)
+	}
+}
+
+func (c *pushgatewayClient) put(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.endpoint, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushgateway returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
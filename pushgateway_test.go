@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushgatewayPush(t *testing.T) {
+	var method, path, user, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.EscapedPath(), string(data)
+		user, _, _ = r.BasicAuth()
+	}))
+	defer srv.Close()
+
+	t.Setenv("PUSHGATEWAY_USERNAME", "bot")
+	t.Setenv("PUSHGATEWAY_PASSWORD", "secret")
+
+	cfg := PushgatewayConfig{Enabled: true, URL: srv.URL + "/", Instance: "eu west"}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	var m metrics
+	m.recordCycle(time.Unix(1700000000, 0), time.Second, nil)
+	newPushgatewayClient(cfg).push(context.Background(), &m)
+
+	if method != http.MethodPut {
+		t.Errorf("expected PUT, got %s", method)
+	}
+	if path != "/metrics/job/status_bot/instance/eu%20west" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if user != "bot" {
+		t.Errorf("expected basic auth, got user %q", user)
+	}
+	if !strings.Contains(body, "status_bot_cycles_total 1\n") {
+		t.Errorf("expected the metrics exposition, got:\n%s", body)
+	}
+}
+
+func TestPushgatewayConfigRequiresURL(t *testing.T) {
+	cfg := PushgatewayConfig{Enabled: true}
+	if err := cfg.validate(); err == nil {
+		t.Errorf("expected an error without a url")
+	}
+}
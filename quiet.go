@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// QuietHoursConfig silences @mentions during a daily window. Alerts are still
+// posted; with morning_summary, the incidents of the night are recapped when
+// the window ends.
+type QuietHoursConfig struct {
+	Start          string   `json:"start"`
+	End            string   `json:"end"`
+	Timezone       string   `json:"timezone"`
+	Envs           []string `json:"envs"`
+	MorningSummary bool     `json:"morning_summary"`
+
+	loc *time.Location
+}
+
+func (q QuietHoursConfig) enabled() bool {
+	return q.Start != "" && q.End != ""
+}
+
+func (q *QuietHoursConfig) validate() error {
+	if !q.enabled() {
+		return nil
+	}
+	for _, v := range []string{q.Start, q.End} {
+		if _, err := time.Parse("15:04", v); err != nil {
+			return fmt.Errorf("quiet_hours start and end must be HH:MM")
+		}
+	}
+
+	// Without a timezone loc stays nil, meaning local time, rather than the
+	// UTC time.LoadLocation would give.
+	q.loc = nil
+	if q.Timezone != "" {
+		loc, err := time.LoadLocation(q.Timezone)
+		if err != nil {
+			return fmt.Errorf("quiet_hours.timezone: %w", err)
+		}
+		q.loc = loc
+	}
+	return nil
+}
+
+func (q QuietHoursConfig) location() *time.Location {
+	if q.loc == nil {
+		return time.Local
+	}
+	return q.loc
+}
+
+// clock returns today's occurrence of an HH:MM time in the configured zone.
+func (q QuietHoursConfig) clock(value string, now time.Time) time.Time {
+	at, _ := time.Parse("15:04", value)
+	now = now.In(q.location())
+	return time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+}
+
+// active reports whether mentions for env are silenced at now.
+func (q QuietHoursConfig) active(env string, now time.Time) bool {
+	if !q.enabled() || (len(q.Envs) > 0 && !slices.Contains(q.Envs, env)) {
+		return false
+	}
+	return q.covers(now)
+}
+
+// covers reports whether now falls within the quiet window, whatever the
+// env. A window whose end is before its start spans midnight.
+func (q QuietHoursConfig) covers(now time.Time) bool {
+	start, end := q.clock(q.Start, now), q.clock(q.End, now)
+	if !end.After(start) {
+		return !now.Before(start) || now.Before(end)
+	}
+	return !now.Before(start) && now.Before(end)
+}
+
+// lastWindow returns the most recent quiet window that ended at or before now.
+func (q QuietHoursConfig) lastWindow(now time.Time) (from, to time.Time) {
+	to = q.clock(q.End, now)
+	if now.Before(to) {
+		to = to.AddDate(0, 0, -1)
+	}
+
+	from = q.clock(q.Start, to)
+	if !from.Before(to) {
+		from = from.AddDate(0, 0, -1)
+	}
+	return from, to
+}
+
+func renderQuietSummary(incidents []Incident, from, to time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🌅 *Overnight summary* (%s → %s)\n", from.Format("Jan 2 15:04"), to.Format("Jan 2 15:04"))
+	for _, i := range incidents {
+		if i.Open() {
+			fmt.Fprintf(&b, "• *%s*: down since %s `%s`\n", i.ServiceName, i.StartedAt.In(from.Location()).Format("15:04"), i.Error)
+		} else {
+			fmt.Fprintf(&b, "• *%s*: down %s at %s, recovered\n", i.ServiceName, formatDuration(i.Duration(to)), i.StartedAt.In(from.Location()).Format("15:04"))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// sendQuietSummary recaps, once per window, the incidents that started during
+// the last quiet hours. It waits for the current window to end, so a bot
+// started at night doesn't post the previous one's recap then.
+func (b *Bot) sendQuietSummary(now time.Time) {
+	q := b.cfg.QuietHours
+	if !q.enabled() || !q.MorningSummary || q.covers(now) {
+		return
+	}
+
+	from, to := q.lastWindow(now)
+	if b.history.LastQuietSummary == to.Format(dayLayout) {
+		return
+	}
+	b.history.LastQuietSummary = to.Format(dayLayout)
+
+	var incidents []Incident
+	for _, i := range b.history.incidentsBetween(from, to) {
+		if q.active(i.Env, i.StartedAt) {
+			incidents = append(incidents, i)
+		}
+	}
+	if len(incidents) == 0 {
+		return
+	}
+
+	msg := renderQuietSummary(incidents, from, to)
+	if _, _, err := b.api.PostMessage(b.defaultChannel(), slack.MsgOptionText(msg, false)); err != nil {
+		slog.Error("failed to post overnight summary", "err", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	q := QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "UTC", Envs: []string{"development"}}
+	if err := q.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		env  string
+		now  time.Time
+		want bool
+	}{
+		{"development", at(23, 30), true},
+		{"development", at(3, 0), true},
+		{"development", at(7, 0), false},
+		{"development", at(12, 0), false},
+		{"production", at(23, 30), false},
+	}
+	for _, tt := range tests {
+		if got := q.active(tt.env, tt.now); got != tt.want {
+			t.Errorf("active(%s, %s) = %v, want %v", tt.env, tt.now.Format("15:04"), got, tt.want)
+		}
+	}
+
+	if err := (&QuietHoursConfig{Start: "10pm", End: "07:00"}).validate(); err == nil {
+		t.Errorf("expected an error for a malformed start")
+	}
+}
+
+func TestQuietHoursLastWindow(t *testing.T) {
+	q := QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	if err := q.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	from, to := q.lastWindow(time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC))
+	if !from.Equal(time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected window %s → %s", from, to)
+	}
+
+	_, to = q.lastWindow(time.Date(2024, 3, 2, 6, 0, 0, 0, time.UTC))
+	if !to.Equal(time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("a window still in progress shouldn't count, got end %s", to)
+	}
+}
+
+func TestQuietHoursDefaultTimezone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+	config := `{"interval_seconds": 30, "timeout_ms": 2000, "concurrency": 1, "timezone": "Europe/Paris", "quiet_hours": {"start": "22:00", "end": "07:00"}, "services": [{"name": "api", "env": "production", "url": "https://api.example.com"}]}`
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc := cfg.QuietHours.location(); loc.String() != "Europe/Paris" {
+		t.Errorf("expected quiet hours to follow the configured timezone, got %s", loc)
+	}
+
+	q := QuietHoursConfig{Start: "22:00", End: "07:00"}
+	if err := q.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if loc := q.location(); loc != time.Local {
+		t.Errorf("expected quiet hours without any timezone to use local time, got %s", loc)
+	}
+}
+
+func TestSendQuietSummary(t *testing.T) {
+	api, calls := newTestSlack(t)
+	b := newTestBot()
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.QuietHours = QuietHoursConfig{Start: "22:00", End: "07:00", Timezone: "UTC", MorningSummary: true}
+	if err := b.cfg.QuietHours.validate(); err != nil {
+		t.Fatal(err)
+	}
+	b.history.Incidents = []Incident{{
+		ServiceName: "api",
+		StartedAt:   time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC),
+		EndedAt:     time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC),
+	}}
+
+	b.sendQuietSummary(time.Date(2024, 3, 2, 23, 30, 0, 0, time.UTC))
+	if got := calls(); len(got) != 0 {
+		t.Errorf("expected no summary during quiet hours, got %v", got)
+	}
+
+	b.sendQuietSummary(time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC))
+	if got := calls(); len(got) != 1 || !strings.Contains(got[0].Get("text"), "api") {
+		t.Errorf("expected the overnight summary once the window ended, got %v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// alertRateWindow is the period over which alert_rate_limit is counted.
+const alertRateWindow = time.Hour
+
+// rateLimited drops the transitions of services that already sent
+// alert_rate_limit alerts within the last hour. The first time a service is
+// held back, a notice saying so is posted on its boards; the service alerts
+// again once its older alerts fall out of the window. Only down and recovery
+// alerts count against the limit, so status changes during an outage can't
+// use up the budget of the next one; they are held back with the rest while
+// their service is over it. Callers hold b.mu.
+func (b *Bot) rateLimited(transitions []Transition, now time.Time) []Transition {
+	limit := b.cfg.AlertRateLimit
+	if limit <= 0 {
+		return transitions
+	}
+
+	var out []Transition
+	for _, t := range transitions {
+		state := b.states[serviceKey(t.Service)]
+		if state == nil {
+			out = append(out, t)
+			continue
+		}
+
+		state.AlertTimes = recentAlerts(state.AlertTimes, now)
+		if t.Type != "down" && t.Type != "up" {
+			if len(state.AlertTimes) < limit {
+				out = append(out, t)
+			}
+			continue
+		}
+		if len(state.AlertTimes) < limit {
+			state.AlertTimes = append(state.AlertTimes, now)
+			state.RateLimited = false
+			out = append(out, t)
+			continue
+		}
+
+		if !state.RateLimited {
+			state.RateLimited = true
+			b.postSuppressedNotice(t.Service, limit)
+		}
+	}
+	return out
+}
+
+// recentAlerts returns the alert times still inside the rate window.
+func recentAlerts(times []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= alertRateWindow {
+		i++
+	}
+	return times[i:]
+}
+
+func (b *Bot) postSuppressedNotice(svc Service, limit int) {
+	msg := fmt.Sprintf("🔇 *%s (%s)* sent %d alerts in the last hour, further alerts are suppressed until it settles. The board stays up to date.",
+		svc.Name, svc.Env, limit)
+	for _, bc := range b.boards {
+		if !bc.matches(svc) {
+			continue
+		}
+		if err := b.postBoardNotice(bc, msg); err != nil {
+			slog.Error("failed to post suppressed alerts notice", "board", bc.Name, "service", svc.Name, "err", err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimited(t *testing.T) {
+	api, calls := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production"}
+	other := Service{Name: "db", Env: "production"}
+
+	b := newTestBot(svc, other)
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.AlertPlacement = placementChannel
+	b.states[serviceKey(svc)] = &ServiceState{}
+	b.states[serviceKey(other)] = &ServiceState{}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	flap := []Transition{{Service: svc, Type: "down"}}
+
+	if got := b.rateLimited(flap, now); len(got) != 1 {
+		t.Fatalf("expected alerts to pass without a limit, got %+v", got)
+	}
+
+	b.cfg.AlertRateLimit = 2
+	changes := []Transition{{Service: svc, Type: "change"}, {Service: svc, Type: "change"}, {Service: svc, Type: "change"}}
+	if got := b.rateLimited(changes, now); len(got) != 3 || len(b.states[serviceKey(svc)].AlertTimes) != 0 {
+		t.Fatalf("expected status changes to pass without using up the budget, got %+v", got)
+	}
+	for i := range 2 {
+		if got := b.rateLimited(flap, now.Add(time.Duration(i)*time.Minute)); len(got) != 1 {
+			t.Fatalf("expected alert %d under the limit to pass, got %+v", i+1, got)
+		}
+	}
+
+	got := b.rateLimited(append(flap, Transition{Service: other, Type: "down"}), now.Add(2*time.Minute))
+	if len(got) != 1 || got[0].Service.Name != "db" {
+		t.Fatalf("expected only the other service to alert, got %+v", got)
+	}
+	b.rateLimited(flap, now.Add(3*time.Minute))
+	if got := b.rateLimited(changes, now.Add(3*time.Minute)); len(got) != 0 {
+		t.Errorf("expected the changes of a suppressed service to be held back, got %+v", got)
+	}
+
+	sent := calls()
+	if len(sent) != 1 || !strings.Contains(sent[0].Get("text"), "further alerts are suppressed") {
+		t.Fatalf("expected a single suppression notice, got %v", sent)
+	}
+
+	if got := b.rateLimited(flap, now.Add(time.Hour)); len(got) != 1 {
+		t.Errorf("expected alerts to resume once the first one left the window, got %+v", got)
+	}
+	if b.states[serviceKey(svc)].RateLimited {
+		t.Errorf("expected the suppression to be lifted")
+	}
+}
+
+func TestThreadIncidentsRateLimited(t *testing.T) {
+	api, calls := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production"}
+
+	b := newTestBot(svc)
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.Theme = defaultTheme
+	b.cfg.AlertRateLimit = 1
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := &ServiceState{AlertTimes: []time.Time{now.Add(-time.Minute)}, RateLimited: true, IncidentTS: "1699999999.000001"}
+	b.states[serviceKey(svc)] = state
+
+	up := []Transition{{Service: svc, ServiceName: "api (production)", Type: "up"}}
+	b.threadIncidents(b.rateLimited(up, now), now)
+	b.endIncidents(up)
+	if state.IncidentTS != "" || len(calls()) != 0 {
+		t.Errorf("expected a held back recovery to end the incident without a reply, got %v", calls())
+	}
+
+	down := []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "http_503"}}
+	b.threadIncidents(b.rateLimited(down, now), now)
+	if state.IncidentTS != "" || len(calls()) != 0 {
+		t.Errorf("expected a rate limited service not to open an incident thread, got %v", calls())
+	}
+
+	b.threadIncidents(b.rateLimited(down, now.Add(time.Hour)), now.Add(time.Hour))
+	if state.IncidentTS == "" || len(calls()) != 1 {
+		t.Errorf("expected the thread to open once under the limit, got %v", calls())
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+type ReportConfig struct {
+	Period    string   `json:"period"`
+	Channel   string   `json:"channel"`
+	OutputDir string   `json:"output_dir"`
+	Formats   []string `json:"formats"`
+}
+
+type ServiceReport struct {
+	Name      string
+	Checks    int
+	Failures  int
+	Incidents int
+	MTTR      time.Duration
+	MTBF      time.Duration
+}
+
+// Uptime is the percentage of successful checks, or 100 when nothing was
+// checked.
+func (s ServiceReport) Uptime() float64 {
+	if s.Checks == 0 {
+		return 100
+	}
+	return float64(s.Checks-s.Failures) / float64(s.Checks) * 100
+}
+
+type Report struct {
+	Period    string
+	Key       string
+	From      time.Time
+	To        time.Time
+	Services  []ServiceReport
+	Incidents []Incident
+}
+
+func (r Report) Empty() bool {
+	for _, s := range r.Services {
+		if s.Checks > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reportPeriod returns the last complete weekly (ISO week, starting Monday)
+// or monthly period before now, along with a key identifying it.
+func reportPeriod(period string, now time.Time) (key string, from, to time.Time, err error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case "weekly":
+		offset := (int(midnight.Weekday()) + 6) % 7
+		to = midnight.AddDate(0, 0, -offset)
+		from = to.AddDate(0, 0, -7)
+		year, week := from.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), from, to, nil
+	case "monthly":
+		to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		from = to.AddDate(0, -1, 0)
+		return from.Format("2006-01"), from, to, nil
+	}
+
+	return "", time.Time{}, time.Time{}, fmt.Errorf("unknown report period %q", period)
+}
+
+func buildReport(history *History, services []Service, period string, key string, from, to time.Time) Report {
+	report := Report{
+		Period:    period,
+		Key:       key,
+		From:      from,
+		To:        to,
+		Incidents: history.incidentsBetween(from, to),
+	}
+
+	for _, svc := range services {
+		sk := serviceKey(svc)
+		sr := ServiceReport{Name: fmt.Sprintf("%s (%s)", svc.Name, svc.Env)}
+
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if stats := history.Days[day.Format(dayLayout)][sk]; stats != nil {
+				sr.Checks += stats.Checks
+				sr.Failures += stats.Failures
+			}
+		}
+
+		rel := history.reliability(sk, from, to)
+		sr.Incidents = rel.Incidents
+		sr.MTTR = rel.MTTR
+		sr.MTBF = rel.MTBF
+
+		report.Services = append(report.Services, sr)
+	}
+
+	return report
+}
+
+func reportTitle(r Report) string {
+	period := strings.ToUpper(r.Period[:1]) + r.Period[1:]
+	return fmt.Sprintf("%s uptime report %s (%s → %s)",
+		period, r.Key, r.From.Format(dayLayout), r.To.AddDate(0, 0, -1).Format(dayLayout))
+}
+
+func formatMeanTime(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return formatDuration(d)
+}
+
+func renderReportSlack(r Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 *%s*\n", reportTitle(r))
+	for _, s := range r.Services {
+		fmt.Fprintf(&b, "• *%s*: `%.3f%%` uptime, %d incident(s), MTTR %s, MTBF %s\n",
+			s.Name, s.Uptime(), s.Incidents, formatMeanTime(s.MTTR), formatMeanTime(s.MTBF))
+	}
+
+	var annotated []string
+	for _, i := range r.Incidents {
+		for _, n := range i.Notes {
+			annotated = append(annotated, fmt.Sprintf("• %s, %s: _%s_ (%s)", i.ServiceName, i.StartedAt.Format("Jan 2 15:04"), n.Text, n.Author))
+		}
+	}
+	if len(annotated) > 0 {
+		b.WriteString("\n*Incident notes*\n" + strings.Join(annotated, "\n"))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func renderReportMarkdown(r Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", reportTitle(r))
+	b.WriteString("| Service | Uptime | Checks | Failures | Incidents | MTTR | MTBF |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, s := range r.Services {
+		fmt.Fprintf(&b, "| %s | %.3f%% | %d | %d | %d | %s | %s |\n",
+			s.Name, s.Uptime(), s.Checks, s.Failures, s.Incidents, formatMeanTime(s.MTTR), formatMeanTime(s.MTBF))
+	}
+
+	if len(r.Incidents) > 0 {
+		b.WriteString("\n## Incidents\n\n")
+		b.WriteString("| Service | Started | Duration | Error | Notes |\n")
+		b.WriteString("|---|---|---:|---|---|\n")
+		for _, i := range r.Incidents {
+			var notes []string
+			for _, n := range i.Notes {
+				notes = append(notes, fmt.Sprintf("%s: %s", n.Author, n.Text))
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				i.ServiceName, i.StartedAt.Format("2006-01-02 15:04"), formatDuration(i.Duration(r.To)), i.Error, strings.Join(notes, "; "))
+		}
+	}
+	return b.String()
+}
+
+func renderReportCSV(r Report) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{{"service", "uptime_percent", "checks", "failures", "incidents", "mttr_seconds", "mtbf_seconds"}}
+	for _, s := range r.Services {
+		rows = append(rows, []string{
+			s.Name,
+			strconv.FormatFloat(s.Uptime(), 'f', 3, 64),
+			strconv.Itoa(s.Checks),
+			strconv.Itoa(s.Failures),
+			strconv.Itoa(s.Incidents),
+			strconv.Itoa(int(s.MTTR.Seconds())),
+			strconv.Itoa(int(s.MTBF.Seconds())),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeReportFiles(r Report, cfg ReportConfig) error {
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	base := filepath.Join(cfg.OutputDir, fmt.Sprintf("uptime-%s-%s", r.Period, r.Key))
+
+	for _, format := range cfg.Formats {
+		var data []byte
+		var ext string
+
+		switch format {
+		case "markdown":
+			data, ext = []byte(renderReportMarkdown(r)), ".md"
+		case "csv":
+			out, err := renderReportCSV(r)
+			if err != nil {
+				return fmt.Errorf("render csv: %w", err)
+			}
+			data, ext = out, ".csv"
+		}
+
+		if err := os.WriteFile(base+ext, data, 0644); err != nil {
+			return fmt.Errorf("write %s: %w", format, err)
+		}
+	}
+
+	return nil
+}
+
+// sendReports writes and posts every configured report whose last complete
+// period hasn't been reported yet. A report is only recorded as sent once
+// both went through, so a failure has it retried on the next cycle. Files
+// are written first: rewriting them is harmless, posting twice isn't.
+func sendReports(api *slack.Client, reports []ReportConfig, defaultChannel string, history *History, services []Service, now time.Time) {
+	for _, cfg := range reports {
+		key, from, to, err := reportPeriod(cfg.Period, now)
+		if err != nil {
+			continue
+		}
+
+		id := cfg.Period + ":" + cfg.Channel
+		if history.LastReports[id] == key {
+			continue
+		}
+
+		report := buildReport(history, services, cfg.Period, key, from, to)
+		if report.Empty() {
+			history.LastReports[id] = key
+			continue
+		}
+
+		if cfg.OutputDir != "" && len(cfg.Formats) > 0 {
+			if err := writeReportFiles(report, cfg); err != nil {
+				slog.Error("failed to write report, retrying next cycle", "period", cfg.Period, "err", err)
+				continue
+			}
+		}
+
+		channel := cfg.Channel
+		if channel == "" {
+			channel = defaultChannel
+		}
+		if _, _, err := api.PostMessage(channel, slack.MsgOptionText(renderReportSlack(report), false)); err != nil {
+			slog.Error("failed to post report, retrying next cycle", "period", cfg.Period, "err", err)
+			continue
+		}
+
+		history.LastReports[id] = key
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestReportPeriod(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC) // Wednesday
+
+	key, from, to, err := reportPeriod("weekly", now)
+	if err != nil {
+		t.Fatalf("weekly: %v", err)
+	}
+	if key != "2024-W09" || from.Format(dayLayout) != "2024-02-26" || to.Format(dayLayout) != "2024-03-04" {
+		t.Errorf("unexpected weekly period %s %s → %s", key, from, to)
+	}
+
+	key, from, to, err = reportPeriod("monthly", now)
+	if err != nil {
+		t.Fatalf("monthly: %v", err)
+	}
+	if key != "2024-02" || from.Format(dayLayout) != "2024-02-01" || to.Format(dayLayout) != "2024-03-01" {
+		t.Errorf("unexpected monthly period %s %s → %s", key, from, to)
+	}
+
+	if _, _, _, err := reportPeriod("yearly", now); err == nil {
+		t.Errorf("expected an error for an unknown period")
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	svc := Service{Name: "api", Env: "production"}
+
+	h := newHistory()
+	h.Days["2024-02-10"] = map[string]*DayStats{"api:production": {Checks: 900, Failures: 9}}
+	h.Days["2024-02-11"] = map[string]*DayStats{"api:production": {Checks: 100, Failures: 1}}
+	h.Incidents = []Incident{
+		{ServiceKey: "api:production", StartedAt: from.Add(time.Hour), EndedAt: from.Add(time.Hour + 10*time.Minute)},
+		{ServiceKey: "api:production", StartedAt: from.Add(2 * time.Hour), EndedAt: from.Add(2*time.Hour + 20*time.Minute)},
+	}
+
+	report := buildReport(h, []Service{svc}, "monthly", "2024-02", from, to)
+	sr := report.Services[0]
+
+	if sr.Checks != 1000 || sr.Failures != 10 {
+		t.Errorf("expected 1000 checks / 10 failures, got %d / %d", sr.Checks, sr.Failures)
+	}
+
+	if sr.Uptime() != 99 {
+		t.Errorf("expected 99%% uptime, got %f", sr.Uptime())
+	}
+
+	if sr.Incidents != 2 || sr.MTTR != 15*time.Minute {
+		t.Errorf("expected 2 incidents with MTTR 15m, got %d / %v", sr.Incidents, sr.MTTR)
+	}
+
+	if sr.MTBF != 50*time.Minute {
+		t.Errorf("expected MTBF 50m, got %v", sr.MTBF)
+	}
+
+	md := renderReportMarkdown(report)
+	if !strings.Contains(md, "| api (production) | 99.000% | 1000 | 10 | 2 | 15m | 50m |") {
+		t.Errorf("unexpected markdown:\n%s", md)
+	}
+}
+
+func TestSendReportsRetriesFailedPost(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if failing.Load() {
+			fmt.Fprint(w, `{"ok":false,"error":"internal_error"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"channel":"C1","ts":"1700000000.000001"}`)
+	}))
+	defer srv.Close()
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+
+	svc := Service{Name: "api", Env: "production"}
+	h := newHistory()
+	h.Days["2024-02-10"] = map[string]*DayStats{"api:production": {Checks: 900, Failures: 9}}
+	dir := t.TempDir()
+	reports := []ReportConfig{{Period: "monthly", OutputDir: dir, Formats: []string{"csv"}}}
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+
+	sendReports(api, reports, "C1", h, []Service{svc}, now)
+	if h.LastReports["monthly:"] != "" || posts.Load() == 0 {
+		t.Fatalf("expected a failed report not to be recorded as sent, got %q after %d posts", h.LastReports["monthly:"], posts.Load())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "uptime-monthly-2024-02.csv")); err != nil {
+		t.Errorf("expected the report file to be written: %v", err)
+	}
+
+	failing.Store(false)
+	sendReports(api, reports, "C1", h, []Service{svc}, now.Add(time.Minute))
+	if h.LastReports["monthly:"] != "2024-02" {
+		t.Errorf("expected the report to be sent on the next cycle, got %q", h.LastReports["monthly:"])
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryTransport retries Slack API requests that were rate limited (429,
+// honoring Retry-After) or failed transiently (network errors and 5xx), with
+// exponential backoff in between.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		base:        base,
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxBackoff:  30 * time.Second,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := t.backoff
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		retryable := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= t.maxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		// A body that can't be replayed means the request can't be retried.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := wait
+		if err == nil {
+			if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs >= 0 {
+				delay = time.Duration(secs) * time.Second
+			}
+			resp.Body.Close()
+		}
+		wait = min(wait*2, t.maxBackoff)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
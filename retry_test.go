@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "text=hello" {
+			t.Errorf("attempt %d: body not replayed, got %q", attempts, body)
+		}
+
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	rt := newRetryTransport(http.DefaultTransport)
+	rt.backoff = time.Millisecond
+	client := &http.Client{Transport: rt}
+
+	resp, err := client.Post(srv.URL, "application/x-www-form-urlencoded", strings.NewReader("text=hello"))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("expected success on the third attempt, got %d after %d", resp.StatusCode, attempts)
+	}
+}
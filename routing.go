@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RoutingConfig decides which notifiers receive a transition. Rules are
+// evaluated in order and the first match wins, unless it sets continue.
+// Transitions that match no rule go to default, which is every notifier
+// unless configured.
+type RoutingConfig struct {
+	Default []string    `json:"default"`
+	Rules   []RouteRule `json:"rules"`
+}
+
+// RouteRule matches transitions on service name, env, tag, type and how long
+// the service has been down; empty criteria match everything. Notifiers are
+// named by kind ("slack", "email", …), by full name ("webhook
+// hooks.example.com") or "*" for all. Channels are extra Slack channels the
+// matching alerts are posted to.
+type RouteRule struct {
+	Services       []string `json:"services"`
+	Envs           []string `json:"envs"`
+	Tags           []string `json:"tags"`
+	Types          []string `json:"types"`
+	MinDownMinutes int      `json:"min_down_minutes"`
+
+	Notifiers []string `json:"notifiers"`
+	Channels  []string `json:"channels"`
+	Continue  bool     `json:"continue"`
+}
+
+const routeAll = "*"
+
+var notifierKinds = []string{routeAll, "slack", "webhook", "discord", "teams", "email", "telegram", "ntfy", "pushover", "statuspage", "grafana"}
+
+func validateTargets(targets []string) error {
+	for _, target := range targets {
+		kind, _, _ := strings.Cut(target, " ")
+		if !slices.Contains(notifierKinds, kind) {
+			return fmt.Errorf("unknown notifier %q", target)
+		}
+	}
+	return nil
+}
+
+func (c RoutingConfig) validate() error {
+	if err := validateTargets(c.Default); err != nil {
+		return fmt.Errorf("routing.default: %w", err)
+	}
+
+	for i, rule := range c.Rules {
+		if len(rule.Notifiers) == 0 && len(rule.Channels) == 0 {
+			return fmt.Errorf("routing rule %d: needs notifiers or channels", i)
+		}
+		if err := validateTargets(rule.Notifiers); err != nil {
+			return fmt.Errorf("routing rule %d: %w", i, err)
+		}
+		for _, typ := range rule.Types {
+			if typ != "down" && typ != "up" && typ != "change" {
+				return fmt.Errorf("routing rule %d: type must be down, up or change, got %q", i, typ)
+			}
+		}
+	}
+	return nil
+}
+
+func (r RouteRule) matches(t Transition) bool {
+	if len(r.Services) > 0 && !slices.Contains(r.Services, t.Service.Name) {
+		return false
+	}
+	if len(r.Envs) > 0 && !slices.Contains(r.Envs, t.Service.Env) {
+		return false
+	}
+	if len(r.Types) > 0 && !slices.Contains(r.Types, t.Type) {
+		return false
+	}
+	if t.DownFor < time.Duration(r.MinDownMinutes)*time.Minute {
+		return false
+	}
+	if len(r.Tags) == 0 {
+		return true
+	}
+	for _, tag := range t.Service.Tags {
+		if slices.Contains(r.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the notifiers and extra channels a transition goes to.
+func (c RoutingConfig) route(t Transition) ([]string, []string) {
+	var notifiers, channels []string
+	matched := false
+	for _, rule := range c.Rules {
+		if !rule.matches(t) {
+			continue
+		}
+		matched = true
+		notifiers = append(notifiers, rule.Notifiers...)
+		channels = append(channels, rule.Channels...)
+		if !rule.Continue {
+			break
+		}
+	}
+	if !matched {
+		notifiers = c.Default
+		if len(notifiers) == 0 {
+			notifiers = []string{routeAll}
+		}
+	}
+	return notifiers, channels
+}
+
+// routedTo reports whether a notifier called name is among targets.
+func routedTo(targets []string, name string) bool {
+	for _, target := range targets {
+		if target == routeAll || target == name || strings.HasPrefix(name, target+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// forNotifier keeps the transitions routed to the notifier called name.
+func (c RoutingConfig) forNotifier(transitions []Transition, name string) []Transition {
+	var out []Transition
+	for _, t := range transitions {
+		if notifiers, _ := c.route(t); routedTo(notifiers, name) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// forChannels groups the transitions routed to extra Slack channels.
+func (c RoutingConfig) forChannels(transitions []Transition) ([]string, map[string][]Transition) {
+	var order []string
+	byChannel := make(map[string][]Transition)
+	for _, t := range transitions {
+		_, channels := c.route(t)
+		slices.Sort(channels)
+		for _, ch := range slices.Compact(channels) {
+			if _, seen := byChannel[ch]; !seen {
+				order = append(order, ch)
+			}
+			byChannel[ch] = append(byChannel[ch], t)
+		}
+	}
+	return order, byChannel
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+func TestRoutingRoute(t *testing.T) {
+	routing := RoutingConfig{
+		Default: []string{"slack"},
+		Rules: []RouteRule{
+			{Envs: []string{"production"}, Types: []string{"down"}, Notifiers: []string{"pushover"}, Continue: true},
+			{Tags: []string{"payments"}, Notifiers: []string{"email"}, Channels: []string{"CPAY"}},
+			{Envs: []string{"production"}, Notifiers: []string{"slack", "webhook"}},
+			{Types: []string{"up"}, MinDownMinutes: 60, Notifiers: []string{"email"}},
+		},
+	}
+	if err := routing.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	prod := Service{Name: "api", Env: "production"}
+	pay := Service{Name: "billing", Env: "production", Tags: []string{"payments"}}
+	staging := Service{Name: "api", Env: "staging"}
+
+	tests := []struct {
+		name      string
+		t         Transition
+		notifiers []string
+		channels  []string
+	}{
+		{"continue then match", Transition{Service: prod, Type: "down"}, []string{"pushover", "slack", "webhook"}, nil},
+		{"tag rule", Transition{Service: pay, Type: "down"}, []string{"pushover", "email"}, []string{"CPAY"}},
+		{"no match uses default", Transition{Service: staging, Type: "down"}, []string{"slack"}, nil},
+		{"short outage", Transition{Service: staging, Type: "up", DownFor: time.Minute}, []string{"slack"}, nil},
+		{"long outage", Transition{Service: staging, Type: "up", DownFor: 2 * time.Hour}, []string{"email"}, nil},
+	}
+
+	for _, tt := range tests {
+		notifiers, channels := routing.route(tt.t)
+		if !slices.Equal(notifiers, tt.notifiers) || !slices.Equal(channels, tt.channels) {
+			t.Errorf("%s: got %v %v, want %v %v", tt.name, notifiers, channels, tt.notifiers, tt.channels)
+		}
+	}
+
+	if !routedTo([]string{"webhook"}, "webhook hooks.example.com") || routedTo([]string{"webhook other.example.com"}, "webhook hooks.example.com") {
+		t.Errorf("expected webhooks to match by kind or full name")
+	}
+
+	if err := (RoutingConfig{Rules: []RouteRule{{Notifiers: []string{"pager"}}}}).validate(); err == nil {
+		t.Errorf("expected an unknown notifier to be rejected")
+	}
+}
+
+func TestNotifyAppliesRouting(t *testing.T) {
+	api, calls := newTestSlack(t)
+
+	svc := Service{Name: "api", Env: "production"}
+	b := newTestBot(svc)
+	b.api = api
+	b.cfg.Theme = defaultTheme
+	b.cfg.AlertPlacement = placementBoardThread
+	b.recent = newRecentIncidents(3)
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(t.TempDir(), ".board_ts"))}}
+	b.cfg.Routing = RoutingConfig{Rules: []RouteRule{{Notifiers: []string{"webhook"}, Channels: []string{"CTEAM"}}}}
+
+	webhook := &recordingNotifier{}
+	other := &recordingNotifier{}
+	b.register(namedNotifier{"webhook hooks.example.com", webhook})
+	b.register(other)
+
+	err := b.notify(context.Background(), Cycle{
+		At:          time.Now(),
+		Results:     []CheckResult{{Service: svc, Error: "timeout"}},
+		Transitions: []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}},
+	})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(webhook.cycles[0].Transitions) != 1 || len(other.cycles[0].Transitions) != 0 {
+		t.Errorf("expected only the webhook to get the transition")
+	}
+
+	var alertChannels []string
+	for _, call := range calls() {
+		if call.Get("method") == "chat.postMessage" && call.Get("thread_ts") == "" && call.Get("blocks") == "" {
+			alertChannels = append(alertChannels, call.Get("channel"))
+		}
+	}
+	if !slices.Equal(alertChannels, []string{"CTEAM"}) {
+		t.Errorf("expected a single alert in CTEAM, got %v", alertChannels)
+	}
+}
+
+type namedNotifier struct {
+	name string
+	Notifier
+}
+
+func (n namedNotifier) Name() string { return n.name }
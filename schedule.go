@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Overrun policies decide when to run the next cycle after one that took
+// longer than the interval: "delay" waits a full interval from the end of the
+// slow cycle, "skip" drops the missed slots and keeps to the original grid.
+// Either way cycles never run back to back to catch up.
+const (
+	overrunDelay = "delay"
+	overrunSkip  = "skip"
+)
+
+// slowCycleRatio is the share of the interval a cycle may take before a
+// warning is logged.
+const slowCycleRatio = 0.8
+
+// nextCycle returns when to start the cycle after one that ran from started
+// to ended.
+func nextCycle(started time.Time, ended time.Time, interval time.Duration, policy string) time.Time {
+	next := started.Add(interval)
+	if next.After(ended) {
+		return next
+	}
+	if policy == overrunSkip {
+		missed := ended.Sub(started) / interval
+		return started.Add((missed + 1) * interval)
+	}
+	return ended.Add(interval)
+}
+
+// scheduledCycle runs one cycle, warns when it came close to or exceeded the
+// interval, and returns when the next one is due.
+func (b *Bot) scheduledCycle(ctx context.Context) time.Time {
+	interval := time.Duration(b.cfg.IntervalSeconds) * time.Second
+
+	started := time.Now()
+	if err := b.runCycle(ctx); err != nil {
+		slog.Error("cycle error", "err", err)
+	}
+	ended := time.Now()
+	took := ended.Sub(started)
+
+	next := nextCycle(started, ended, interval, b.cfg.OverrunPolicy)
+	switch {
+	case took > interval:
+		slog.Warn("cycle overran the interval", "took", took, "interval", interval, "policy", b.cfg.OverrunPolicy, "next_in", time.Until(next).Round(time.Second))
+	case float64(took) > slowCycleRatio*float64(interval):
+		slog.Warn("cycle is close to the interval", "took", took, "interval", interval)
+	}
+	return next
+}
+
+// runCycles runs cycles on schedule until ctx is done, then shuts down
+// cleanly. When lost is closed, because another replica took the leader
+// lease, it stops right away without touching the boards: they are the new
+// leader's now.
+func (b *Bot) runCycles(ctx context.Context, lost <-chan struct{}) {
+	// Cycles get a context of their own, so a signal lets the one in flight
+	// finish instead of failing its checks.
+	cycleCtx, cancelCycles := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelCycles()
+
+	// Socket Mode would answer commands from the real channel on a dry run.
+	if b.interactive && b.dryRun == nil {
+		go b.runSocketMode(cycleCtx)
+	}
+
+	// The daemon checks each service on its own timer; cycles only collect
+	// the results.
+	if b.pool != nil {
+		defer b.startLoops(ctx)()
+	}
+
+	next := b.scheduledCycle(cycleCtx)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			next = b.scheduledCycle(cycleCtx)
+			timer.Reset(time.Until(next))
+		case <-lost:
+			cancelCycles()
+			b.cycleMu.Lock()
+			b.cycleMu.Unlock()
+			return
+		case <-ctx.Done():
+			slog.Info("shutting down")
+			sdNotify("STOPPING=1")
+			grace := time.Duration(b.cfg.ShutdownTimeoutSeconds) * time.Second
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), grace+10*time.Second)
+			b.shutdown(shutdownCtx, cancelCycles, grace)
+			cancel()
+			return
+		}
+	}
+}
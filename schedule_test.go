@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+func TestNextCycle(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	interval := 30 * time.Second
+
+	cases := []struct {
+		name   string
+		took   time.Duration
+		policy string
+		want   time.Duration
+	}{
+		{"on time", 10 * time.Second, overrunDelay, 30 * time.Second},
+		{"on time skip", 10 * time.Second, overrunSkip, 30 * time.Second},
+		{"delay slides the schedule", 70 * time.Second, overrunDelay, 100 * time.Second},
+		{"skip keeps the grid", 70 * time.Second, overrunSkip, 90 * time.Second},
+		{"skip exactly on a slot", 60 * time.Second, overrunSkip, 90 * time.Second},
+	}
+
+	for _, c := range cases {
+		got := nextCycle(start, start.Add(c.took), interval, c.policy)
+		if want := start.Add(c.want); !got.Equal(want) {
+			t.Errorf("%s: expected next cycle at +%s, got +%s", c.name, c.want, got.Sub(start))
+		}
+	}
+}
+
+func TestCheckAll_Spread(t *testing.T) {
+	var mu sync.Mutex
+	var seen []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, time.Now())
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	services := []Service{{Name: "a", URL: srv.URL}, {Name: "b", URL: srv.URL}}
+	pool := checker.NewPool(2)
+	defer pool.Close()
+	results := checkAll(context.Background(), pool, srv.Client(), services, Config{}, 200*time.Millisecond, 0)
+
+	if !results[0].Up || !results[1].Up || results[1].Service.Name != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(seen) != 2 || seen[1].Sub(seen[0]) < 80*time.Millisecond {
+		t.Errorf("expected the second check to start about 100ms after the first, got %v", seen)
+	}
+}
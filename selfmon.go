@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// SelfMonitorConfig alerts on the bot's own problems: Slack updates failing
+// slack_failures cycles in a row, config writes failing, or a cycle taking
+// longer than the check interval. The problems are always shown on the
+// board; notifiers names the secondary notifiers (as in routing rules) that
+// are alerted too, since a broken Slack can't report itself.
+type SelfMonitorConfig struct {
+	Notifiers     []string `json:"notifiers"`
+	SlackFailures int      `json:"slack_failures"`
+}
+
+const defaultSlackFailures = 3
+
+func (c *SelfMonitorConfig) validate() error {
+	if err := validateTargets(c.Notifiers); err != nil {
+		return fmt.Errorf("self_monitoring.notifiers: %w", err)
+	}
+	if c.SlackFailures <= 0 {
+		c.SlackFailures = defaultSlackFailures
+	}
+	return nil
+}
+
+const (
+	problemSlack   = "slack"
+	problemConfig  = "config"
+	problemOverrun = "overrun"
+)
+
+// selfService stands in for the bot in the alerts it raises about itself.
+var selfService = Service{Name: "status-bot", Env: "self"}
+
+// selfMonitor tracks the bot's own problems. The zero value is ready to use.
+type selfMonitor struct {
+	mu            sync.Mutex
+	slackFailures int
+	problems      map[string]string
+	alerted       map[string]string
+}
+
+func (m *selfMonitor) set(kind string, problem string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.problems == nil {
+		m.problems = make(map[string]string)
+	}
+	if problem == "" {
+		delete(m.problems, kind)
+		return
+	}
+	m.problems[kind] = problem
+}
+
+// recordSlack counts consecutive failed Slack updates.
+func (m *selfMonitor) recordSlack(err error, threshold int) {
+	m.mu.Lock()
+	if err == nil {
+		m.slackFailures = 0
+	} else {
+		m.slackFailures++
+	}
+	failures := m.slackFailures
+	m.mu.Unlock()
+
+	if err == nil || failures < threshold {
+		m.set(problemSlack, "")
+		return
+	}
+	m.set(problemSlack, fmt.Sprintf("Slack updates failed %d cycles in a row: %v", failures, err))
+}
+
+func (m *selfMonitor) recordConfigWrite(err error) {
+	if err == nil {
+		m.set(problemConfig, "")
+		return
+	}
+	m.set(problemConfig, fmt.Sprintf("Writing the config failed: %v", err))
+}
+
+func (m *selfMonitor) recordCycle(took time.Duration, interval time.Duration) {
+	if interval <= 0 || took <= interval {
+		m.set(problemOverrun, "")
+		return
+	}
+	m.set(problemOverrun, fmt.Sprintf("The last cycle took %s, longer than the %s interval", formatDuration(took), formatDuration(interval)))
+}
+
+// current lists the open problems in a stable order.
+func (m *selfMonitor) current() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kinds := make([]string, 0, len(m.problems))
+	for kind := range m.problems {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	out := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		out = append(out, m.problems[kind])
+	}
+	return out
+}
+
+// changes returns transitions for problems that appeared or cleared since the
+// last call, as if the bot were one of its own services.
+func (m *selfMonitor) changes() []Transition {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.alerted == nil {
+		m.alerted = make(map[string]string)
+	}
+
+	var out []Transition
+	for kind, problem := range m.problems {
+		if _, ok := m.alerted[kind]; !ok {
+			out = append(out, Transition{Service: selfService, ServiceName: selfService.Name, Type: "down", Error: problem})
+		}
+		m.alerted[kind] = problem
+	}
+	for kind, problem := range m.alerted {
+		if _, ok := m.problems[kind]; !ok {
+			out = append(out, Transition{Service: selfService, ServiceName: selfService.Name, Type: "up", PrevError: problem})
+			delete(m.alerted, kind)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Error+out[i].PrevError < out[j].Error+out[j].PrevError })
+	return out
+}
+
+// alertSelf sends the bot's own problems to the secondary notifiers.
+func (b *Bot) alertSelf(ctx context.Context, now time.Time) {
+	transitions := b.self.changes()
+	if len(transitions) == 0 || len(b.cfg.SelfMonitor.Notifiers) == 0 {
+		return
+	}
+
+	for _, n := range b.notifiers {
+		if !routedTo(b.cfg.SelfMonitor.Notifiers, n.Name()) {
+			continue
+		}
+		if err := n.Notify(ctx, Cycle{At: now, Transitions: transitions}); err != nil {
+			slog.Error("failed to send self-monitoring alert", "notifier", n.Name(), "err", err)
+		}
+	}
+}
+
+// selfWarningBlock renders the open problems for the top of the board, or
+// returns nil when there are none.
+func selfWarningBlock(problems []string, theme Theme) slack.Block {
+	if len(problems) == 0 {
+		return nil
+	}
+	text := fmt.Sprintf("%s *Status bot problems*\n• %s", theme.DownEmoji, strings.Join(problems, "\n• "))
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSelfMonitorSlackThreshold(t *testing.T) {
+	var m selfMonitor
+	fail := errors.New("channel_not_found")
+
+	m.recordSlack(fail, 3)
+	m.recordSlack(fail, 3)
+	if p := m.current(); len(p) != 0 {
+		t.Errorf("expected no problem below the threshold, got %v", p)
+	}
+
+	m.recordSlack(fail, 3)
+	if p := m.current(); len(p) != 1 || !strings.Contains(p[0], "3 cycles in a row") {
+		t.Errorf("expected a Slack problem, got %v", p)
+	}
+
+	m.recordSlack(nil, 3)
+	if p := m.current(); len(p) != 0 {
+		t.Errorf("expected the problem to clear after a success, got %v", p)
+	}
+}
+
+func TestSelfMonitorChanges(t *testing.T) {
+	var m selfMonitor
+
+	m.recordCycle(90*time.Second, time.Minute)
+	m.recordConfigWrite(errors.New("read-only file system"))
+
+	changes := m.changes()
+	if len(changes) != 2 || changes[0].Type != "down" || changes[1].Type != "down" {
+		t.Fatalf("expected two down transitions, got %+v", changes)
+	}
+	if serviceKey(changes[0].Service) != serviceKey(selfService) {
+		t.Errorf("expected transitions for the bot itself, got %+v", changes[0].Service)
+	}
+
+	m.recordCycle(2*time.Minute, time.Minute)
+	if changes := m.changes(); len(changes) != 0 {
+		t.Errorf("expected open problems to alert once, got %+v", changes)
+	}
+
+	m.recordCycle(time.Second, time.Minute)
+	changes = m.changes()
+	if len(changes) != 1 || changes[0].Type != "up" || !strings.Contains(changes[0].PrevError, "longer than") {
+		t.Errorf("expected the overrun to recover, got %+v", changes)
+	}
+}
+
+func TestAlertSelfUsesSecondaryNotifiers(t *testing.T) {
+	b := newTestBot()
+	b.cfg.SelfMonitor = SelfMonitorConfig{Notifiers: []string{"email"}}
+
+	email, other := &recordingNotifier{}, &recordingNotifier{}
+	b.register(namedNotifier{"email", email})
+	b.register(namedNotifier{"discord", other})
+
+	b.self.recordConfigWrite(errors.New("disk full"))
+	b.alertSelf(context.Background(), time.Now())
+
+	if len(email.cycles) != 1 || len(email.cycles[0].Transitions) != 1 {
+		t.Fatalf("expected the email notifier to get the alert, got %+v", email.cycles)
+	}
+	if len(other.cycles) != 0 {
+		t.Errorf("expected other notifiers to be left alone")
+	}
+
+	b.alertSelf(context.Background(), time.Now())
+	if len(email.cycles) != 1 {
+		t.Errorf("expected no repeat alert")
+	}
+}
+
+func TestSelfWarningBlock(t *testing.T) {
+	if selfWarningBlock(nil, defaultTheme) != nil {
+		t.Errorf("expected no block without problems")
+	}
+
+	block, ok := selfWarningBlock([]string{"a", "b"}, defaultTheme).(*slack.SectionBlock)
+	if !ok {
+		t.Fatalf("expected a section block")
+	}
+	if !strings.Contains(block.Text.Text, "• a\n• b") {
+		t.Errorf("unexpected text %q", block.Text.Text)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sentryThrottle is how often the same error message is sent again while it
+// keeps being logged. Sentry counts the occurrences in between anyway.
+const sentryThrottle = 10 * time.Minute
+
+// sentryReporter sends error logs and panics to Sentry. It is configured from
+// SENTRY_DSN, with SENTRY_ENVIRONMENT tagging the events.
+type sentryReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	serverName  string
+	client      *http.Client
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+	closed   bool
+	events   chan sentryEvent
+	done     chan struct{}
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   []sentryException `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// newSentryReporter parses a DSN of the form https://key@host/project.
+func newSentryReporter(dsn string) (*sentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("parse SENTRY_DSN: %w", err)
+	}
+	key := u.User.Username()
+	project := strings.Trim(u.Path, "/")
+	if key == "" || project == "" || u.Host == "" {
+		return nil, fmt.Errorf("SENTRY_DSN must look like https://key@host/project")
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+
+	host, _ := os.Hostname()
+	r := &sentryReporter{
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=status-bot/1.0, sentry_key=%s", key),
+		environment: os.Getenv("SENTRY_ENVIRONMENT"),
+		serverName:  host,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		lastSent:    make(map[string]time.Time),
+		events:      make(chan sentryEvent, 64),
+		done:        make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+// setupSentry forwards error logs to Sentry when SENTRY_DSN is set. It
+// returns nil otherwise.
+func setupSentry() (*sentryReporter, error) {
+	dsn := os.Getenv("SENTRY_DSN")
+	if dsn == "" {
+		return nil, nil
+	}
+	r, err := newSentryReporter(dsn)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(&sentryHandler{Handler: slog.Default().Handler(), reporter: r}))
+	return r, nil
+}
+
+func (r *sentryReporter) run() {
+	defer close(r.done)
+	for e := range r.events {
+		if err := r.send(e); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to send event to sentry: %v\n", err)
+		}
+	}
+}
+
+// capture queues e unless the same message was sent recently. Events are
+// dropped rather than block the caller when Sentry falls behind.
+func (r *sentryReporter) capture(e sentryEvent, now time.Time) {
+	key := strings.Join(e.Fingerprint, "|")
+
+	e.EventID = newEventID()
+	e.Timestamp = now.UTC()
+	e.Platform = "go"
+	e.ServerName = r.serverName
+	e.Environment = r.environment
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.lastSent[key]; r.closed || ok && now.Sub(last) < sentryThrottle {
+		return
+	}
+	r.lastSent[key] = now
+
+	select {
+	case r.events <- e:
+	default:
+	}
+}
+
+// recoverPanic reports a panic in the calling goroutine, waits for the event
+// to go out and panics again. Use it deferred.
+func (r *sentryReporter) recoverPanic() {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if r != nil {
+		r.capture(sentryEvent{
+			Level:       "fatal",
+			Exception:   []sentryException{{Type: "panic", Value: fmt.Sprint(p)}},
+			Extra:       map[string]any{"stack": string(debug.Stack())},
+			Fingerprint: []string{"panic", fmt.Sprint(p)},
+		}, time.Now())
+		r.Close()
+	}
+	panic(p)
+}
+
+// Close sends the queued events, waiting at most a few seconds.
+func (r *sentryReporter) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+	case <-time.After(5 * time.Second):
+	}
+}
+
+func (r *sentryReporter) send(e sentryEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sentry returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// sentryHandler passes every record on to Handler and sends error records to
+// Sentry, with the record's attributes as context. Attributes named service,
+// env, board, notifier and period become tags.
+type sentryHandler struct {
+	slog.Handler
+	reporter *sentryReporter
+	attrs    []slog.Attr
+}
+
+var sentryTags = map[string]bool{"service": true, "env": true, "board": true, "notifier": true, "period": true}
+
+func (h *sentryHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if rec.Level >= slog.LevelError {
+		e := sentryEvent{
+			Level:       "error",
+			Logger:      "status-bot",
+			Message:     rec.Message,
+			Tags:        make(map[string]string),
+			Extra:       make(map[string]any),
+			Fingerprint: []string{rec.Message},
+		}
+
+		add := func(a slog.Attr) bool {
+			switch {
+			case a.Key == "err":
+				e.Exception = []sentryException{{Type: rec.Message, Value: a.Value.String()}}
+			case sentryTags[a.Key]:
+				e.Tags[a.Key] = a.Value.String()
+				e.Fingerprint = append(e.Fingerprint, a.Value.String())
+			default:
+				e.Extra[a.Key] = a.Value.String()
+			}
+			return true
+		}
+		for _, a := range h.attrs {
+			add(a)
+		}
+		rec.Attrs(add)
+
+		h.reporter.capture(e, rec.Time)
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h *sentryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sentryHandler{
+		Handler:  h.Handler.WithAttrs(attrs),
+		reporter: h.reporter,
+		attrs:    append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *sentryHandler) WithGroup(name string) slog.Handler {
+	return &sentryHandler{Handler: h.Handler.WithGroup(name), reporter: h.reporter, attrs: h.attrs}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewSentryReporterDSN(t *testing.T) {
+	r, err := newSentryReporter("https://abc123@sentry.example.com/sub/42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer r.Close()
+
+	if r.endpoint != "https://sentry.example.com/sub/api/42/store/" {
+		t.Errorf("unexpected endpoint %q", r.endpoint)
+	}
+	if !strings.Contains(r.auth, "sentry_key=abc123") {
+		t.Errorf("unexpected auth header %q", r.auth)
+	}
+
+	if _, err := newSentryReporter("https://sentry.example.com/42"); err == nil {
+		t.Errorf("expected an error for a DSN without a key")
+	}
+}
+
+func TestSentryHandlerReportsErrors(t *testing.T) {
+	var mu sync.Mutex
+	var events []sentryEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=key") {
+			t.Errorf("missing auth header")
+		}
+		var e sentryEvent
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	r, err := newSentryReporter(strings.Replace(srv.URL, "http://", "http://key@", 1) + "/7")
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	var out strings.Builder
+	logger := slog.New(&sentryHandler{Handler: slog.NewTextHandler(&out, nil), reporter: r}).With("board", "main")
+
+	logger.Info("board updated")
+	logger.Error("failed to post alert", "err", errors.New("channel_not_found"), "attempt", 2)
+	logger.Error("failed to post alert", "err", errors.New("channel_not_found"), "attempt", 3)
+	r.Close()
+
+	if !strings.Contains(out.String(), "board updated") || strings.Count(out.String(), "failed to post alert") != 2 {
+		t.Errorf("expected every record to reach the wrapped handler, got:\n%s", out.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("expected a single throttled event, got %d", len(events))
+	}
+	e := events[0]
+	if e.Message != "failed to post alert" || e.Level != "error" || e.Tags["board"] != "main" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if len(e.Exception) != 1 || e.Exception[0].Value != "channel_not_found" {
+		t.Errorf("expected the error as exception, got %+v", e.Exception)
+	}
+	if e.Extra["attempt"] != "2" {
+		t.Errorf("expected attributes as extra context, got %+v", e.Extra)
+	}
+}
+
+func TestSentryThrottle(t *testing.T) {
+	r := &sentryReporter{lastSent: make(map[string]time.Time), events: make(chan sentryEvent, 4)}
+	now := time.Now()
+
+	r.capture(sentryEvent{Fingerprint: []string{"a"}}, now)
+	r.capture(sentryEvent{Fingerprint: []string{"a"}}, now.Add(time.Minute))
+	r.capture(sentryEvent{Fingerprint: []string{"b"}}, now.Add(time.Minute))
+	r.capture(sentryEvent{Fingerprint: []string{"a"}}, now.Add(sentryThrottle+time.Minute))
+
+	if n := len(r.events); n != 3 {
+		t.Errorf("expected 3 queued events, got %d", n)
+	}
+}
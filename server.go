@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+)
+
+type Note struct {
+	Author string    `json:"author"`
+	Text   string    `json:"text"`
+	At     time.Time `json:"at"`
+}
+
+var (
+	errUnknownService = errors.New("unknown service")
+	errNoOpenIncident = errors.New("no open incident")
+)
+
+// findService resolves a service by name and, when the name is ambiguous
+// across environments, by env.
+func findService(services []Service, name string, env string) (Service, error) {
+	var matches []Service
+	for _, svc := range services {
+		if strings.EqualFold(svc.Name, name) && (env == "" || strings.EqualFold(svc.Env, env)) {
+			matches = append(matches, svc)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Service{}, errUnknownService
+	case 1:
+		return matches[0], nil
+	}
+	return Service{}, fmt.Errorf("%q exists in several environments, specify one", name)
+}
+
+// addNote attaches an operator note to the open incident of svc and echoes it
+// into the incident thread.
+func (b *Bot) addNote(svc Service, note Note) (Incident, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	incident := b.history.openIncident(serviceKey(svc))
+	if incident == nil {
+		return Incident{}, errNoOpenIncident
+	}
+	incident.Notes = append(incident.Notes, note)
+
+	if state := b.states[serviceKey(svc)]; state != nil && state.IncidentTS != "" {
+		msg := fmt.Sprintf("📝 %s  note from %s: %s", note.At.Format("15:04:05"), note.Author, note.Text)
+		if err := postIncidentReply(b.api, primaryChannel(b.boards, svc), state.IncidentTS, msg); err != nil {
+			slog.Error("failed to post note", "err", err)
+		}
+	}
+
+	return *incident, nil
+}
+
+// routes wires the HTTP API. See guard for who may call which route; with no
+// token configured, anything proxied from the bot's own host passes for
+// local.
+func (b *Bot) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/incidents/{service}/notes", b.guard(scopeAdmin, b.leaderOnly(b.handleAddNote)))
+	mux.HandleFunc("POST /api/v1/blackouts", b.guard(scopeAdmin, b.leaderOnly(b.handleBlackout)))
+	mux.HandleFunc("GET /api/v1/status", b.guard(scopeRead, b.handleStatus))
+	mux.HandleFunc("GET /api/v1/events", b.guard(scopeRead, b.handleEvents))
+	mux.HandleFunc("GET /badge/{file}", b.guard(scopeRead, b.handleBadge))
+	mux.HandleFunc("GET /feed.atom", b.guard(scopeRead, b.handleFeed))
+	mux.HandleFunc("GET /maintenance.ics", b.guard(scopeRead, b.handleCalendar))
+	mux.HandleFunc("POST /api/v1/ingest/alertmanager", b.guard(scopeIngest, b.leaderOnly(b.handleAlertmanager)))
+	mux.HandleFunc("POST /api/v1/ingest/cloudwatch", b.guard(scopeIngest, b.leaderOnly(b.handleCloudWatch)))
+	mux.HandleFunc("GET /api/v1/services", b.adminOnly(b.handleListServices))
+	mux.HandleFunc("POST /api/v1/services", b.adminOnly(b.leaderOnly(b.handleCreateService)))
+	mux.HandleFunc("PUT /api/v1/services/{service}", b.adminOnly(b.leaderOnly(b.handleUpdateService)))
+	mux.HandleFunc("POST /api/v1/services/{service}/disable", b.adminOnly(b.leaderOnly(b.handleSetDisabled(true))))
+	mux.HandleFunc("POST /api/v1/services/{service}/enable", b.adminOnly(b.leaderOnly(b.handleSetDisabled(false))))
+	mux.HandleFunc("DELETE /api/v1/services/{service}", b.adminOnly(b.leaderOnly(b.handleDeleteService)))
+	mux.HandleFunc("GET /metrics", b.guard(scopeRead, b.handleMetrics))
+
+	// Health checks stay open so probes don't need credentials.
+	mux.HandleFunc("GET /healthz", b.handleHealthz)
+	mux.HandleFunc("GET /readyz", b.handleReadyz)
+
+	// Profiles can expose internals, so they are only served when asked for.
+	if b.cfg.Pprof {
+		mux.HandleFunc("GET /debug/pprof/", b.guard(scopeAdmin, pprof.Index))
+		mux.HandleFunc("GET /debug/pprof/cmdline", b.guard(scopeAdmin, pprof.Cmdline))
+		mux.HandleFunc("GET /debug/pprof/profile", b.guard(scopeAdmin, pprof.Profile))
+		mux.HandleFunc("GET /debug/pprof/symbol", b.guard(scopeAdmin, pprof.Symbol))
+		mux.HandleFunc("GET /debug/pprof/trace", b.guard(scopeAdmin, pprof.Trace))
+	}
+	return mux
+}
+
+func (b *Bot) handleAddNote(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Author string `json:"author"`
+		Text   string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	if strings.TrimSpace(body.Text) == "" {
+		writeError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+
+	if body.Author == "" {
+		body.Author = "api"
+	}
+
+	svc, err := findService(b.services(), r.PathValue("service"), r.URL.Query().Get("env"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	incident, err := b.addNote(svc, Note{Author: body.Author, Text: body.Text, At: time.Now()})
+	if errors.Is(err, errNoOpenIncident) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, incident)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// serveHTTP runs the admin API until ctx is cancelled.
+func (b *Bot) serveHTTP(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           b.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+
+		// Event streams only end when their request context does.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	if len(b.cfg.APIAuth.Tokens) == 0 {
+		slog.Warn("no API tokens configured: write and ingest routes trust every caller on this host, including a reverse proxy in front of the bot")
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestBot(services ...Service) *Bot {
+	return &Bot{
+		cfg:     Config{Services: services},
+		states:  make(map[string]*ServiceState),
+		history: newHistory(),
+	}
+}
+
+func TestFindService(t *testing.T) {
+	services := []Service{
+		{Name: "api", Env: "production"},
+		{Name: "api", Env: "development"},
+		{Name: "auth", Env: "production"},
+	}
+
+	if svc, err := findService(services, "auth", ""); err != nil || svc.Env != "production" {
+		t.Errorf("expected auth (production), got %+v, %v", svc, err)
+	}
+
+	if _, err := findService(services, "api", ""); err == nil {
+		t.Errorf("expected an ambiguity error")
+	}
+
+	if svc, err := findService(services, "API", "development"); err != nil || svc.Env != "development" {
+		t.Errorf("expected api (development), got %+v, %v", svc, err)
+	}
+
+	if _, err := findService(services, "db", ""); err != errUnknownService {
+		t.Errorf("expected errUnknownService, got %v", err)
+	}
+}
+
+func TestHandleAddNote(t *testing.T) {
+	svc := Service{Name: "api", Env: "production"}
+	b := newTestBot(svc)
+	mux := b.routes()
+
+	post := func() *httptest.ResponseRecorder {
+		req := localRequest(http.MethodPost, "/api/v1/incidents/api/notes", strings.NewReader(`{"author":"ops","text":"expected: database migration"}`))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post(); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without an open incident, got %d", rec.Code)
+	}
+
+	b.history.Incidents = []Incident{{ServiceKey: "api:production", StartedAt: time.Now()}}
+
+	if rec := post(); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+
+	notes := b.history.Incidents[0].Notes
+	if len(notes) != 1 || notes[0].Text != "expected: database migration" || notes[0].Author != "ops" {
+		t.Errorf("unexpected notes: %+v", notes)
+	}
+}
+
+func TestPprofIsOptIn(t *testing.T) {
+	b := newTestBot()
+	get := func() int {
+		rec := httptest.NewRecorder()
+		b.routes().ServeHTTP(rec, localRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+		return rec.Code
+	}
+
+	if code := get(); code != http.StatusNotFound {
+		t.Errorf("expected pprof to be off by default, got %d", code)
+	}
+
+	b.cfg.Pprof = true
+	if code := get(); code != http.StatusOK {
+		t.Errorf("expected the goroutine profile, got %d", code)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Windows service support. Install the bot as a service that starts with the
+// machine, passing the flags it should run with:
+//
+//	status-bot service install --token-file C:\status-bot\token
+//	status-bot service start
+//
+// The service runs from the directory of the executable, so services.json
+// and the state files live next to it, and logs to the Application event log
+// unless LOG_OUTPUT says otherwise. stop and uninstall undo the above.
+
+// serviceName is the name the bot is installed under, and the source of its
+// event log entries.
+const serviceName = "status-bot"
+
+var serviceActions = []string{"install", "uninstall", "start", "stop"}
+
+// parseServiceCommand splits the arguments of the service command into the
+// action and, for install, the flags the service runs with.
+func parseServiceCommand(args []string) (string, []string, error) {
+	if len(args) == 0 || !slices.Contains(serviceActions, args[0]) {
+		return "", nil, fmt.Errorf("usage: status-bot service install|uninstall|start|stop")
+	}
+	action, rest := args[0], args[1:]
+	if action != "install" {
+		if len(rest) > 0 {
+			return "", nil, fmt.Errorf("service %s takes no arguments", action)
+		}
+		return action, nil, nil
+	}
+
+	_, commands, err := parseFlags(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(commands) > 0 {
+		return "", nil, fmt.Errorf("the service runs the bot continuously, not %q", commands[0])
+	}
+	return action, rest, nil
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errNotWindows = errors.New("only supported on Windows")
+
+func isWindowsService() bool {
+	return false
+}
+
+func openEventLog(source string) (eventLog, error) {
+	return nil, fmt.Errorf("event log: %w", errNotWindows)
+}
+
+func runService(opts runOptions) error {
+	return fmt.Errorf("windows service: %w", errNotWindows)
+}
+
+func controlService(args []string) error {
+	if _, _, err := parseServiceCommand(args); err != nil {
+		return err
+	}
+	return fmt.Errorf("windows service: %w", errNotWindows)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseServiceCommand(t *testing.T) {
+	action, flags, err := parseServiceCommand([]string{"install", "--channel", "C1", "--interval", "30s"})
+	if err != nil || action != "install" || !slices.Equal(flags, []string{"--channel", "C1", "--interval", "30s"}) {
+		t.Fatalf("expected install with its flags, got %q %q (%v)", action, flags, err)
+	}
+	if action, _, err := parseServiceCommand([]string{"stop"}); err != nil || action != "stop" {
+		t.Errorf("expected stop, got %q (%v)", action, err)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"restart"},
+		{"start", "now"},
+		{"install", "once"},
+		{"install", "--interval", "1.5s"},
+	} {
+		if _, _, err := parseServiceCommand(args); err == nil {
+			t.Errorf("expected %q to be rejected", args)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// isWindowsService reports whether the service control manager started the
+// bot.
+func isWindowsService() bool {
+	ok, err := svc.IsWindowsService()
+	return err == nil && ok
+}
+
+func openEventLog(source string) (eventLog, error) {
+	return eventlog.Open(source)
+}
+
+// windowsService runs the bot under the service control manager, stopping
+// it as a signal would.
+type windowsService struct {
+	opts runOptions
+}
+
+func (s windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, s.opts)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				slog.Error("fatal error", "err", err)
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				// The cycle in flight gets the shutdown grace to finish and
+				// the boards are marked offline after it.
+				wait := defaultShutdownGrace + 30*time.Second
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32(wait.Milliseconds())}
+				cancel()
+			}
+		}
+	}
+}
+
+// runService runs the bot as a Windows service until it is stopped.
+func runService(opts runOptions) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("find executable: %w", err)
+	}
+	if err := os.Chdir(filepath.Dir(exe)); err != nil {
+		return fmt.Errorf("change directory: %w", err)
+	}
+	return svc.Run(serviceName, windowsService{opts: opts})
+}
+
+// controlService implements the service command.
+func controlService(args []string) error {
+	action, flags, err := parseServiceCommand(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if action == "install" {
+		return installService(m, flags)
+	}
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("open service: %w", err)
+	}
+	defer s.Close()
+
+	switch action {
+	case "uninstall":
+		if err := s.Delete(); err != nil {
+			return fmt.Errorf("delete service: %w", err)
+		}
+		if err := eventlog.Remove(serviceName); err != nil {
+			return fmt.Errorf("remove event log source: %w", err)
+		}
+	case "start":
+		if err := s.Start(); err != nil {
+			return fmt.Errorf("start service: %w", err)
+		}
+	case "stop":
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("stop service: %w", err)
+		}
+	}
+	fmt.Printf("service %s: %s done\n", serviceName, action)
+	return nil
+}
+
+func installService(m *mgr.Mgr, flags []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("find executable: %w", err)
+	}
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "Slack status bot",
+		Description: "Checks services and keeps their status board in Slack up to date.",
+		StartType:   mgr.StartAutomatic,
+	}, flags...)
+	if err != nil {
+		return fmt.Errorf("create service: %w", err)
+	}
+	defer s.Close()
+
+	if err := eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		s.Delete()
+		return fmt.Errorf("register event log source: %w", err)
+	}
+	fmt.Printf("service %s: installed, running %s\n", serviceName, exe)
+	return nil
+}
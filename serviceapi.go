@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func (b *Bot) handleListServices(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.services())
+}
+
+func (b *Bot) handleCreateService(w http.ResponseWriter, r *http.Request) {
+	var svc Service
+	if err := json.NewDecoder(r.Body).Decode(&svc); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	b.storeService(w, "", svc, http.StatusCreated)
+}
+
+func (b *Bot) handleUpdateService(w http.ResponseWriter, r *http.Request) {
+	old, ok := b.pathService(w, r)
+	if !ok {
+		return
+	}
+	var svc Service
+	if err := json.NewDecoder(r.Body).Decode(&svc); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	b.storeService(w, serviceKey(old), svc, http.StatusOK)
+}
+
+// handleSetDisabled returns a handler that disables or re-enables a service.
+// A disabled service stays in the config but isn't checked or shown.
+func (b *Bot) handleSetDisabled(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, ok := b.pathService(w, r)
+		if !ok {
+			return
+		}
+		svc.Disabled = disabled
+		b.storeService(w, serviceKey(svc), svc, http.StatusOK)
+	}
+}
+
+func (b *Bot) handleDeleteService(w http.ResponseWriter, r *http.Request) {
+	svc, ok := b.pathService(w, r)
+	if !ok {
+		return
+	}
+	if err := b.removeService(serviceKey(svc)); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pathService resolves the {service} path value, with ?env= for names used
+// in several environments, and answers 404 when there's no such service.
+func (b *Bot) pathService(w http.ResponseWriter, r *http.Request) (Service, bool) {
+	svc, err := findService(b.services(), r.PathValue("service"), r.URL.Query().Get("env"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return Service{}, false
+	}
+	return svc, true
+}
+
+// storeService validates svc and saves it in place of the service identified
+// by oldKey, or as a new one.
+func (b *Bot) storeService(w http.ResponseWriter, oldKey string, svc Service, status int) {
+	if problems := b.validateService(&svc, oldKey); len(problems) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid service", "fields": problems})
+		return
+	}
+	if err := b.saveService(oldKey, svc); err != nil {
+		if errors.Is(err, errUnknownService) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, status, svc)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	b := newServiceTestBot(t, Service{Name: "api", Env: "production", URL: srv.URL})
+	b.client = srv.Client()
+	b.cfg.Concurrency = 1
+	b.cfg.APIAuth.Tokens = []APIToken{{Name: "ci", Scope: scopeAdmin, value: "s3cret"}}
+	mux := b.routes()
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/v1/services", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/v1/services", "", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/api/v1/services", `{"name":"auth","env":"production","url":"ftp://auth"}`, "s3cret")
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"url"`) {
+		t.Errorf("expected the bad url to be reported, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/api/v1/services", `{"name":"auth","env":"production","url":"https://auth.example.com"}`, "s3cret"); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPut, "/api/v1/services/auth", `{"name":"auth","env":"production","url":"https://auth2.example.com"}`, "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	b.states["auth:production"] = &ServiceState{IsDown: true}
+	if rec := do(http.MethodPost, "/api/v1/services/auth/disable", "", "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if b.states["auth:production"] != nil {
+		t.Errorf("expected the state of a disabled service to be dropped")
+	}
+	if results := b.checkServices(context.Background(), 0, 0); len(results) != 1 || results[0].Service.Name != "api" {
+		t.Errorf("expected the disabled service not to be checked, got %+v", results)
+	}
+
+	services := b.services()
+	if len(services) != 2 || services[1].URL != "https://auth2.example.com" || !services[1].Disabled {
+		t.Errorf("unexpected services %+v", services)
+	}
+
+	if rec := do(http.MethodDelete, "/api/v1/services/auth", "", "s3cret"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodDelete, "/api/v1/services/auth", "", "s3cret"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 once deleted, got %d", rec.Code)
+	}
+}
+
+func TestServiceAPIOffWithoutToken(t *testing.T) {
+	b := newTestBot()
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/services", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without an admin token, got %d", rec.Code)
+	}
+}
+
+func TestServiceAPIValidatesSettings(t *testing.T) {
+	b := newServiceTestBot(t, Service{Name: "api", Env: "production", URL: "https://api.example.com"})
+	b.cfg.AlertSchedules = map[string]AlertSchedule{"business": {}}
+	b.cfg.APIAuth.Tokens = []APIToken{{Name: "ci", Scope: scopeAdmin, value: "s3cret"}}
+	mux := b.routes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/services", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for field, body := range map[string]string{
+		"alert_schedule": `{"name":"auth","env":"production","url":"https://auth.example.com","alert_schedule":"weekends"}`,
+		"slo":            `{"name":"auth","env":"production","url":"https://auth.example.com","slo":120}`,
+		"maintenance":    `{"name":"auth","env":"production","url":"https://auth.example.com","maintenance":[{"cron":"not a schedule","duration_minutes":30}]}`,
+	} {
+		if rec := post(body); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), `"`+field+`"`) {
+			t.Errorf("expected a bad %s to be reported, got %d: %s", field, rec.Code, rec.Body)
+		}
+	}
+
+	rec := post(`{"name":"auth","env":"production","url":"https://auth.example.com","maintenance":[{"cron":"* * * * *","duration_minutes":30}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	services := b.services()
+	if len(services) != 2 || len(services[1].Maintenance) != 1 || !services[1].Maintenance[0].active(time.Now()) {
+		t.Errorf("expected the recurring window added over the API to be active, got %+v", services)
+	}
+}
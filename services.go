@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+var errServiceExists = errors.New("a service with this name already exists in that environment")
+
+// services returns the monitored services. The slice is replaced, never
+// modified in place, so callers may keep iterating it after the lock is gone.
+func (b *Bot) services() []Service {
+	b.servicesMu.RLock()
+	defer b.servicesMu.RUnlock()
+	return b.cfg.Services
+}
+
+// enabledServices returns the services that are checked and shown.
+func (b *Bot) enabledServices() []Service {
+	var services []Service
+	for _, svc := range b.services() {
+		if !svc.Disabled {
+			services = append(services, svc)
+		}
+	}
+	return services
+}
+
+// serviceFieldError is a problem with one setting of a service, named after
+// its field.
+type serviceFieldError struct {
+	field string
+	err   error
+}
+
+func (e *serviceFieldError) Error() string { return e.err.Error() }
+func (e *serviceFieldError) Unwrap() error { return e.err }
+
+// validate checks the settings of svc that don't depend on the other
+// services, and parses its maintenance schedules. loadConfig and
+// validateService both go through it, so a service saved from Slack or the
+// API loads on the next start.
+func (svc *Service) validate(schedules map[string]AlertSchedule) error {
+	invalid := func(field string, err error) error {
+		return &serviceFieldError{field: field, err: err}
+	}
+
+	if svc.Retries < 0 || svc.RetryDelayMs < 0 {
+		return invalid("retries", fmt.Errorf("retries and retry_delay_ms can't be negative"))
+	}
+	if svc.TimeoutMs < 0 {
+		return invalid("timeout_ms", fmt.Errorf("timeout_ms can't be negative"))
+	}
+	if svc.IntervalSeconds < 0 {
+		return invalid("interval_seconds", fmt.Errorf("interval_seconds can't be negative"))
+	}
+	if _, ok := schedules[svc.AlertSchedule]; svc.AlertSchedule != "" && !ok {
+		return invalid("alert_schedule", fmt.Errorf("unknown alert_schedule %q", svc.AlertSchedule))
+	}
+	if err := validateSource(*svc); err != nil {
+		return invalid("source", err)
+	}
+	if svc.SLO < 0 || svc.SLO >= 100 {
+		return invalid("slo", fmt.Errorf("slo must be between 0 and 100, got %g", svc.SLO))
+	}
+	for i := range svc.Maintenance {
+		if err := svc.Maintenance[i].validate(); err != nil {
+			return invalid("maintenance", fmt.Errorf("maintenance: %w", err))
+		}
+	}
+	return nil
+}
+
+// validateService checks a service submitted from Slack or the API, parsing
+// its maintenance schedules in place. oldKey is the key of the service being
+// edited, or empty for a new one.
+func (b *Bot) validateService(svc *Service, oldKey string) map[string]string {
+	problems := make(map[string]string)
+
+	if strings.TrimSpace(svc.Name) == "" {
+		problems["name"] = "A name is required"
+	}
+	if u, err := url.Parse(svc.URL); svc.Source == "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		problems["url"] = "Enter an http(s) URL"
+	}
+	var fieldErr *serviceFieldError
+	if err := svc.validate(b.cfg.AlertSchedules); errors.As(err, &fieldErr) {
+		problems[fieldErr.field] = fieldErr.Error()
+	}
+	if svc.Env == "" {
+		problems["env"] = "An environment is required"
+	} else if primaryChannel(b.boards, *svc) == "" {
+		problems["env"] = fmt.Sprintf("No board shows the %q environment", svc.Env)
+	}
+
+	key := serviceKey(*svc)
+	if key != oldKey && slices.ContainsFunc(b.services(), func(s Service) bool { return serviceKey(s) == key }) {
+		problems["name"] = errServiceExists.Error()
+	}
+	return problems
+}
+
+// saveService adds svc, or replaces the service identified by oldKey, and
+// writes the service list back to the config file.
+func (b *Bot) saveService(oldKey string, svc Service) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.servicesMu.Lock()
+	defer b.servicesMu.Unlock()
+
+	services := slices.Clone(b.cfg.Services)
+	i := slices.IndexFunc(services, func(s Service) bool { return serviceKey(s) == oldKey })
+	if oldKey != "" && i < 0 {
+		return errUnknownService
+	}
+
+	if i >= 0 {
+		services[i] = svc
+		if newKey := serviceKey(svc); newKey != oldKey {
+			if state := b.states[oldKey]; state != nil {
+				b.states[newKey] = state
+				delete(b.states, oldKey)
+			}
+		}
+	} else {
+		services = append(services, svc)
+	}
+
+	err := saveServices(b.configPath, services)
+	b.self.recordConfigWrite(err)
+	if err != nil {
+		return err
+	}
+	b.cfg.Services = services
+
+	// A disabled service starts afresh once enabled again.
+	if svc.Disabled {
+		delete(b.states, serviceKey(svc))
+	}
+	return nil
+}
+
+// removeService stops monitoring the service identified by key.
+func (b *Bot) removeService(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.servicesMu.Lock()
+	defer b.servicesMu.Unlock()
+
+	services := slices.DeleteFunc(slices.Clone(b.cfg.Services), func(s Service) bool { return serviceKey(s) == key })
+	if len(services) == len(b.cfg.Services) {
+		return errUnknownService
+	}
+	if len(services) == 0 {
+		return fmt.Errorf("can't remove the last service")
+	}
+
+	err := saveServices(b.configPath, services)
+	b.self.recordConfigWrite(err)
+	if err != nil {
+		return err
+	}
+	b.cfg.Services = services
+	delete(b.states, key)
+	return nil
+}
+
+// saveServices rewrites the "services" list of the config file, leaving the
+// other settings untouched.
+func saveServices(path string, services []Service) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+
+	encoded, err := json.Marshal(services)
+	if err != nil {
+		return fmt.Errorf("encode services: %w", err)
+	}
+	raw["services"] = encoded
+
+	out, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	return writeFileAtomic(path, append(out, '\n'))
+}
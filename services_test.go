@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func newServiceTestBot(t *testing.T, services ...Service) *Bot {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "services.json")
+	if err := os.WriteFile(path, []byte(`{"interval_seconds": 30, "services": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newTestBot(services...)
+	b.configPath = path
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	return b
+}
+
+func TestSaveAndRemoveService(t *testing.T) {
+	b := newServiceTestBot(t, Service{Name: "api", Env: "production", URL: "https://api.example.com"})
+	b.states["api:production"] = &ServiceState{FailCount: 2}
+
+	if err := b.saveService("", Service{Name: "auth", Env: "production", URL: "https://auth.example.com"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := b.saveService("api:production", Service{Name: "api", Env: "staging", URL: "https://api.example.com"}); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if b.states["api:staging"] == nil || b.states["api:production"] != nil {
+		t.Errorf("state should follow the renamed service")
+	}
+	if err := b.removeService("auth:production"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	data, err := os.ReadFile(b.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		IntervalSeconds int       `json:"interval_seconds"`
+		Services        []Service `json:"services"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("parse saved config: %v", err)
+	}
+	if saved.IntervalSeconds != 30 {
+		t.Errorf("other settings should be kept, got interval %d", saved.IntervalSeconds)
+	}
+	if len(saved.Services) != 1 || saved.Services[0].Env != "staging" {
+		t.Errorf("unexpected saved services %+v", saved.Services)
+	}
+
+	if err := b.removeService("api:staging"); err == nil {
+		t.Errorf("removing the last service should fail")
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	b := newServiceTestBot(t, Service{Name: "api", Env: "production", URL: "https://api.example.com"})
+	b.boards[0].Envs = []string{"production"}
+
+	problems := b.validateService(&Service{Name: "api", Env: "production", URL: "ftp://x"}, "")
+	if problems["name"] == "" || problems["url"] == "" {
+		t.Errorf("expected duplicate name and bad url, got %v", problems)
+	}
+
+	if problems := b.validateService(&Service{Name: "api", Env: "production", URL: "https://api.example.com/v2"}, "api:production"); len(problems) != 0 {
+		t.Errorf("editing a service in place should be valid, got %v", problems)
+	}
+
+	if problems := b.validateService(&Service{Name: "web", Env: "staging", URL: "https://web"}, ""); problems["env"] == "" {
+		t.Errorf("expected an error for an env no board shows")
+	}
+}
+
+func TestServiceFromModal(t *testing.T) {
+	var view slack.View
+	view.State = &slack.ViewState{Values: map[string]map[string]slack.BlockAction{
+		"name":    {"name": {Value: " web "}},
+		"url":     {"url": {Value: "https://web.example.com"}},
+		"env":     {"env": {Value: "production"}},
+		"owners":  {"owners": {Value: "U1, S2,"}},
+		"runbook": {"runbook": {Value: ""}},
+	}}
+
+	svc := serviceFromModal(view, Service{Tags: []string{"frontend"}})
+	if svc.Name != "web" || svc.Env != "production" || len(svc.Owners) != 2 || len(svc.Tags) != 1 {
+		t.Errorf("unexpected service %+v", svc)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+	"github.com/slack-go/slack"
+)
+
+// defaultShutdownGrace is how long shutdown waits for the cycle in flight
+// when shutdown_timeout_seconds isn't set.
+const defaultShutdownGrace = 30 * time.Second
+
+// shutdown stops the bot cleanly: it lets the cycle in flight finish, up to
+// grace before cancelling it, saves the state and marks every board offline
+// so a stopped bot doesn't leave an all-green board behind. No cycle runs
+// after it.
+func (b *Bot) shutdown(ctx context.Context, cancelCycles context.CancelFunc, grace time.Duration) {
+	idle := make(chan struct{})
+	go func() {
+		b.cycleMu.Lock()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-time.After(grace):
+		slog.Warn("cycle still running at shutdown, cancelling it", "grace", grace)
+		cancelCycles()
+		<-idle
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.persist(ctx, now, true)
+	for _, bc := range b.boards {
+		if b.unavailable[bc.Name] {
+			continue
+		}
+		blocks := append([]slack.Block{offlineBlock(now, b.cfg.clock, b.cfg.Theme)}, b.boardBlocks(bc.filter(b.results), bc, now)...)
+		if err := slackboard.Upsert(b.api, bc.Channel, bc.ref, blocks, 0); err != nil {
+			slog.Error("failed to mark board offline", "board", bc.Name, "err", err)
+		}
+	}
+}
+
+// offlineBlock is the banner put at the top of the board when the bot stops.
+// The next cycle after a restart draws the board without it.
+func offlineBlock(since time.Time, c clock, theme Theme) slack.Block {
+	text := fmt.Sprintf("%s *Monitoring paused (bot offline)* since %s. The statuses below are from the last check and are no longer updated.",
+		theme.DownEmoji, c.format(since))
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+func TestShutdown(t *testing.T) {
+	api, calls := newTestSlack(t)
+	dir := t.TempDir()
+	b := newTestBot(Service{Name: "api", Env: "production"})
+	b.api = api
+	b.recent = newRecentIncidents(5)
+	b.cfg.StateFile = filepath.Join(dir, "state.json")
+	b.cfg.Theme = defaultTheme
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1", ref: slackboard.NewRef(filepath.Join(dir, ".board_ts"))}}
+	if err := b.boards[0].ref.Set([]string{"1700000000.000001"}); err != nil {
+		t.Fatal(err)
+	}
+	b.results = []CheckResult{{Service: b.cfg.Services[0], Up: true}}
+
+	// A cycle in flight is waited for.
+	b.cycleMu.Lock()
+	finished := false
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		finished = true
+		b.cycleMu.Unlock()
+	}()
+	cancelled := false
+	b.shutdown(context.Background(), func() { cancelled = true }, time.Second)
+
+	if !finished || cancelled {
+		t.Errorf("expected shutdown to wait for the cycle, finished %v, cancelled %v", finished, cancelled)
+	}
+	if _, _, err := loadState(b.cfg.StateFile); err != nil {
+		t.Errorf("expected the state to be saved: %v", err)
+	}
+
+	got := calls()
+	if len(got) != 1 || got[0].Get("method") != "chat.update" || got[0].Get("ts") != "1700000000.000001" {
+		t.Fatalf("expected the board to be updated in place, got %v", got)
+	}
+	if blocks := got[0].Get("blocks"); !strings.Contains(blocks, "Monitoring paused (bot offline)") || !strings.Contains(blocks, "api") {
+		t.Errorf("expected the offline banner above the board, got %s", blocks)
+	}
+}
+
+func TestShutdownCancelsSlowCycle(t *testing.T) {
+	api, _ := newTestSlack(t)
+	b := newTestBot()
+	b.api = api
+	b.recent = newRecentIncidents(5)
+	b.cfg.StateFile = filepath.Join(t.TempDir(), "state.json")
+
+	b.cycleMu.Lock()
+	cancel := func() { go b.cycleMu.Unlock() }
+	start := time.Now()
+	b.shutdown(context.Background(), cancel, 20*time.Millisecond)
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("expected the cycle to be cancelled after the grace period, took %s", took)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SLAConfig tunes the alerts for services with an slo. A service is at risk
+// once no more than at_risk_percent of its monthly error budget is left, and
+// in breach once the budget is spent.
+type SLAConfig struct {
+	AtRiskPercent float64 `json:"at_risk_percent"`
+}
+
+func (c *SLAConfig) validate() error {
+	if c.AtRiskPercent < 0 || c.AtRiskPercent >= 100 {
+		return fmt.Errorf("sla.at_risk_percent must be between 0 and 100, got %g", c.AtRiskPercent)
+	}
+	if c.AtRiskPercent == 0 {
+		c.AtRiskPercent = 25
+	}
+	return nil
+}
+
+const (
+	slaAtRisk   = "at_risk"
+	slaBreached = "breached"
+)
+
+// slaStatus is where a service stands against its SLO this month. The error
+// budget is the downtime the SLO allows over the whole month; each failed
+// check spends one interval of it.
+type slaStatus struct {
+	Uptime    float64
+	Budget    time.Duration
+	Spent     time.Duration
+	Remaining float64 // share of the budget left, in percent
+}
+
+func (s slaStatus) level(cfg SLAConfig) string {
+	switch {
+	case s.Spent >= s.Budget:
+		return slaBreached
+	case s.Remaining <= cfg.AtRiskPercent:
+		return slaAtRisk
+	}
+	return ""
+}
+
+// monthlySLA computes the status of the service identified by key from the
+// start of the month of now.
+func monthlySLA(h *History, key string, slo float64, interval time.Duration, now time.Time) slaStatus {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+
+	var checks, failures int
+	for day := from; !day.After(now); day = day.AddDate(0, 0, 1) {
+		if stats := h.Days[day.Format(dayLayout)][key]; stats != nil {
+			checks += stats.Checks
+			failures += stats.Failures
+		}
+	}
+
+	s := slaStatus{
+		Uptime: 100,
+		Budget: time.Duration(float64(to.Sub(from)) * (100 - slo) / 100).Round(time.Second),
+		Spent:  time.Duration(failures) * interval,
+	}
+	if checks > 0 {
+		s.Uptime = float64(checks-failures) / float64(checks) * 100
+	}
+	if s.Budget > 0 {
+		s.Remaining = max(0, 100*(1-float64(s.Spent)/float64(s.Budget)))
+	}
+	return s
+}
+
+// sendSLAAlerts posts an alert on the boards of every service whose SLA
+// moved to at risk or breached this month. Each level is announced once per
+// month; alerts for muted services wait until the mute ends. Callers hold
+// b.mu.
+func (b *Bot) sendSLAAlerts(now time.Time) {
+	interval := time.Duration(b.cfg.IntervalSeconds) * time.Second
+	month := now.Format("2006-01")
+
+	for _, svc := range b.services() {
+		state := b.states[serviceKey(svc)]
+		if svc.SLO <= 0 || state == nil || state.muted(now) || !b.cfg.alerting(svc, now) {
+			continue
+		}
+
+		status := monthlySLA(b.history, serviceKey(svc), svc.SLO, interval, now)
+		level := status.level(b.cfg.SLA)
+		if level == "" || state.SLAAlert == month+" "+level || state.SLAAlert == month+" "+slaBreached {
+			continue
+		}
+
+		msg := renderSLAAlert(svc, level, status)
+		sent := false
+		for _, bc := range b.boards {
+			if !bc.matches(svc) {
+				continue
+			}
+			if err := b.postBoardNotice(bc, msg); err != nil {
+				slog.Error("failed to post SLA alert", "board", bc.Name, "service", svc.Name, "err", err)
+				continue
+			}
+			sent = true
+		}
+		if sent {
+			state.SLAAlert = month + " " + level
+		}
+	}
+}
+
+func renderSLAAlert(svc Service, level string, s slaStatus) string {
+	title := "📉 *SLA at risk*"
+	if level == slaBreached {
+		title = "🚨 *SLA breached*"
+	}
+	return fmt.Sprintf("%s: *%s (%s)* is at %.3f%% uptime this month against a %g%% SLO. Error budget left: %s of %s (%.0f%%).",
+		title, svc.Name, svc.Env, s.Uptime, svc.SLO, formatDuration(max(0, s.Budget-s.Spent)), formatDuration(s.Budget), s.Remaining)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonthlySLA(t *testing.T) {
+	h := newHistory()
+	now := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	h.Days["2024-04-02"] = map[string]*DayStats{"api:production": {Checks: 1000, Failures: 10}}
+	h.Days["2024-03-31"] = map[string]*DayStats{"api:production": {Checks: 1000, Failures: 1000}}
+
+	// April has 30 days, so a 99.9% SLO allows 43m12s of downtime.
+	s := monthlySLA(h, "api:production", 99.9, time.Minute, now)
+	if s.Budget != 43*time.Minute+12*time.Second {
+		t.Errorf("unexpected budget %v", s.Budget)
+	}
+	if s.Spent != 10*time.Minute {
+		t.Errorf("expected last month's failures to be ignored, spent %v", s.Spent)
+	}
+	if s.Uptime != 99 {
+		t.Errorf("unexpected uptime %v", s.Uptime)
+	}
+
+	cfg := SLAConfig{}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if got := s.level(cfg); got != "" {
+		t.Errorf("expected a healthy budget, got %q", got)
+	}
+
+	h.Days["2024-04-03"] = map[string]*DayStats{"api:production": {Checks: 100, Failures: 25}}
+	if got := monthlySLA(h, "api:production", 99.9, time.Minute, now).level(cfg); got != slaAtRisk {
+		t.Errorf("expected the SLA to be at risk, got %q", got)
+	}
+
+	h.Days["2024-04-04"] = map[string]*DayStats{"api:production": {Checks: 100, Failures: 10}}
+	if got := monthlySLA(h, "api:production", 99.9, time.Minute, now).level(cfg); got != slaBreached {
+		t.Errorf("expected the SLA to be breached, got %q", got)
+	}
+}
+
+func TestSendSLAAlerts(t *testing.T) {
+	api, calls := newTestSlack(t)
+	svc := Service{Name: "api", Env: "production", SLO: 99.9}
+
+	b := newTestBot(svc, Service{Name: "web", Env: "production"})
+	b.api = api
+	b.boards = []BoardConfig{{Name: "main", Channel: "C1"}}
+	b.cfg.AlertPlacement = placementChannel
+	b.cfg.IntervalSeconds = 60
+	b.cfg.SLA.validate()
+	b.states[serviceKey(svc)] = &ServiceState{}
+
+	now := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	b.history.Days["2024-04-02"] = map[string]*DayStats{"api:production": {Checks: 1000, Failures: 35}}
+
+	b.sendSLAAlerts(now)
+	b.sendSLAAlerts(now.Add(time.Minute))
+	sent := calls()
+	if len(sent) != 1 || !strings.Contains(sent[0].Get("text"), "SLA at risk") {
+		t.Fatalf("expected a single at-risk alert, got %v", sent)
+	}
+
+	b.history.Days["2024-04-03"] = map[string]*DayStats{"api:production": {Checks: 100, Failures: 10}}
+	b.sendSLAAlerts(now.Add(2 * time.Minute))
+	sent = calls()
+	if len(sent) != 2 || !strings.Contains(sent[1].Get("text"), "SLA breached") || !strings.Contains(sent[1].Get("text"), "0s of 43m") {
+		t.Fatalf("expected a breach alert, got %v", sent)
+	}
+
+	b.sendSLAAlerts(time.Date(2024, 5, 1, 0, 5, 0, 0, time.UTC))
+	if len(calls()) != 2 {
+		t.Errorf("expected a fresh month to start with a full budget")
+	}
+}
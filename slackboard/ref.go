@@ -0,0 +1,150 @@
+package slackboard
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// Ref is where a board lives: the timestamps of its messages. They are read
+// from the file once and kept in memory, and the file is only rewritten when
+// they change, so updating the board and replying in its thread don't touch
+// the disk.
+type Ref struct {
+	path string
+
+	mu     sync.Mutex
+	loaded bool
+	pages  []string
+
+	// shown is what each board message was last drawn with, so pages that
+	// didn't change aren't sent again. It isn't saved: the first update
+	// after a restart draws the whole board.
+	shown []shownPage
+}
+
+// NewRef returns the board whose timestamps are kept in path.
+func NewRef(path string) *Ref {
+	return &Ref{path: path}
+}
+
+// Path is the file the timestamps are kept in.
+func (r *Ref) Path() string {
+	return r.path
+}
+
+// TS returns the timestamp of the first board message, which hosts the alert
+// thread, or "" when the board hasn't been posted yet.
+func (r *Ref) TS() string {
+	pages := r.Pages()
+	if len(pages) == 0 {
+		return ""
+	}
+	return pages[0]
+}
+
+// Pages returns the timestamps of every board message.
+func (r *Ref) Pages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		r.pages = LoadPages(r.path)
+		r.loaded = true
+	}
+	return slices.Clone(r.pages)
+}
+
+// Set records the timestamps of every board message, saving them when they
+// differ from the ones already known.
+func (r *Ref) Set(pages []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loaded && slices.Equal(r.pages, pages) {
+		return nil
+	}
+	if err := SavePages(r.path, pages); err != nil {
+		// The file may hold either version now; read it again next time.
+		r.loaded = false
+		return err
+	}
+	r.pages = slices.Clone(pages)
+	r.loaded = true
+	return nil
+}
+
+// Clear forgets the board, so the next update posts it afresh.
+func (r *Ref) Clear() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pages = nil
+	r.loaded = true
+	r.shown = nil
+	if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Reload drops the timestamps kept in memory, for when the file was replaced
+// behind the board's back (e.g. restored from a backup).
+func (r *Ref) Reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pages = nil
+	r.loaded = false
+	r.shown = nil
+}
+
+func (r *Ref) shownPages() []shownPage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.shown)
+}
+
+// show records what the board messages were drawn with, or forgets it when
+// that isn't known for sure.
+func (r *Ref) show(shown []shownPage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shown = slices.Clone(shown)
+}
+
+// shownPage is a page as drawn at some point, whole and without its stamp
+// block.
+type shownPage struct {
+	full    string
+	content string
+	at      time.Time
+}
+
+// renderPages returns pages as drawn at at.
+func renderPages(pages [][]slack.Block, at time.Time) []shownPage {
+	shown := make([]shownPage, len(pages))
+	for i, page := range pages {
+		shown[i] = render(page, at)
+	}
+	return shown
+}
+
+func render(page []slack.Block, at time.Time) shownPage {
+	var content []slack.Block
+	for _, block := range page {
+		if block.ID() != StampBlockID {
+			content = append(content, block)
+		}
+	}
+	full, _ := json.Marshal(page)
+	rest, _ := json.Marshal(content)
+	return shownPage{full: string(full), content: string(rest), at: at}
+}
+
+// current reports whether a message showing s needn't be updated to show p:
+// nothing changed, or only the stamp did and s was drawn less than
+// minInterval before p.
+func (s shownPage) current(p shownPage, minInterval time.Duration) bool {
+	return s.full == p.full || (s.content == p.content && p.at.Sub(s.at) < minInterval)
+}
@@ -0,0 +1,70 @@
+package slackboard
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".board_ts")
+	if err := SavePages(path, []string{"1.1", "1.2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := NewRef(path)
+	if ts := ref.TS(); ts != "1.1" {
+		t.Fatalf("expected the first page, got %q", ts)
+	}
+
+	// Reads come from memory once the file was read.
+	if err := os.WriteFile(path, []byte("2.1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ts := ref.TS(); ts != "1.1" {
+		t.Errorf("expected the timestamp kept in memory, got %q", ts)
+	}
+	ref.Reload()
+	if ts := ref.TS(); ts != "2.1" {
+		t.Errorf("expected the file to be read again after a reload, got %q", ts)
+	}
+
+	// Unchanged timestamps aren't written again.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ref.Set([]string{"2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected unchanged timestamps not to be saved, got %v", err)
+	}
+	if err := ref.Set([]string{"3.1", "3.2"}); err != nil {
+		t.Fatal(err)
+	}
+	if pages := LoadPages(path); !slices.Equal(pages, []string{"3.1", "3.2"}) {
+		t.Errorf("expected the new timestamps to be saved, got %v", pages)
+	}
+
+	if err := ref.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); ref.TS() != "" || !os.IsNotExist(err) {
+		t.Errorf("expected a cleared board to be forgotten, got %q (%v)", ref.TS(), err)
+	}
+}
+
+func TestSavePages_LeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := SavePages(filepath.Join(dir, ".board_ts"), []string{"1.1"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != ".board_ts" {
+		t.Errorf("expected only the timestamp file, got %v", entries)
+	}
+}
@@ -0,0 +1,203 @@
+// Package slackboard keeps a status board in Slack: one or more messages
+// that are edited in place on every cycle, with their timestamps saved to a
+// file so the board survives restarts.
+package slackboard
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// MaxBlocks is Slack's limit on blocks per message.
+const MaxBlocks = 50
+
+// LoadTS returns the timestamp of the first board message, which hosts the
+// alert thread.
+func LoadTS(path string) string {
+	pages := LoadPages(path)
+	if len(pages) == 0 {
+		return ""
+	}
+	return pages[0]
+}
+
+// SaveTS saves a single-message board.
+func SaveTS(path string, ts string) error {
+	return SavePages(path, []string{ts})
+}
+
+// LoadPages returns the timestamps of every board message, one per line.
+func LoadPages(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// SavePages saves the timestamps of every board message. They are written to
+// a temporary file that then replaces path, so a crash mid-write can't leave
+// the board without its timestamps.
+func SavePages(path string, timestamps []string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(strings.Join(timestamps, "\n")); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Paginate splits a board that doesn't fit in one message into pages, each
+// starting with a "page n/m" context block.
+func Paginate(blocks []slack.Block) [][]slack.Block {
+	if len(blocks) <= MaxBlocks {
+		return [][]slack.Block{blocks}
+	}
+
+	perPage := MaxBlocks - 1
+	total := (len(blocks) + perPage - 1) / perPage
+
+	var pages [][]slack.Block
+	for i := 0; i < len(blocks); i += perPage {
+		page := []slack.Block{slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Board page %d/%d", len(pages)+1, total), false, false),
+		)}
+		page = append(page, blocks[i:min(i+perPage, len(blocks))]...)
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// StampBlockID marks the block that only tells when the board was drawn,
+// like its "Updated:" line. A page whose other blocks didn't change isn't
+// edited for it more often than the minInterval given to Upsert.
+const StampBlockID = "board_stamp"
+
+// Upsert updates the board messages in place, posting extra pages when the
+// board grew and deleting the ones it no longer needs. The board is posted
+// afresh when ref holds no timestamps yet. Pages that look the same as when
+// they were last drawn aren't sent again, to spare Slack's rate limit.
+func Upsert(api *slack.Client, channelID string, ref *Ref, blocks []slack.Block, minInterval time.Duration) error {
+	pages := Paginate(blocks)
+	timestamps := ref.Pages()
+
+	if len(timestamps) == 0 {
+		return post(api, channelID, ref, pages)
+	}
+
+	shown := ref.shownPages()
+	drawn := renderPages(pages, time.Now())
+	// Until every page is through, what the messages show isn't known.
+	ref.show(nil)
+
+	for i, page := range pages {
+		if i >= len(timestamps) {
+			_, ts, err := api.PostMessage(channelID, slack.MsgOptionBlocks(page...))
+			if err != nil {
+				ref.Set(timestamps)
+				return fmt.Errorf("post page %d: %w", i+1, err)
+			}
+			timestamps = append(timestamps, ts)
+			continue
+		}
+
+		if i < len(shown) && shown[i].current(drawn[i], minInterval) {
+			drawn[i] = shown[i]
+			continue
+		}
+
+		_, _, _, err := api.UpdateMessage(channelID, timestamps[i], slack.MsgOptionBlocks(page...))
+		if err != nil && !Gone(err) {
+			return fmt.Errorf("update message: %w", err)
+		}
+		if err != nil {
+			// Pages are reposted from the broken one onward so they stay in
+			// order. Old messages may still exist (e.g. they became
+			// uneditable); don't leave them or a stale pin behind.
+			for _, ts := range timestamps[i+1:] {
+				api.DeleteMessage(channelID, ts)
+			}
+			if i == 0 {
+				api.RemovePin(channelID, slack.NewRefToMessage(channelID, timestamps[0]))
+				return post(api, channelID, ref, pages)
+			}
+			timestamps = timestamps[:i]
+			_, ts, err := api.PostMessage(channelID, slack.MsgOptionBlocks(page...))
+			if err != nil {
+				ref.Set(timestamps)
+				return fmt.Errorf("post page %d: %w", i+1, err)
+			}
+			timestamps = append(timestamps, ts)
+		}
+	}
+
+	for _, ts := range timestamps[len(pages):] {
+		if _, _, err := api.DeleteMessage(channelID, ts); err != nil {
+			slog.Error("failed to delete extra board page", "err", err)
+		}
+	}
+	if err := ref.Set(timestamps[:len(pages)]); err != nil {
+		return err
+	}
+	ref.show(drawn)
+	return nil
+}
+
+// Gone reports whether an update failed because the board message can no
+// longer be edited, as opposed to a rate limit or outage that a retry on the
+// next cycle will get past.
+func Gone(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return false
+	}
+	switch slackErr.Err {
+	case "message_not_found", "cant_update_message", "edit_window_closed":
+		return true
+	}
+	return false
+}
+
+// post posts a fresh board and pins its first message, so newcomers find it
+// in the channel details. A failed pin (e.g. missing pins:write) is only
+// logged.
+func post(api *slack.Client, channelID string, ref *Ref, pages [][]slack.Block) error {
+	var timestamps []string
+	for i, page := range pages {
+		_, ts, err := api.PostMessage(channelID, slack.MsgOptionBlocks(page...))
+		if err != nil {
+			if len(timestamps) > 0 {
+				ref.Set(timestamps)
+			}
+			return fmt.Errorf("post message: %w", err)
+		}
+		timestamps = append(timestamps, ts)
+
+		if i == 0 {
+			if err := api.AddPin(channelID, slack.NewRefToMessage(channelID, ts)); err != nil {
+				slog.Error("failed to pin board", "err", err)
+			}
+		}
+	}
+
+	if err := ref.Set(timestamps); err != nil {
+		return err
+	}
+	ref.show(renderPages(pages, time.Now()))
+	return nil
+}
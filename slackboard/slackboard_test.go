@@ -0,0 +1,186 @@
+package slackboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// newTestSlack returns a client for a fake Slack API that accepts every call,
+// and a function listing the calls made so far.
+func newTestSlack(t *testing.T) (*slack.Client, func() []url.Values) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []url.Values
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		form := r.Form
+		form.Set("method", r.URL.Path[1:])
+		calls = append(calls, form)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":"1700000000.%06d"}`, form.Get("channel"), len(calls))
+	}))
+	t.Cleanup(srv.Close)
+
+	api := slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))
+	return api, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]url.Values(nil), calls...)
+	}
+}
+
+func TestBoardTS(t *testing.T) {
+	path := t.TempDir() + "/.board_ts"
+	if ts := LoadTS(path); ts != "" {
+		t.Fatalf("expected no board yet, got %q", ts)
+	}
+	if err := SavePages(path, []string{"1.1", "1.2"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if ts := LoadTS(path); ts != "1.1" {
+		t.Errorf("expected the first page, got %q", ts)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	blocks := make([]slack.Block, 120)
+	for i := range blocks {
+		blocks[i] = slack.NewDividerBlock()
+	}
+
+	pages := Paginate(blocks)
+	if len(pages) != 3 {
+		t.Fatalf("expected 3 pages, got %d", len(pages))
+	}
+	total := 0
+	for _, page := range pages {
+		if len(page) > MaxBlocks {
+			t.Errorf("page has %d blocks", len(page))
+		}
+		total += len(page) - 1
+	}
+	if total != len(blocks) {
+		t.Errorf("expected every block to be kept, got %d", total)
+	}
+
+	if got := Paginate(blocks[:10]); len(got) != 1 || len(got[0]) != 10 {
+		t.Errorf("a small board should stay a single unmarked message")
+	}
+}
+
+func TestUpsertPages(t *testing.T) {
+	api, calls := newTestSlack(t)
+	tsPath := t.TempDir() + "/.board_ts"
+	ref := NewRef(tsPath)
+
+	big := make([]slack.Block, 60)
+	for i := range big {
+		big[i] = slack.NewDividerBlock()
+	}
+
+	if err := Upsert(api, "C1", ref, big, 0); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if pages := LoadPages(tsPath); len(pages) != 2 {
+		t.Fatalf("expected 2 board messages, got %v", pages)
+	}
+
+	before := len(calls())
+	if err := Upsert(api, "C1", ref, big[:5], 0); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	got := calls()[before:]
+	if len(got) != 2 || got[0].Get("method") != "chat.update" || got[1].Get("method") != "chat.delete" {
+		t.Fatalf("expected an update and a delete, got %v", got)
+	}
+	if pages := LoadPages(tsPath); len(pages) != 1 {
+		t.Errorf("expected a single board message left, got %v", pages)
+	}
+}
+
+func TestUpsert_SkipsUnchanged(t *testing.T) {
+	api, calls := newTestSlack(t)
+	ref := NewRef(t.TempDir() + "/.board_ts")
+
+	board := func(updated string, status string) []slack.Block {
+		return []slack.Block{
+			slack.NewContextBlock(StampBlockID, slack.NewTextBlockObject(slack.MarkdownType, updated, false, false)),
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, status, false, false), nil, nil),
+		}
+	}
+	updates := func(from int) int {
+		n := 0
+		for _, c := range calls()[from:] {
+			if c.Get("method") == "chat.update" {
+				n++
+			}
+		}
+		return n
+	}
+
+	if err := Upsert(api, "C1", ref, board("Updated: 10:00", "api is up"), time.Hour); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	before := len(calls())
+	if err := Upsert(api, "C1", ref, board("Updated: 10:00", "api is up"), time.Hour); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := Upsert(api, "C1", ref, board("Updated: 10:01", "api is up"), time.Hour); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if n := updates(before); n != 0 {
+		t.Errorf("expected an unchanged board not to be updated, got %d updates", n)
+	}
+
+	if err := Upsert(api, "C1", ref, board("Updated: 10:02", "api is down"), time.Hour); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if n := updates(before); n != 1 {
+		t.Errorf("expected a changed board to be updated, got %d updates", n)
+	}
+
+	before = len(calls())
+	if err := Upsert(api, "C1", ref, board("Updated: 10:03", "api is down"), 0); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if n := updates(before); n != 1 {
+		t.Errorf("expected the stamp to be updated once min interval passed, got %d updates", n)
+	}
+
+	ref.Reload()
+	before = len(calls())
+	if err := Upsert(api, "C1", ref, board("Updated: 10:03", "api is down"), time.Hour); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if n := updates(before); n != 1 {
+		t.Errorf("expected a board not drawn since loading to be updated, got %d updates", n)
+	}
+}
+
+func TestGone(t *testing.T) {
+	if !Gone(slack.SlackErrorResponse{Err: "message_not_found"}) {
+		t.Errorf("a deleted board should be reposted")
+	}
+	if Gone(&slack.RateLimitedError{RetryAfter: time.Second}) {
+		t.Errorf("a rate limit shouldn't repost the board")
+	}
+	if Gone(errors.New("connection reset")) {
+		t.Errorf("a network error shouldn't repost the board")
+	}
+}
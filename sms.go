@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SMSConfig texts the numbers in to through Twilio once a service in one of
+// envs (production by default) has been down for after_minutes without
+// anyone acknowledging it. It is meant as the last escalation tier.
+type SMSConfig struct {
+	Enabled      bool     `json:"enabled"`
+	From         string   `json:"from"`
+	To           []string `json:"to"`
+	AfterMinutes int      `json:"after_minutes"`
+	Envs         []string `json:"envs"`
+}
+
+const defaultSMSAfter = 30
+
+func (c *SMSConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.From == "" || len(c.To) == 0 {
+		return fmt.Errorf("sms.from and sms.to are required")
+	}
+	if c.AfterMinutes <= 0 {
+		c.AfterMinutes = defaultSMSAfter
+	}
+	if len(c.Envs) == 0 {
+		c.Envs = []string{"production"}
+	}
+	return nil
+}
+
+// dueSMS returns the services that qualify for an SMS: down past the
+// threshold, unacknowledged, not muted or in maintenance, and not texted
+// about yet.
+func dueSMS(cfg SMSConfig, services []Service, states map[string]*ServiceState, now time.Time) []Service {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	after := time.Duration(cfg.AfterMinutes) * time.Minute
+	var due []Service
+	for _, svc := range services {
+		if !slices.Contains(cfg.Envs, svc.Env) {
+			continue
+		}
+		state := states[serviceKey(svc)]
+		if state == nil || !state.IsDown || state.SMSSent || state.AckedBy != "" || state.muted(now) || inMaintenance(svc, now) {
+			continue
+		}
+		if now.Sub(state.DownSince) >= after {
+			due = append(due, svc)
+		}
+	}
+	return due
+}
+
+func (b *Bot) sendSMS(ctx context.Context, now time.Time) {
+	if b.sms == nil {
+		return
+	}
+
+	for _, svc := range dueSMS(b.cfg.SMS, b.cfg.Services, b.states, now) {
+		if !b.cfg.alerting(svc, now) {
+			continue
+		}
+		state := b.states[serviceKey(svc)]
+		body := fmt.Sprintf("%s (%s) has been down for %s and nobody acknowledged it: %s",
+			svc.Name, svc.Env, formatDuration(now.Sub(state.DownSince)), state.LastError)
+
+		var sent bool
+		for _, to := range b.cfg.SMS.To {
+			if err := b.sms.send(ctx, b.cfg.SMS.From, to, body); err != nil {
+				slog.Error("failed to send sms", "to", to, "err", err)
+				continue
+			}
+			sent = true
+		}
+		// Retry next cycle only if nobody could be reached.
+		state.SMSSent = sent
+	}
+}
+
+type twilioClient struct {
+	accountSID string
+	authToken  string
+	baseURL    string
+	client     *http.Client
+}
+
+func newTwilioClient() (*twilioClient, error) {
+	sid, token := os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN")
+	if sid == "" || token == "" {
+		return nil, fmt.Errorf("TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN must be set")
+	}
+	return &twilioClient{
+		accountSID: sid,
+		authToken:  token,
+		baseURL:    "https://api.twilio.com",
+		client:     &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}, nil
+}
+
+func (c *twilioClient) send(ctx context.Context, from string, to string, body string) error {
+	form := url.Values{}
+	form.Set("From", from)
+	form.Set("To", to)
+	form.Set("Body", body)
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", c.baseURL, c.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("twilio returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDueSMS(t *testing.T) {
+	now := time.Now()
+	cfg := SMSConfig{Enabled: true, AfterMinutes: 30, Envs: []string{"production"}}
+	services := []Service{
+		{Name: "api", Env: "production"},
+		{Name: "auth", Env: "production"},
+		{Name: "db", Env: "production"},
+		{Name: "web", Env: "production"},
+		{Name: "api", Env: "staging"},
+	}
+	states := map[string]*ServiceState{
+		"api:production":  {IsDown: true, DownSince: now.Add(-31 * time.Minute)},
+		"auth:production": {IsDown: true, DownSince: now.Add(-5 * time.Minute)},
+		"db:production":   {IsDown: true, DownSince: now.Add(-time.Hour), AckedBy: "U1"},
+		"web:production":  {IsDown: true, DownSince: now.Add(-time.Hour), SMSSent: true},
+		"api:staging":     {IsDown: true, DownSince: now.Add(-time.Hour)},
+	}
+
+	due := dueSMS(cfg, services, states, now)
+	if len(due) != 1 || serviceKey(due[0]) != "api:production" {
+		t.Fatalf("expected only production api to be texted about, got %+v", due)
+	}
+}
+
+func TestSendSMS(t *testing.T) {
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/2010-04-01/Accounts/AC1/Messages.json" || user != "AC1" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		forms = append(forms, r.PostForm)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	b := newTestBot(Service{Name: "api", Env: "production"})
+	b.cfg.SMS = SMSConfig{Enabled: true, From: "+15550000", To: []string{"+15551111"}, AfterMinutes: 30, Envs: []string{"production"}}
+	b.states["api:production"] = &ServiceState{IsDown: true, DownSince: now.Add(-time.Hour), LastError: "timeout"}
+	b.sms = &twilioClient{accountSID: "AC1", authToken: "secret", baseURL: srv.URL, client: srv.Client()}
+
+	b.sendSMS(context.Background(), now)
+	b.sendSMS(context.Background(), now.Add(time.Minute))
+
+	if len(forms) != 1 {
+		t.Fatalf("expected a single SMS, got %d", len(forms))
+	}
+	if forms[0].Get("To") != "+15551111" || forms[0].Get("From") != "+15550000" {
+		t.Errorf("unexpected SMS %v", forms[0])
+	}
+	if !b.states["api:production"].SMSSent {
+		t.Errorf("expected the service to be marked as texted")
+	}
+}
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+// Round is the outcome of checking every target once.
+type Round struct {
+	Time    time.Time
+	Results []checker.Result
+	Changes []Change
+}
+
+// Sink receives every round, e.g. to update a board or send alerts.
+type Sink interface {
+	Publish(ctx context.Context, r Round) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, r Round) error
+
+func (f SinkFunc) Publish(ctx context.Context, r Round) error {
+	return f(ctx, r)
+}
+
+// Engine checks Targets on an interval, tracks their status and hands each
+// round to Sink, for programs that embed the checker.
+//
+// The bot itself doesn't run on Engine or Tracker: its cycle also handles
+// maintenance windows, per-service loops and confirmation checks, and keeps
+// more per service than Status holds. It shares the transition rules through
+// Status.Observe instead.
+type Engine struct {
+	Client  *http.Client
+	Targets []checker.Target
+	Options checker.Options
+	Tracker *Tracker
+	Sink    Sink
+
+	// Pool, when set, runs the checks. Otherwise each round starts a pool
+	// of its own.
+	Pool *checker.Pool
+
+	// Interval is the time between the starts of two rounds.
+	Interval time.Duration
+}
+
+// RunOnce checks every target once and publishes the round.
+func (e *Engine) RunOnce(ctx context.Context) error {
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if e.Tracker == nil {
+		e.Tracker = NewTracker(1, 1)
+	}
+
+	var results []checker.Result
+	if e.Pool != nil {
+		results = e.Pool.CheckAll(ctx, client, e.Targets, e.Options)
+	} else {
+		results = checker.CheckAll(ctx, client, e.Targets, e.Options)
+	}
+	now := time.Now()
+	round := Round{Time: now, Results: results, Changes: e.Tracker.Observe(results, now)}
+	if e.Sink == nil {
+		return nil
+	}
+	if err := e.Sink.Publish(ctx, round); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	return nil
+}
+
+// Run runs rounds every Interval until ctx is done. A failed publish is
+// logged and the next round runs anyway.
+func (e *Engine) Run(ctx context.Context) error {
+	if e.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+	for {
+		if err := e.RunOnce(ctx); err != nil {
+			slog.Error("round error", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
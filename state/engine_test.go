@@ -0,0 +1,43 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+func TestEngineRunOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	var rounds []Round
+	e := &Engine{
+		Client:  srv.Client(),
+		Targets: []checker.Target{{Name: "api", URL: srv.URL}},
+		Tracker: NewTracker(2, 1),
+		Sink: SinkFunc(func(ctx context.Context, r Round) error {
+			rounds = append(rounds, r)
+			return nil
+		}),
+	}
+
+	for range 2 {
+		if err := e.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	if len(rounds) != 2 || len(rounds[0].Changes) != 0 || len(rounds[1].Changes) != 1 || rounds[1].Changes[0].Error != "http_502" {
+		t.Fatalf("expected the second round to take api down, got %+v", rounds)
+	}
+
+	e.Sink = SinkFunc(func(ctx context.Context, r Round) error { return errors.New("slack is down") })
+	if err := e.RunOnce(context.Background()); err == nil {
+		t.Errorf("expected the sink error to be returned")
+	}
+}
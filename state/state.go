@@ -0,0 +1,124 @@
+// Package state turns check results into up/down transitions. A target goes
+// down after a run of failed checks and comes back up after a run of passed
+// ones, so single blips don't alert.
+package state
+
+import (
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+// Change types.
+const (
+	Down = "down"
+	Up   = "up"
+
+	// Changed is a target that is still down but now fails differently,
+	// e.g. a timeout that became a 502.
+	Changed = "change"
+)
+
+// Status is what the bot remembers about a target between checks.
+type Status struct {
+	IsDown    bool
+	FailCount int
+	PassCount int
+	DownSince time.Time
+	LastError string
+}
+
+// Change is a transition of one target.
+type Change struct {
+	Key       string
+	Type      string
+	Error     string
+	PrevError string
+
+	// DownFor is how long the target has been down: the whole outage when
+	// it came back up.
+	DownFor time.Duration
+}
+
+// Observe records a check that passed or failed with errMsg at now and
+// returns the change it caused, if any.
+func (s *Status) Observe(up bool, errMsg string, now time.Time, failThreshold int, recoveryThreshold int) (Change, bool) {
+	if up {
+		var change Change
+		changed := false
+		if s.IsDown {
+			s.PassCount++
+			if s.PassCount < recoveryThreshold {
+				return Change{}, false
+			}
+			change = Change{Type: Up}
+			if !s.DownSince.IsZero() {
+				change.DownFor = now.Sub(s.DownSince)
+			}
+			changed = true
+			s.IsDown = false
+			s.DownSince = time.Time{}
+		}
+		s.FailCount = 0
+		s.PassCount = 0
+		s.LastError = ""
+		return change, changed
+	}
+
+	var change Change
+	changed := false
+	s.FailCount++
+	s.PassCount = 0
+	if !s.IsDown && s.FailCount >= failThreshold {
+		change = Change{Type: Down, Error: errMsg}
+		changed = true
+		s.IsDown = true
+		s.DownSince = now
+	} else if s.IsDown && errMsg != s.LastError {
+		change = Change{Type: Changed, Error: errMsg, PrevError: s.LastError, DownFor: now.Sub(s.DownSince)}
+		changed = true
+	}
+	s.LastError = errMsg
+	return change, changed
+}
+
+// Tracker keeps the status of every target it has seen.
+type Tracker struct {
+	// FailThreshold failed checks in a row take a target down and
+	// RecoveryThreshold passed ones bring it back up. Zero means one.
+	FailThreshold     int
+	RecoveryThreshold int
+
+	Statuses map[string]*Status
+}
+
+// NewTracker returns a tracker with no targets seen yet.
+func NewTracker(failThreshold int, recoveryThreshold int) *Tracker {
+	return &Tracker{
+		FailThreshold:     failThreshold,
+		RecoveryThreshold: recoveryThreshold,
+		Statuses:          map[string]*Status{},
+	}
+}
+
+// Observe records results checked at now and returns what changed.
+func (t *Tracker) Observe(results []checker.Result, now time.Time) []Change {
+	if t.Statuses == nil {
+		t.Statuses = map[string]*Status{}
+	}
+
+	var changes []Change
+	for _, r := range results {
+		key := r.Target.Key()
+		s, ok := t.Statuses[key]
+		if !ok {
+			s = &Status{}
+			t.Statuses[key] = s
+		}
+		if c, ok := s.Observe(r.Up, r.Error, now, max(t.FailThreshold, 1), max(t.RecoveryThreshold, 1)); ok {
+			c.Key = key
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/checker"
+)
+
+func TestStatusObserve(t *testing.T) {
+	var s Status
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if _, ok := s.Observe(false, "http_503", now, 3, 2); ok {
+			t.Fatalf("failure %d shouldn't change anything yet", i+1)
+		}
+	}
+	c, ok := s.Observe(false, "http_503", now, 3, 2)
+	if !ok || c.Type != Down || c.Error != "http_503" || !s.IsDown || !s.DownSince.Equal(now) {
+		t.Fatalf("expected down on the third failure, got %+v %+v", c, s)
+	}
+
+	c, ok = s.Observe(false, "request failed", now.Add(time.Minute), 3, 2)
+	if !ok || c.Type != Changed || c.PrevError != "http_503" || c.DownFor != time.Minute {
+		t.Fatalf("expected a change of error, got %+v", c)
+	}
+
+	if _, ok := s.Observe(true, "", now.Add(2*time.Minute), 3, 2); ok || !s.IsDown {
+		t.Fatalf("one pass shouldn't bring it back up")
+	}
+	c, ok = s.Observe(true, "", now.Add(3*time.Minute), 3, 2)
+	if !ok || c.Type != Up || c.DownFor != 3*time.Minute || s.IsDown || s.LastError != "" {
+		t.Fatalf("expected up after two passes, got %+v %+v", c, s)
+	}
+}
+
+func TestTrackerObserve(t *testing.T) {
+	tr := NewTracker(1, 1)
+	now := time.Now()
+	api := checker.Target{Name: "api", Env: "prod"}
+
+	changes := tr.Observe([]checker.Result{{Target: api, Error: "http_500"}, {Target: checker.Target{Name: "web"}, Up: true}}, now)
+	if len(changes) != 1 || changes[0].Key != "api:prod" || changes[0].Type != Down {
+		t.Fatalf("expected api down, got %+v", changes)
+	}
+	if changes := tr.Observe([]checker.Result{{Target: api, Up: true}}, now); len(changes) != 1 || changes[0].Type != Up {
+		t.Errorf("expected api up, got %+v", changes)
+	}
+}
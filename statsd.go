@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// StatsDConfig sends check latencies, up/down gauges and transitions to a
+// StatsD agent over UDP. With dogstatsd, service and env travel as tags and
+// transitions are also sent as Datadog events; plain StatsD has no tags, so
+// they become part of the metric name instead.
+type StatsDConfig struct {
+	Enabled   bool     `json:"enabled"`
+	Addr      string   `json:"addr"`
+	Prefix    string   `json:"prefix"`
+	DogStatsD bool     `json:"dogstatsd"`
+	Tags      []string `json:"tags"`
+}
+
+func (c *StatsDConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Addr == "" {
+		c.Addr = "127.0.0.1:8125"
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("statsd.addr: %w", err)
+	}
+	if c.Prefix == "" {
+		c.Prefix = "status_bot"
+	}
+	c.Prefix = strings.TrimSuffix(c.Prefix, ".")
+	return nil
+}
+
+// maxStatsDPacket keeps datagrams under the usual 1500 byte MTU.
+const maxStatsDPacket = 1432
+
+type statsdClient struct {
+	cfg  StatsDConfig
+	conn net.Conn
+}
+
+func newStatsDClient(cfg StatsDConfig) (*statsdClient, error) {
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial statsd: %w", err)
+	}
+	return &statsdClient{cfg: cfg, conn: conn}, nil
+}
+
+// record sends the metrics of one cycle. A nil client is a no-op.
+func (c *statsdClient) record(results []CheckResult, transitions []Transition) {
+	if c == nil {
+		return
+	}
+
+	var lines []string
+	for _, r := range results {
+		lines = append(lines,
+			c.line(r.Service, "service.up", boolValue(r.Up), "g"),
+			c.line(r.Service, "check.latency", fmt.Sprint(r.Latency.Milliseconds()), "ms"),
+		)
+		if !r.Up {
+			lines = append(lines, c.line(r.Service, "check.failures", "1", "c"))
+		}
+	}
+
+	for _, t := range transitions {
+		lines = append(lines, c.line(t.Service, "transitions."+t.Type, "1", "c"))
+		if c.cfg.DogStatsD {
+			lines = append(lines, c.event(t))
+		}
+	}
+
+	c.send(lines)
+}
+
+// line formats one metric, e.g. "status_bot.check.latency:42|ms|#service:api".
+func (c *statsdClient) line(svc Service, name string, value string, typ string) string {
+	if !c.cfg.DogStatsD {
+		return fmt.Sprintf("%s.%s.%s.%s:%s|%s", c.cfg.Prefix, statsdName(svc.Name), statsdName(svc.Env), name, value, typ)
+	}
+	return fmt.Sprintf("%s.%s:%s|%s|#%s", c.cfg.Prefix, name, value, typ, strings.Join(c.tags(svc), ","))
+}
+
+// event formats a DogStatsD event for a transition.
+func (c *statsdClient) event(t Transition) string {
+	title := fmt.Sprintf("%s (%s) is %s", t.Service.Name, t.Service.Env, t.Type)
+	text, alert := t.Error, "error"
+	switch t.Type {
+	case "up":
+		text, alert = "Recovered", "success"
+		if t.Downtime != "" {
+			text = "Recovered after " + t.Downtime
+		}
+	case "change":
+		title = fmt.Sprintf("%s (%s) error changed", t.Service.Name, t.Service.Env)
+		alert = "warning"
+	}
+	text = strings.ReplaceAll(text, "\n", `\n`)
+	return fmt.Sprintf("_e{%d,%d}:%s|%s|t:%s|#%s", len(title), len(text), title, text, alert, strings.Join(c.tags(t.Service), ","))
+}
+
+func (c *statsdClient) tags(svc Service) []string {
+	return append([]string{"service:" + statsdTag(svc.Name), "env:" + statsdTag(svc.Env)}, c.cfg.Tags...)
+}
+
+// send packs lines into as few datagrams as fit. Errors are only logged:
+// metrics are best-effort and must not hold up a cycle.
+func (c *statsdClient) send(lines []string) {
+	var packet strings.Builder
+	flush := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		if _, err := c.conn.Write([]byte(packet.String())); err != nil {
+			slog.Error("failed to send statsd metrics", "err", err)
+		}
+		packet.Reset()
+	}
+
+	for _, l := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(l) > maxStatsDPacket {
+			flush()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(l)
+	}
+	flush()
+}
+
+func (c *statsdClient) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+var (
+	statsdNameReplacer = strings.NewReplacer(".", "_", ":", "_", "|", "_", "@", "_", "#", "_", " ", "_")
+	statsdTagReplacer  = strings.NewReplacer(",", "_", "|", "_", "#", "_", " ", "_")
+)
+
+func statsdName(s string) string {
+	return statsdNameReplacer.Replace(strings.ToLower(s))
+}
+
+func statsdTag(s string) string {
+	return statsdTagReplacer.Replace(s)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenStatsD(t *testing.T) (*net.UDPConn, func() []string) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, func() []string {
+		buf := make([]byte, 65536)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return strings.Split(string(buf[:n]), "\n")
+	}
+}
+
+func TestStatsDDogStatsD(t *testing.T) {
+	conn, read := listenStatsD(t)
+	cfg := StatsDConfig{Enabled: true, Addr: conn.LocalAddr().String(), DogStatsD: true, Tags: []string{"team:ops"}}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	c, err := newStatsDClient(cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer c.Close()
+
+	svc := Service{Name: "api", Env: "production"}
+	c.record(
+		[]CheckResult{{Service: svc, Up: false, Latency: 42 * time.Millisecond}},
+		[]Transition{{Service: svc, Type: "down", Error: "timeout"}},
+	)
+
+	got := read()
+	want := []string{
+		"status_bot.service.up:0|g|#service:api,env:production,team:ops",
+		"status_bot.check.latency:42|ms|#service:api,env:production,team:ops",
+		"status_bot.check.failures:1|c|#service:api,env:production,team:ops",
+		"status_bot.transitions.down:1|c|#service:api,env:production,team:ops",
+		"_e{24,7}:api (production) is down|timeout|t:error|#service:api,env:production,team:ops",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected packet:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStatsDPlainNames(t *testing.T) {
+	c := &statsdClient{cfg: StatsDConfig{Prefix: "bot"}}
+	got := c.line(Service{Name: "Auth API", Env: "eu.prod"}, "check.latency", "5", "ms")
+	if want := "bot.auth_api.eu_prod.check.latency:5|ms"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestStatsDSplitsPackets(t *testing.T) {
+	conn, read := listenStatsD(t)
+	c, err := newStatsDClient(StatsDConfig{Addr: conn.LocalAddr().String(), Prefix: "p"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer c.Close()
+
+	line := strings.Repeat("x", 1000)
+	c.send([]string{line, line})
+
+	if got := read(); len(got) != 1 {
+		t.Errorf("expected one line in the first packet, got %d", len(got))
+	}
+	if got := read(); len(got) != 1 {
+		t.Errorf("expected one line in the second packet, got %d", len(got))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// serviceStatus is one service in the status API, as the bot saw it in the
+// last cycle.
+type serviceStatus struct {
+	Name         string     `json:"name"`
+	Env          string     `json:"env"`
+	Status       string     `json:"status"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	LatencyMs    int64      `json:"latency_ms"`
+	Error        string     `json:"error,omitempty"`
+	DownSince    *time.Time `json:"down_since,omitempty"`
+	Downtime     string     `json:"downtime,omitempty"`
+	Muted        bool       `json:"muted"`
+	AckedBy      string     `json:"acked_by,omitempty"`
+	LastIncident *Incident  `json:"last_incident,omitempty"`
+}
+
+type statusResponse struct {
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+	Healthy   int             `json:"healthy"`
+	Down      int             `json:"down"`
+	Services  []serviceStatus `json:"services"`
+}
+
+// status reports the results of the last cycle along with what the bot
+// knows about each service. Status is "up", "down" or "maintenance".
+func (b *Bot) status(now time.Time) statusResponse {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	resp := statusResponse{Services: []serviceStatus{}}
+	if _, last := b.metrics.cycleTimes(); !last.IsZero() {
+		resp.UpdatedAt = &last
+	}
+
+	for _, r := range b.results {
+		s := serviceStatus{
+			Name:       r.Service.Name,
+			Env:        r.Service.Env,
+			Status:     "up",
+			StatusCode: r.StatusCode,
+			LatencyMs:  r.Latency.Milliseconds(),
+			Error:      r.Error,
+		}
+
+		switch {
+		case inMaintenance(r.Service, now):
+			s.Status = "maintenance"
+		case !r.Up:
+			s.Status = "down"
+			resp.Down++
+		default:
+			resp.Healthy++
+		}
+
+		key := serviceKey(r.Service)
+		if state := b.states[key]; state != nil {
+			if state.IsDown && !state.DownSince.IsZero() {
+				since := state.DownSince
+				s.DownSince = &since
+				s.Downtime = formatDuration(now.Sub(since))
+			}
+			s.Muted = state.muted(now)
+			s.AckedBy = state.AckedBy
+		}
+		if i := b.history.lastIncident(key); i != nil {
+			incident := *i
+			s.LastIncident = &incident
+		}
+
+		resp.Services = append(resp.Services, s)
+	}
+	return resp
+}
+
+func (b *Bot) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.status(time.Now()))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleStatus(t *testing.T) {
+	api := Service{Name: "api", Env: "production"}
+	db := Service{Name: "db", Env: "production"}
+	b := newTestBot(api, db)
+
+	now := time.Now()
+	b.results = []CheckResult{
+		{Service: api, Up: true, StatusCode: 200, Latency: 42 * time.Millisecond},
+		{Service: db, Error: "request failed", Latency: 3 * time.Second},
+	}
+	b.states[serviceKey(db)] = &ServiceState{IsDown: true, DownSince: now.Add(-10 * time.Minute), AckedBy: "<@U1>"}
+	b.history.Incidents = []Incident{{ServiceKey: serviceKey(db), Error: "request failed", StartedAt: now.Add(-10 * time.Minute)}}
+	b.metrics.recordCycle(now, time.Second, nil)
+
+	rec := httptest.NewRecorder()
+	b.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp statusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Healthy != 1 || resp.Down != 1 || len(resp.Services) != 2 || resp.UpdatedAt == nil {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	up, down := resp.Services[0], resp.Services[1]
+	if up.Status != "up" || up.LatencyMs != 42 || up.DownSince != nil || up.LastIncident != nil {
+		t.Errorf("unexpected healthy service: %+v", up)
+	}
+	if down.Status != "down" || down.Downtime != "10m" || down.AckedBy != "<@U1>" || down.LastIncident == nil || !down.LastIncident.Open() {
+		t.Errorf("unexpected down service: %+v", down)
+	}
+}
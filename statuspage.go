@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// StatuspageConfig mirrors transitions onto Atlassian Statuspage components,
+// keyed by service ("name:env"). With open_incidents, every outage also opens
+// a Statuspage incident that is resolved on recovery. The API key is read
+// from STATUSPAGE_API_KEY.
+type StatuspageConfig struct {
+	Enabled       bool              `json:"enabled"`
+	PageID        string            `json:"page_id"`
+	Components    map[string]string `json:"components"`
+	OpenIncidents bool              `json:"open_incidents"`
+}
+
+func (c StatuspageConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.PageID == "" || len(c.Components) == 0 {
+		return fmt.Errorf("statuspage.page_id and statuspage.components are required")
+	}
+	return nil
+}
+
+const (
+	componentOperational = "operational"
+	componentMajorOutage = "major_outage"
+)
+
+type statuspageNotifier struct {
+	cfg     StatuspageConfig
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+func newStatuspageNotifier(cfg StatuspageConfig) (*statuspageNotifier, error) {
+	apiKey := os.Getenv("STATUSPAGE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("STATUSPAGE_API_KEY is not set")
+	}
+	return &statuspageNotifier{
+		cfg:     cfg,
+		apiKey:  apiKey,
+		baseURL: "https://api.statuspage.io/v1/pages/" + cfg.PageID,
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+	}, nil
+}
+
+func (n *statuspageNotifier) Name() string {
+	return "statuspage"
+}
+
+func (n *statuspageNotifier) Notify(ctx context.Context, c Cycle) error {
+	for _, t := range c.Transitions {
+		key := serviceKey(t.Service)
+		component := n.cfg.Components[key]
+		if component == "" {
+			continue
+		}
+
+		switch t.Type {
+		case "down":
+			if err := n.setComponent(ctx, component, componentMajorOutage); err != nil {
+				return err
+			}
+			if !n.cfg.OpenIncidents || c.History == nil {
+				continue
+			}
+			id, err := n.openIncident(ctx, t, component)
+			if err != nil {
+				return err
+			}
+			if incident := c.History.openIncident(key); incident != nil {
+				incident.StatuspageID = id
+			}
+		case "up":
+			if err := n.setComponent(ctx, component, componentOperational); err != nil {
+				return err
+			}
+			if c.History == nil {
+				continue
+			}
+			if incident := c.History.lastIncident(key); incident != nil && incident.StatuspageID != "" {
+				if err := n.resolveIncident(ctx, incident.StatuspageID, t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (n *statuspageNotifier) setComponent(ctx context.Context, component string, status string) error {
+	body := map[string]any{"component": map[string]string{"status": status}}
+	return n.call(ctx, http.MethodPatch, "/components/"+component, body, nil)
+}
+
+func (n *statuspageNotifier) openIncident(ctx context.Context, t Transition, component string) (string, error) {
+	body := map[string]any{"incident": map[string]any{
+		"name":          fmt.Sprintf("%s is unavailable", t.Service.Name),
+		"status":        "investigating",
+		"body":          "We are investigating an outage and will post updates here.",
+		"component_ids": []string{component},
+		"components":    map[string]string{component: componentMajorOutage},
+	}}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := n.call(ctx, http.MethodPost, "/incidents", body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (n *statuspageNotifier) resolveIncident(ctx context.Context, id string, t Transition) error {
+	msg := "The service has recovered."
+	if t.Downtime != "" {
+		msg = fmt.Sprintf("The service has recovered after %s.", t.Downtime)
+	}
+	body := map[string]any{"incident": map[string]string{"status": "resolved", "body": msg}}
+	return n.call(ctx, http.MethodPatch, "/incidents/"+id, body, nil)
+}
+
+func (n *statuspageNotifier) call(ctx context.Context, method string, path string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "OAuth "+n.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("statuspage %s %s returned %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatuspageNotifier(t *testing.T) {
+	var requests []string
+	var bodies []map[string]map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "OAuth key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var body map[string]map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"id":"inc1"}`))
+		}
+	}))
+	defer srv.Close()
+
+	n := &statuspageNotifier{
+		cfg: StatuspageConfig{
+			Components:    map[string]string{"api:production": "cmp1"},
+			OpenIncidents: true,
+		},
+		apiKey:  "key",
+		baseURL: srv.URL + "/v1/pages/pg1",
+		client:  srv.Client(),
+	}
+
+	api := Service{Name: "api", Env: "production"}
+	web := Service{Name: "web", Env: "production"}
+	history := newHistory()
+	now := time.Now()
+
+	down := []Transition{
+		{Service: api, ServiceName: "api (production)", Type: "down", Error: "timeout"},
+		{Service: web, ServiceName: "web (production)", Type: "down", Error: "timeout"},
+	}
+	history.record(nil, down, now)
+	if err := n.Notify(context.Background(), Cycle{At: now, Transitions: down, History: history}); err != nil {
+		t.Fatalf("notify down: %v", err)
+	}
+
+	up := []Transition{{Service: api, ServiceName: "api (production)", Type: "up", Downtime: "5m"}}
+	history.record(nil, up, now.Add(5*time.Minute))
+	if err := n.Notify(context.Background(), Cycle{At: now, Transitions: up, History: history}); err != nil {
+		t.Fatalf("notify up: %v", err)
+	}
+
+	want := []string{
+		"PATCH /v1/pages/pg1/components/cmp1",
+		"POST /v1/pages/pg1/incidents",
+		"PATCH /v1/pages/pg1/components/cmp1",
+		"PATCH /v1/pages/pg1/incidents/inc1",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected requests:\n%s", strings.Join(requests, "\n"))
+	}
+
+	if bodies[0]["component"]["status"] != componentMajorOutage || bodies[2]["component"]["status"] != componentOperational {
+		t.Errorf("expected the component to go down then back up, got %v / %v", bodies[0], bodies[2])
+	}
+	if bodies[3]["incident"]["status"] != "resolved" {
+		t.Errorf("expected the incident to be resolved, got %v", bodies[3])
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// systemd supervision. Run the bot as a notify service with a watchdog:
+//
+//	[Service]
+//	Type=notify
+//	WatchdogSec=5min
+//	Restart=on-failure
+//
+// The bot reports READY once it is set up and pings the watchdog for as long
+// as cycles keep finishing, so systemd restarts it when they stop.
+
+// sdNotify sends state to the service manager. It does nothing when the bot
+// wasn't started by systemd.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("dial notify socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("write notify socket: %w", err)
+	}
+	return nil
+}
+
+// watchdogInterval returns the watchdog timeout systemd expects pings
+// within, if it enabled one for this process.
+func watchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// runWatchdog pings the systemd watchdog at half its timeout while cycles
+// keep finishing. Once none has finished for staleIntervals intervals the
+// pings stop and systemd restarts the bot, as /healthz would tell an
+// orchestrator to.
+func (b *Bot) runWatchdog(ctx context.Context, timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	stalled := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if age, _ := b.cycleAge(now); age > b.staleAfter() && !b.standby.Load() {
+				if !stalled {
+					slog.Error("cycles stalled, no longer pinging the systemd watchdog", "since", formatDuration(age))
+					stalled = true
+				}
+				continue
+			}
+			stalled = false
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				slog.Error("failed to ping systemd watchdog", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listenNotify(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readNotify(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSDNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("expected no-op outside systemd, got %v", err)
+	}
+
+	conn := listenNotify(t)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readNotify(t, conn); got != "READY=1" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if _, ok := watchdogInterval(); ok {
+		t.Errorf("expected no watchdog without WATCHDOG_USEC")
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if d, ok := watchdogInterval(); !ok || d != 30*time.Second {
+		t.Errorf("expected 30s, got %s %v", d, ok)
+	}
+
+	t.Setenv("WATCHDOG_PID", "1")
+	if _, ok := watchdogInterval(); ok {
+		t.Errorf("expected the watchdog of another process to be ignored")
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	conn := listenNotify(t)
+	b := newTestBot()
+	b.cfg.IntervalSeconds = 60
+	b.metrics = metrics{started: time.Now()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.runWatchdog(ctx, 20*time.Millisecond)
+	if got := readNotify(t, conn); got != "WATCHDOG=1" {
+		t.Errorf("got %q", got)
+	}
+	cancel()
+
+	// With no cycle finished for longer than the stale limit, the pings stop.
+	stale := newTestBot()
+	stale.cfg.IntervalSeconds = 1
+	stale.metrics = metrics{started: time.Now().Add(-time.Minute)}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go stale.runWatchdog(ctx, 20*time.Millisecond)
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	for {
+		buf := make([]byte, 64)
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+		// Drain pings the first watchdog sent before it stopped.
+	}
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 64)); err == nil {
+		t.Errorf("expected no ping from a stalled bot, got %d bytes", n)
+	}
+}
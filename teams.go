@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TeamsConfig posts alerts to Microsoft Teams through the incoming webhook in
+// TEAMS_WEBHOOK_URL. Incoming webhooks can't edit messages, so instead of a
+// live board every alert card carries a snapshot of the board.
+type TeamsConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
+type teamsNotifier struct {
+	client  *http.Client
+	webhook string
+	theme   Theme
+}
+
+func newTeamsNotifier(theme Theme) (*teamsNotifier, error) {
+	webhook := os.Getenv("TEAMS_WEBHOOK_URL")
+	if webhook == "" {
+		return nil, fmt.Errorf("TEAMS_WEBHOOK_URL is not set")
+	}
+	return &teamsNotifier{
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+		webhook: webhook,
+		theme:   theme,
+	}, nil
+}
+
+func (n *teamsNotifier) Name() string {
+	return "teams"
+}
+
+func (n *teamsNotifier) Notify(ctx context.Context, c Cycle) error {
+	if len(c.Transitions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(teamsCard(c, n.theme))
+	if err != nil {
+		return fmt.Errorf("encode card: %w", err)
+	}
+	return postJSON(ctx, n.client, n.webhook, body, nil)
+}
+
+// teamsCard renders the cycle's transitions followed by the whole board as an
+// Adaptive Card message.
+func teamsCard(c Cycle, theme Theme) map[string]any {
+	var body []map[string]any
+
+	var down, up []Transition
+	for _, t := range c.Transitions {
+		switch t.Type {
+		case "down":
+			down = append(down, t)
+		case "up":
+			up = append(up, t)
+		}
+	}
+
+	if len(down) > 0 {
+		body = append(body, teamsHeading(fmt.Sprintf("%s %s", theme.DownEmoji, theme.DownTitle), "attention"))
+		for _, t := range down {
+			body = append(body, teamsText(fmt.Sprintf("**%s**: %s", t.ServiceName, t.Error)))
+		}
+	}
+	if len(up) > 0 {
+		body = append(body, teamsHeading(fmt.Sprintf("%s %s", theme.UpEmoji, theme.UpTitle), "good"))
+		for _, t := range up {
+			line := fmt.Sprintf("**%s** is back up", t.ServiceName)
+			if t.Downtime != "" {
+				line += " after " + t.Downtime
+			}
+			body = append(body, teamsText(line))
+		}
+	}
+
+	var facts []map[string]string
+	for _, r := range c.Results {
+		value := fmt.Sprintf("%s %dms", theme.UpEmoji, r.Latency.Milliseconds())
+		if !r.Up {
+			value = fmt.Sprintf("%s %s", theme.DownEmoji, r.Error)
+		}
+		facts = append(facts, map[string]string{
+			"title": fmt.Sprintf("%s (%s)", r.Service.Name, r.Service.Env),
+			"value": value,
+		})
+	}
+
+	title := theme.BoardTitle
+	if title == "" {
+		title = "Service status"
+	}
+	body = append(body,
+		map[string]any{"type": "TextBlock", "text": title, "weight": "bolder", "separator": true},
+		map[string]any{"type": "FactSet", "facts": facts},
+		map[string]any{"type": "TextBlock", "text": "Updated " + c.At.Format(time.RFC1123), "isSubtle": true, "size": "small"},
+	)
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+}
+
+func teamsHeading(text string, color string) map[string]any {
+	return map[string]any{"type": "TextBlock", "text": text, "weight": "bolder", "size": "medium", "color": color, "wrap": true}
+}
+
+func teamsText(text string) map[string]any {
+	return map[string]any{"type": "TextBlock", "text": text, "wrap": true}
+}
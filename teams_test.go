@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamsNotifier(t *testing.T) {
+	var cards []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var card map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			t.Errorf("decode card: %v", err)
+		}
+		data, _ := json.Marshal(card)
+		cards = append(cards, string(data))
+	}))
+	defer srv.Close()
+
+	n := &teamsNotifier{client: srv.Client(), webhook: srv.URL, theme: defaultTheme}
+	svc := Service{Name: "api", Env: "production"}
+	other := Service{Name: "web", Env: "production"}
+
+	quiet := Cycle{At: time.Now(), Results: []CheckResult{{Service: svc, Up: true}}}
+	if err := n.Notify(context.Background(), quiet); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if len(cards) != 0 {
+		t.Fatalf("expected no card without transitions, got %d", len(cards))
+	}
+
+	down := Cycle{
+		At:          time.Now(),
+		Results:     []CheckResult{{Service: svc, Error: "timeout"}, {Service: other, Up: true}},
+		Transitions: []Transition{{Service: svc, ServiceName: "api (production)", Type: "down", Error: "timeout"}},
+	}
+	if err := n.Notify(context.Background(), down); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("expected one card, got %d", len(cards))
+	}
+	for _, want := range []string{"AdaptiveCard", "**api (production)**: timeout", `"title":"web (production)"`} {
+		if !strings.Contains(cards[0], want) {
+			t.Errorf("expected %q in card %s", want, cards[0])
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+// TelegramConfig posts alerts to a Telegram chat and keeps a pinned status
+// message there up to date, using the bot token in TELEGRAM_BOT_TOKEN.
+type TelegramConfig struct {
+	Enabled bool   `json:"enabled"`
+	ChatID  string `json:"chat_id"`
+}
+
+const telegramBoardPath = ".telegram_board_id"
+
+var errTelegramMessageGone = errors.New("telegram message not found")
+
+type telegramNotifier struct {
+	client    *http.Client
+	baseURL   string
+	chatID    string
+	theme     Theme
+	boardPath string
+}
+
+func newTelegramNotifier(cfg TelegramConfig, theme Theme) (*telegramNotifier, error) {
+	token := os.Getenv("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN is not set")
+	}
+	if cfg.ChatID == "" {
+		return nil, fmt.Errorf("telegram.chat_id is required")
+	}
+	return &telegramNotifier{
+		client:    &http.Client{Timeout: 10 * time.Second, Transport: newRetryTransport(http.DefaultTransport)},
+		baseURL:   "https://api.telegram.org/bot" + token,
+		chatID:    cfg.ChatID,
+		theme:     theme,
+		boardPath: telegramBoardPath,
+	}, nil
+}
+
+func (n *telegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, c Cycle) error {
+	if err := n.upsertBoard(ctx, telegramBoard(c, n.theme)); err != nil {
+		return fmt.Errorf("update status message: %w", err)
+	}
+
+	if text := telegramAlert(c.Transitions, n.theme); text != "" {
+		if _, err := n.sendMessage(ctx, text, false); err != nil {
+			return fmt.Errorf("post alert: %w", err)
+		}
+	}
+	return nil
+}
+
+// upsertBoard edits the pinned status message, posting and pinning a new one
+// when it is gone.
+func (n *telegramNotifier) upsertBoard(ctx context.Context, text string) error {
+	if id := slackboard.LoadTS(n.boardPath); id != "" {
+		err := n.call(ctx, "editMessageText", map[string]any{
+			"chat_id":    n.chatID,
+			"message_id": id,
+			"text":       text,
+			"parse_mode": "HTML",
+		}, nil)
+		if !errors.Is(err, errTelegramMessageGone) {
+			return err
+		}
+	}
+
+	id, err := n.sendMessage(ctx, text, true)
+	if err != nil {
+		return err
+	}
+	if err := n.call(ctx, "pinChatMessage", map[string]any{
+		"chat_id":              n.chatID,
+		"message_id":           id,
+		"disable_notification": true,
+	}, nil); err != nil {
+		slog.Error("failed to pin telegram status message", "err", err)
+	}
+	return slackboard.SaveTS(n.boardPath, id)
+}
+
+func (n *telegramNotifier) sendMessage(ctx context.Context, text string, silent bool) (string, error) {
+	var msg struct {
+		MessageID int `json:"message_id"`
+	}
+	err := n.call(ctx, "sendMessage", map[string]any{
+		"chat_id":              n.chatID,
+		"text":                 text,
+		"parse_mode":           "HTML",
+		"disable_notification": silent,
+	}, &msg)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(msg.MessageID), nil
+}
+
+// call invokes a Bot API method. Editing a message into its current text is
+// not an error here.
+func (n *telegramNotifier) call(ctx context.Context, method string, params map[string]any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The URL embeds the bot token, keep it out of the logs.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("decode %s response: %w", method, err)
+	}
+
+	if !out.OK {
+		switch {
+		case strings.Contains(out.Description, "message is not modified"):
+			return nil
+		case strings.Contains(out.Description, "message to edit not found"):
+			return errTelegramMessageGone
+		}
+		return fmt.Errorf("%s: %s", method, out.Description)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(out.Result, result); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func telegramBoard(c Cycle, theme Theme) string {
+	title := theme.BoardTitle
+	if title == "" {
+		title = "Service status"
+	}
+
+	lines := []string{"<b>" + html.EscapeString(title) + "</b>"}
+	for _, r := range c.Results {
+		name := fmt.Sprintf("<b>%s</b> (%s)", html.EscapeString(r.Service.Name), html.EscapeString(r.Service.Env))
+		if r.Up {
+			lines = append(lines, fmt.Sprintf("%s %s %dms", theme.UpEmoji, name, r.Latency.Milliseconds()))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %s <code>%s</code>", theme.DownEmoji, name, html.EscapeString(r.Error)))
+		}
+	}
+	lines = append(lines, "", "<i>Updated "+c.At.Format("2006-01-02 15:04:05 MST")+"</i>")
+	return strings.Join(lines, "\n")
+}
+
+func telegramAlert(transitions []Transition, theme Theme) string {
+	var down, up []string
+	for _, t := range transitions {
+		name := html.EscapeString(t.ServiceName)
+		switch t.Type {
+		case "down":
+			down = append(down, fmt.Sprintf("• <b>%s</b>: <code>%s</code>", name, html.EscapeString(t.Error)))
+		case "up":
+			line := fmt.Sprintf("• <b>%s</b> is back up", name)
+			if t.Downtime != "" {
+				line += " after " + t.Downtime
+			}
+			up = append(up, line)
+		}
+	}
+
+	var parts []string
+	if len(down) > 0 {
+		parts = append(parts, fmt.Sprintf("%s <b>%s</b>\n%s", theme.DownEmoji, html.EscapeString(theme.DownTitle), strings.Join(down, "\n")))
+	}
+	if len(up) > 0 {
+		parts = append(parts, fmt.Sprintf("%s <b>%s</b>\n%s", theme.UpEmoji, html.EscapeString(theme.UpTitle), strings.Join(up, "\n")))
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gb16702/status-bot/slackboard"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	var methods []string
+	var texts []string
+	editGone := false
+	nextID := 100
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/botTOKEN/")
+		methods = append(methods, method)
+
+		var params map[string]any
+		json.NewDecoder(r.Body).Decode(&params)
+		if params["chat_id"] != "-42" {
+			t.Errorf("unexpected chat %v", params["chat_id"])
+		}
+
+		switch method {
+		case "sendMessage":
+			nextID++
+			texts = append(texts, params["text"].(string))
+			fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, nextID)
+		case "editMessageText":
+			if editGone {
+				fmt.Fprint(w, `{"ok":false,"description":"Bad Request: message to edit not found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok":false,"description":"Bad Request: message is not modified"}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		}
+	}))
+	defer srv.Close()
+
+	n := &telegramNotifier{
+		client:    srv.Client(),
+		baseURL:   srv.URL + "/botTOKEN",
+		chatID:    "-42",
+		theme:     defaultTheme,
+		boardPath: filepath.Join(t.TempDir(), ".telegram_board_id"),
+	}
+
+	svc := Service{Name: "a<b>", Env: "production"}
+	err := n.Notify(context.Background(), Cycle{
+		At:          time.Now(),
+		Results:     []CheckResult{{Service: svc, Error: "timeout"}},
+		Transitions: []Transition{{Service: svc, ServiceName: "a<b> (production)", Type: "down", Error: "timeout"}},
+	})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if want := "sendMessage,pinChatMessage,sendMessage"; strings.Join(methods, ",") != want {
+		t.Errorf("expected %s, got %v", want, methods)
+	}
+	if slackboard.LoadTS(n.boardPath) != "101" {
+		t.Errorf("expected the status message ID to be stored, got %q", slackboard.LoadTS(n.boardPath))
+	}
+	if !strings.Contains(texts[1], "a&lt;b&gt;") {
+		t.Errorf("expected service names to be escaped, got %q", texts[1])
+	}
+
+	methods = nil
+	if err := n.Notify(context.Background(), Cycle{At: time.Now()}); err != nil {
+		t.Fatalf("an unmodified status message should not fail: %v", err)
+	}
+	if strings.Join(methods, ",") != "editMessageText" {
+		t.Errorf("expected only an edit, got %v", methods)
+	}
+
+	editGone = true
+	if err := n.Notify(context.Background(), Cycle{At: time.Now()}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if slackboard.LoadTS(n.boardPath) != "103" {
+		t.Errorf("expected a deleted status message to be reposted, got %q", slackboard.LoadTS(n.boardPath))
+	}
+}
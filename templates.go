@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"text/template"
+)
+
+// TemplateConfig holds optional text/template overrides for alert and board
+// lines. Templates see the fields of messageData, e.g.
+// "{{.Name}} [{{.Env}}] is down: {{.Error}} {{.Owner}}".
+type TemplateConfig struct {
+	DownAlert string `json:"down_alert"`
+	UpAlert   string `json:"up_alert"`
+	BoardLine string `json:"board_line"`
+
+	downAlert *template.Template
+	upAlert   *template.Template
+	boardLine *template.Template
+}
+
+type messageData struct {
+	Name     string
+	Env      string
+	URL      string
+	Owner    string
+	Runbook  string
+	Link     string
+	Up       bool
+	Error    string
+	Latency  string
+	Downtime string
+
+	// Emoji and Status are the symbol and status text of the default board
+	// line, so templates can rearrange rather than rebuild them.
+	Emoji  string
+	Status string
+}
+
+func newMessageData(svc Service) messageData {
+	return messageData{
+		Name:    svc.Name,
+		Env:     svc.Env,
+		URL:     svc.URL,
+		Owner:   ownerMentions(svc),
+		Runbook: svc.RunbookURL,
+		Link:    serviceLink(svc),
+	}
+}
+
+func (c *TemplateConfig) compile() error {
+	for _, t := range []struct {
+		name string
+		text string
+		dst  **template.Template
+	}{
+		{"down_alert", c.DownAlert, &c.downAlert},
+		{"up_alert", c.UpAlert, &c.upAlert},
+		{"board_line", c.BoardLine, &c.boardLine},
+	} {
+		if t.text == "" {
+			continue
+		}
+		tmpl, err := template.New(t.name).Option("missingkey=error").Parse(t.text)
+		if err != nil {
+			return fmt.Errorf("templates.%s: %w", t.name, err)
+		}
+		*t.dst = tmpl
+	}
+	return nil
+}
+
+// renderTemplate executes tmpl, or returns fallback when no template is set
+// or it fails to execute.
+func renderTemplate(tmpl *template.Template, data messageData, fallback string) string {
+	if tmpl == nil {
+		return fallback
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		slog.Error("failed to render template", "template", tmpl.Name(), "err", err)
+		return fallback
+	}
+	return buf.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestTemplatesCompile(t *testing.T) {
+	bad := TemplateConfig{DownAlert: "{{.Name"}
+	if err := bad.compile(); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+
+	cfg := TemplateConfig{
+		DownAlert: "{{.Name}} [{{.Env}}] down: {{.Error}} {{.Owner}}",
+		BoardLine: "{{.Emoji}} {{.Name}} {{if .Up}}{{.Latency}}{{else}}{{.Error}}{{end}}",
+	}
+	if err := cfg.compile(); err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	data := newMessageData(Service{Name: "api", Env: "production", Owners: []string{"U123"}})
+	data.Error = "http_503"
+	if got := renderTemplate(cfg.downAlert, data, "fallback"); got != "api [production] down: http_503 <@U123>" {
+		t.Errorf("unexpected down alert %q", got)
+	}
+
+	if got := renderTemplate(cfg.upAlert, data, "fallback"); got != "fallback" {
+		t.Errorf("an unset template should use the fallback, got %q", got)
+	}
+
+	line := renderServiceLine(CheckResult{Service: Service{Name: "api"}, Up: true}, nil, boardOptions{
+		theme:     defaultTheme,
+		templates: cfg,
+	})
+	if line != "🟢 api 0ms" {
+		t.Errorf("unexpected board line %q", line)
+	}
+}
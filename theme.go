@@ -0,0 +1,50 @@
+package main
+
+// Theme holds the symbols and wording used on the board and in alerts. Emoji
+// may be unicode or workspace shortcodes such as ":status-ok:".
+type Theme struct {
+	UpEmoji          string `json:"up_emoji"`
+	DownEmoji        string `json:"down_emoji"`
+	MutedEmoji       string `json:"muted_emoji"`
+	MaintenanceEmoji string `json:"maintenance_emoji"`
+	DownTitle        string `json:"down_title"`
+	UpTitle          string `json:"up_title"`
+	HealthyLabel     string `json:"healthy_label"`
+	DownLabel        string `json:"down_label"`
+	BoardTitle       string `json:"board_title"`
+	SlowEmoji        string `json:"slow_emoji"`
+	SlowTitle        string `json:"slow_title"`
+}
+
+var defaultTheme = Theme{
+	UpEmoji:          "🟢",
+	DownEmoji:        "🔴",
+	MutedEmoji:       "🔇",
+	MaintenanceEmoji: "🔧",
+	DownTitle:        "Services DOWN",
+	UpTitle:          "Services back UP",
+	HealthyLabel:     "healthy",
+	DownLabel:        "down",
+	SlowEmoji:        "🐢",
+	SlowTitle:        "Latency anomaly",
+}
+
+// withDefaults fills every unset field from defaultTheme.
+func (t Theme) withDefaults() Theme {
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&t.UpEmoji, defaultTheme.UpEmoji)
+	fill(&t.DownEmoji, defaultTheme.DownEmoji)
+	fill(&t.MutedEmoji, defaultTheme.MutedEmoji)
+	fill(&t.MaintenanceEmoji, defaultTheme.MaintenanceEmoji)
+	fill(&t.DownTitle, defaultTheme.DownTitle)
+	fill(&t.UpTitle, defaultTheme.UpTitle)
+	fill(&t.HealthyLabel, defaultTheme.HealthyLabel)
+	fill(&t.DownLabel, defaultTheme.DownLabel)
+	fill(&t.SlowEmoji, defaultTheme.SlowEmoji)
+	fill(&t.SlowTitle, defaultTheme.SlowTitle)
+	return t
+}
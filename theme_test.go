@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemeWithDefaults(t *testing.T) {
+	theme := Theme{UpEmoji: ":ok:", DownLabel: "failing"}.withDefaults()
+
+	if theme.UpEmoji != ":ok:" || theme.DownLabel != "failing" {
+		t.Errorf("custom values should be kept, got %+v", theme)
+	}
+	if theme.DownEmoji != defaultTheme.DownEmoji || theme.UpTitle != defaultTheme.UpTitle {
+		t.Errorf("unset values should fall back to the defaults, got %+v", theme)
+	}
+}
+
+func TestRenderServiceLineUsesTheme(t *testing.T) {
+	opts := boardOptions{theme: Theme{UpEmoji: "✔", DownEmoji: "✖"}.withDefaults()}
+
+	up := renderServiceLine(CheckResult{Service: Service{Name: "api"}, Up: true}, nil, opts)
+	if !strings.HasPrefix(up, "✔") {
+		t.Errorf("expected the custom up symbol, got %q", up)
+	}
+
+	down := renderServiceLine(CheckResult{Service: Service{Name: "api"}, Error: "timeout"}, nil, opts)
+	if !strings.HasPrefix(down, "✖") {
+		t.Errorf("expected the custom down symbol, got %q", down)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+type TopicConfig struct {
+	Enabled            bool `json:"enabled"`
+	MinIntervalSeconds int  `json:"min_interval_seconds"`
+}
+
+const defaultTopicInterval = 600
+
+// channelTopic summarises results in one line suitable for a channel topic.
+func channelTopic(results []CheckResult) string {
+	var down []string
+	for _, r := range results {
+		if !r.Up && !slices.Contains(down, r.Service.Name) {
+			down = append(down, r.Service.Name)
+		}
+	}
+
+	if len(down) == 0 {
+		return fmt.Sprintf("✅ %d/%d healthy", len(results), len(results))
+	}
+	return fmt.Sprintf("🔴 %d down: %s", len(down), strings.Join(down, ", "))
+}
+
+type postedTopic struct {
+	text string
+	at   time.Time
+}
+
+// updateTopics sets the topic of every board channel to a summary of the
+// services shown there. A topic only changes when its text does, and at most
+// once per min_interval_seconds, so the channel isn't flooded with
+// "set the channel topic" messages during a flapping outage.
+func (b *Bot) updateTopics(results []CheckResult, now time.Time) {
+	if !b.cfg.Topic.Enabled {
+		return
+	}
+	if b.topics == nil {
+		b.topics = make(map[string]postedTopic)
+	}
+
+	var channels []string
+	byChannel := make(map[string][]CheckResult)
+	for _, bc := range b.boards {
+		if _, seen := byChannel[bc.Channel]; !seen {
+			channels = append(channels, bc.Channel)
+		}
+		for _, r := range withoutMaintenance(bc.filter(results), now) {
+			if !slices.ContainsFunc(byChannel[bc.Channel], func(o CheckResult) bool {
+				return serviceKey(o.Service) == serviceKey(r.Service)
+			}) {
+				byChannel[bc.Channel] = append(byChannel[bc.Channel], r)
+			}
+		}
+	}
+
+	interval := time.Duration(b.cfg.Topic.MinIntervalSeconds) * time.Second
+	for _, ch := range channels {
+		text := channelTopic(byChannel[ch])
+		last := b.topics[ch]
+		if text == last.text || now.Sub(last.at) < interval {
+			continue
+		}
+
+		if _, err := b.api.SetTopicOfConversation(ch, text); err != nil {
+			slog.Error("failed to set channel topic", "err", err)
+			continue
+		}
+		b.topics[ch] = postedTopic{text: text, at: now}
+	}
+}
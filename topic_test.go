@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestChannelTopic(t *testing.T) {
+	results := []CheckResult{
+		{Service: Service{Name: "api", Env: "production"}, Up: true},
+		{Service: Service{Name: "auth", Env: "production"}, Up: true},
+	}
+	if got := channelTopic(results); got != "✅ 2/2 healthy" {
+		t.Errorf("unexpected healthy topic %q", got)
+	}
+
+	results = append(results,
+		CheckResult{Service: Service{Name: "db", Env: "production"}},
+		CheckResult{Service: Service{Name: "db", Env: "development"}},
+		CheckResult{Service: Service{Name: "web", Env: "production"}},
+	)
+	if got := channelTopic(results); got != "🔴 2 down: db, web" {
+		t.Errorf("unexpected down topic %q", got)
+	}
+}
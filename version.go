@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build details, set at build time with
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+type buildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// currentBuild returns the build details, falling back to what the Go
+// toolchain recorded from version control for builds without ldflags.
+func currentBuild() buildInfo {
+	bi := buildInfo{Version: version, Commit: commit, Date: buildDate}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return bi
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && bi.Commit == "":
+			bi.Commit = s.Value
+		case s.Key == "vcs.time" && bi.Date == "":
+			bi.Date = s.Value
+		}
+	}
+	if len(bi.Commit) > 7 {
+		bi.Commit = bi.Commit[:7]
+	}
+	return bi
+}
+
+func (bi buildInfo) String() string {
+	s := "status-bot " + bi.Version
+	if bi.Commit != "" {
+		s += fmt.Sprintf(" (commit %s", bi.Commit)
+		if bi.Date != "" {
+			s += ", built " + bi.Date
+		}
+		s += ")"
+	}
+	return s
+}
+
+// footer is the short form shown on the board.
+func (bi buildInfo) footer() string {
+	if bi.Commit == "" {
+		return "status-bot " + bi.Version
+	}
+	return fmt.Sprintf("status-bot %s · %s", bi.Version, bi.Commit)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestBuildInfoString(t *testing.T) {
+	cases := []struct {
+		bi           buildInfo
+		full, footer string
+	}{
+		{buildInfo{Version: "dev"}, "status-bot dev", "status-bot dev"},
+		{buildInfo{Version: "1.4.0", Commit: "abc1234"}, "status-bot 1.4.0 (commit abc1234)", "status-bot 1.4.0 · abc1234"},
+		{buildInfo{Version: "1.4.0", Commit: "abc1234", Date: "2024-05-01T12:00:00Z"}, "status-bot 1.4.0 (commit abc1234, built 2024-05-01T12:00:00Z)", "status-bot 1.4.0 · abc1234"},
+	}
+	for _, c := range cases {
+		if got := c.bi.String(); got != c.full {
+			t.Errorf("String() = %q, want %q", got, c.full)
+		}
+		if got := c.bi.footer(); got != c.footer {
+			t.Errorf("footer() = %q, want %q", got, c.footer)
+		}
+	}
+}
+
+func TestBoardFooterVersion(t *testing.T) {
+	results := []CheckResult{{Service: Service{Name: "api", Env: "production"}, Up: true}}
+	blocks := renderBoard(results, map[string]*ServiceState{}, newRecentIncidents(5), boardOptions{theme: defaultTheme, version: "status-bot 1.4.0 · abc1234"})
+
+	footer, ok := blocks[len(blocks)-1].(*slack.ContextBlock)
+	if !ok || len(footer.ContextElements.Elements) != 2 {
+		t.Fatalf("expected the footer to carry the version, got %#v", blocks[len(blocks)-1])
+	}
+	if text := footer.ContextElements.Elements[1].(*slack.TextBlockObject).Text; text != "status-bot 1.4.0 · abc1234" {
+		t.Errorf("unexpected version %q", text)
+	}
+}
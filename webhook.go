@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+)
+
+// WebhookConfig posts transitions and cycle summaries as JSON to url. When
+// secret_env names an environment variable, every body is signed with its
+// value and the signature sent as X-Status-Signature: sha256=<hex>.
+type WebhookConfig struct {
+	URL       string   `json:"url"`
+	SecretEnv string   `json:"secret_env"`
+	Events    []string `json:"events"`
+}
+
+const (
+	webhookTransition = "transition"
+	webhookCycle      = "cycle"
+)
+
+func (c *WebhookConfig) validate() error {
+	if _, err := url.ParseRequestURI(c.URL); err != nil {
+		return fmt.Errorf("url %q is invalid", c.URL)
+	}
+	if c.SecretEnv != "" && os.Getenv(c.SecretEnv) == "" {
+		return fmt.Errorf("%s is not set", c.SecretEnv)
+	}
+	if len(c.Events) == 0 {
+		c.Events = []string{webhookTransition, webhookCycle}
+	}
+	for _, e := range c.Events {
+		if e != webhookTransition && e != webhookCycle {
+			return fmt.Errorf("events must be %s or %s, got %q", webhookTransition, webhookCycle, e)
+		}
+	}
+	return nil
+}
+
+type webhookService struct {
+	Name string `json:"name"`
+	Env  string `json:"env"`
+	URL  string `json:"url"`
+}
+
+type webhookTransitionPayload struct {
+	Event         string         `json:"event"`
+	At            time.Time      `json:"at"`
+	Service       webhookService `json:"service"`
+	Type          string         `json:"type"`
+	Error         string         `json:"error,omitempty"`
+	PreviousError string         `json:"previous_error,omitempty"`
+	Downtime      string         `json:"downtime,omitempty"`
+}
+
+type webhookResult struct {
+	webhookService
+	Up         bool   `json:"up"`
+	StatusCode int    `json:"status_code"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type webhookCyclePayload struct {
+	Event    string          `json:"event"`
+	At       time.Time       `json:"at"`
+	Total    int             `json:"total"`
+	Down     int             `json:"down"`
+	Services []webhookResult `json:"services"`
+}
+
+func newWebhookService(svc Service) webhookService {
+	return webhookService{Name: svc.Name, Env: svc.Env, URL: svc.URL}
+}
+
+// webhookNotifier delivers cycles to one webhook endpoint.
+type webhookNotifier struct {
+	cfg    WebhookConfig
+	secret []byte
+	client *http.Client
+}
+
+func newWebhookNotifier(cfg WebhookConfig) *webhookNotifier {
+	n := &webhookNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if cfg.SecretEnv != "" {
+		n.secret = []byte(os.Getenv(cfg.SecretEnv))
+	}
+	return n
+}
+
+func (n *webhookNotifier) Name() string {
+	u, err := url.Parse(n.cfg.URL)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook " + u.Host
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, c Cycle) error {
+	if slices.Contains(n.cfg.Events, webhookTransition) {
+		for _, t := range c.Transitions {
+			payload := webhookTransitionPayload{
+				Event:         webhookTransition,
+				At:            c.At,
+				Service:       newWebhookService(t.Service),
+				Type:          t.Type,
+				Error:         t.Error,
+				PreviousError: t.PrevError,
+				Downtime:      t.Downtime,
+			}
+			if err := n.send(ctx, webhookTransition, payload); err != nil {
+				return err
+			}
+		}
+	}
+
+	if slices.Contains(n.cfg.Events, webhookCycle) {
+		payload := webhookCyclePayload{Event: webhookCycle, At: c.At, Total: len(c.Results)}
+		for _, r := range c.Results {
+			if !r.Up {
+				payload.Down++
+			}
+			payload.Services = append(payload.Services, webhookResult{
+				webhookService: newWebhookService(r.Service),
+				Up:             r.Up,
+				StatusCode:     r.StatusCode,
+				LatencyMs:      r.Latency.Milliseconds(),
+				Error:          r.Error,
+			})
+		}
+		if err := n.send(ctx, webhookCycle, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *webhookNotifier) send(ctx context.Context, event string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", event, err)
+	}
+
+	header := http.Header{}
+	header.Set("X-Status-Event", event)
+	if len(n.secret) > 0 {
+		header.Set("X-Status-Signature", "sha256="+signBody(n.secret, body))
+	}
+	return postJSON(ctx, n.client, n.cfg.URL, body, header)
+}
+
+// signBody returns the hex HMAC-SHA256 of body.
+func signBody(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON posts body to endpoint and fails on any non-2xx answer.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body []byte, header http.Header) error {
+	return postBody(ctx, client, endpoint, "application/json", bytes.NewReader(body), header)
+}
+
+// postBody posts body with the given content type and fails on any non-2xx
+// answer.
+func postBody(ctx context.Context, client *http.Client, endpoint string, contentType string, body io.Reader, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %d: %s", endpoint, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
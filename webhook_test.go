@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	type delivery struct {
+		event     string
+		signature string
+		body      []byte
+	}
+	var got []delivery
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, delivery{r.Header.Get("X-Status-Event"), r.Header.Get("X-Status-Signature"), body})
+	}))
+	defer srv.Close()
+
+	svc := Service{Name: "api", Env: "production", URL: "https://api.example.com"}
+	n := &webhookNotifier{
+		cfg:    WebhookConfig{URL: srv.URL, Events: []string{webhookTransition, webhookCycle}},
+		secret: []byte("s3cret"),
+		client: srv.Client(),
+	}
+
+	err := n.Notify(context.Background(), Cycle{
+		At:          time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Results:     []CheckResult{{Service: svc, Error: "timeout", Latency: 2 * time.Second}},
+		Transitions: []Transition{{Service: svc, ServiceName: "api", Type: "down", Error: "timeout"}},
+	})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(got) != 2 || got[0].event != webhookTransition || got[1].event != webhookCycle {
+		t.Fatalf("expected a transition then a cycle delivery, got %+v", got)
+	}
+
+	if want := "sha256=" + signBody([]byte("s3cret"), got[0].body); got[0].signature != want {
+		t.Errorf("expected signature %s, got %s", want, got[0].signature)
+	}
+
+	var transition webhookTransitionPayload
+	if err := json.Unmarshal(got[0].body, &transition); err != nil {
+		t.Fatalf("decode transition: %v", err)
+	}
+	if transition.Type != "down" || transition.Service.Name != "api" || transition.Error != "timeout" {
+		t.Errorf("unexpected transition payload %+v", transition)
+	}
+
+	var cycle webhookCyclePayload
+	if err := json.Unmarshal(got[1].body, &cycle); err != nil {
+		t.Fatalf("decode cycle: %v", err)
+	}
+	if cycle.Total != 1 || cycle.Down != 1 || cycle.Services[0].LatencyMs != 2000 {
+		t.Errorf("unexpected cycle payload %+v", cycle)
+	}
+}
+
+func TestWebhookNotifierReportsFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n := &webhookNotifier{cfg: WebhookConfig{URL: srv.URL, Events: []string{webhookCycle}}, client: srv.Client()}
+	if err := n.Notify(context.Background(), Cycle{At: time.Now()}); err == nil {
+		t.Errorf("expected an error for a 502 answer")
+	}
+}